@@ -160,6 +160,16 @@ func main() {
 			}
 
 		endOfSession:
+			ev := faker.Engagement(session)
+			err := store.StoreEngagement(ctx, &ev)
+			if err != nil {
+				logger.Fatal("failed to store engagement event", err)
+			}
+			vital := faker.WebVital(session)
+			err = store.StoreWebVital(ctx, &vital)
+			if err != nil {
+				logger.Fatal("failed to store web vital event", err)
+			}
 			totalSessions.Add(1)
 		}
 	}
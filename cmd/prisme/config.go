@@ -9,23 +9,53 @@ import (
 	"github.com/prismelabs/analytics/pkg/chdb"
 	"github.com/prismelabs/analytics/pkg/clickhouse"
 	"github.com/prismelabs/analytics/pkg/options"
+	"github.com/prismelabs/analytics/pkg/services/apps"
+	"github.com/prismelabs/analytics/pkg/services/botscore"
+	"github.com/prismelabs/analytics/pkg/services/bottraffic"
+	"github.com/prismelabs/analytics/pkg/services/currency"
 	"github.com/prismelabs/analytics/pkg/services/eventdb"
+	"github.com/prismelabs/analytics/pkg/services/eventdedup"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
+	"github.com/prismelabs/analytics/pkg/services/eventschema"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/eventtime"
+	"github.com/prismelabs/analytics/pkg/services/ingestionfilter"
 	"github.com/prismelabs/analytics/pkg/services/originregistry"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+	"github.com/prismelabs/analytics/pkg/services/privacysignals"
+	"github.com/prismelabs/analytics/pkg/services/ratelimiter"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/sitesearch"
+	"github.com/prismelabs/analytics/pkg/services/trafficexclusion"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 )
 
 type Config struct {
-	Proxy          options.Proxy
-	Server         options.Server
-	Admin          options.Admin
-	ChDb           chdb.Config
-	Clickhouse     clickhouse.Config
-	Sessionstore   sessionstore.Config
-	Fiber          fiber.Config
-	EventDb        eventdb.Config
-	EventStore     eventstore.Config
-	OriginRegistry originregistry.Config
+	Proxy            options.Proxy
+	Server           options.Server
+	Admin            options.Admin
+	ChDb             chdb.Config
+	Clickhouse       clickhouse.Config
+	Sessionstore     sessionstore.Config
+	Fiber            fiber.Config
+	EventDb          eventdb.Config
+	EventStore       eventstore.Config
+	OriginRegistry   originregistry.Config
+	Currency         currency.Config
+	EventSchema      eventschema.Config
+	EventLimits      eventlimits.Config
+	PathRules        pathrules.Config
+	UrlScrubber      urlscrubber.Config
+	SiteSearch       sitesearch.Config
+	IngestionFilter  ingestionfilter.Config
+	TrafficExclusion trafficexclusion.Config
+	PrivacySignals   privacysignals.Config
+	BotTraffic       bottraffic.Config
+	BotScore         botscore.Config
+	RateLimiter      ratelimiter.Config
+	Apps             apps.Config
+	EventDedup       eventdedup.Config
+	EventTime        eventtime.Config
 }
 
 // RegisterOptions registers options in provided Figue.
@@ -39,6 +69,21 @@ func (c *Config) RegisterOptions(figue *configue.Figue) {
 	c.EventDb.RegisterOptions(figue)
 	c.EventStore.RegisterOptions(figue)
 	c.OriginRegistry.RegisterOptions(figue)
+	c.Currency.RegisterOptions(figue)
+	c.EventSchema.RegisterOptions(figue)
+	c.EventLimits.RegisterOptions(figue)
+	c.PathRules.RegisterOptions(figue)
+	c.UrlScrubber.RegisterOptions(figue)
+	c.SiteSearch.RegisterOptions(figue)
+	c.IngestionFilter.RegisterOptions(figue)
+	c.TrafficExclusion.RegisterOptions(figue)
+	c.PrivacySignals.RegisterOptions(figue)
+	c.BotTraffic.RegisterOptions(figue)
+	c.BotScore.RegisterOptions(figue)
+	c.RateLimiter.RegisterOptions(figue)
+	c.Apps.RegisterOptions(figue)
+	c.EventDedup.RegisterOptions(figue)
+	c.EventTime.RegisterOptions(figue)
 }
 
 // Validate validates configuration options.
@@ -56,7 +101,22 @@ func (c *Config) Validate() error {
 		c.Sessionstore.Validate(),
 		c.EventDb.Validate(),
 		c.EventStore.Validate(),
-		c.OriginRegistry.Validate())
+		c.OriginRegistry.Validate(),
+		c.Currency.Validate(),
+		c.EventSchema.Validate(),
+		c.EventLimits.Validate(),
+		c.PathRules.Validate(),
+		c.UrlScrubber.Validate(),
+		c.SiteSearch.Validate(),
+		c.IngestionFilter.Validate(),
+		c.TrafficExclusion.Validate(),
+		c.PrivacySignals.Validate(),
+		c.BotTraffic.Validate(),
+		c.BotScore.Validate(),
+		c.RateLimiter.Validate(),
+		c.Apps.Validate(),
+		c.EventDedup.Validate(),
+		c.EventTime.Validate())
 
 	switch c.EventDb.Driver {
 	case "clickhouse":
@@ -19,6 +19,9 @@ func main() {
 	case "default-config":
 		defaultConfig()
 
+	case "path-rules":
+		pathRules()
+
 	case "serve":
 		fallthrough
 	default:
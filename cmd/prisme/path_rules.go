@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/negrel/configue"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+)
+
+func pathRules() {
+	type Params struct {
+		File   string
+		Domain string
+	}
+	var params Params
+
+	flags := configue.NewFlag()
+	figue := configue.New(
+		"path-rules",
+		configue.ContinueOnError,
+		flags,
+	)
+	figue.Usage = func() {
+		_, _ = fmt.Fprintln(figue.Output(), "prisme - High-perfomance, self-hosted and privacy-focused web analytics service.")
+		_, _ = fmt.Fprintln(figue.Output())
+		_, _ = fmt.Fprintln(figue.Output(), "Normalize paths using path rewrite rules. Paths are read from arguments or")
+		_, _ = fmt.Fprintln(figue.Output(), "standard input (one per line).")
+		_, _ = fmt.Fprintln(figue.Output())
+		_, _ = fmt.Fprintln(figue.Output(), "Usage:")
+		_, _ = fmt.Fprintln(figue.Output(), "  prisme path-rules [FLAGS] [PATH...]")
+		_, _ = fmt.Fprintln(figue.Output())
+		_, _ = fmt.Fprintln(figue.Output(), "  prisme path-rules -file rules.json -domain www.example.com /orders/42")
+		_, _ = fmt.Fprintln(figue.Output())
+		figue.PrintDefaults()
+	}
+	figue.StringVar(&params.File, "file", "", "`filepath` to JSON path rewrite rules")
+	figue.StringVar(&params.Domain, "domain", "", "`domain` whose rules are applied")
+
+	err := figue.Parse()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		cliError(err)
+	}
+	if params.File == "" {
+		cliError(fmt.Errorf("invalid -file"))
+	}
+
+	rules, err := pathrules.LoadRules(params.File)
+	if err != nil {
+		cliError(err)
+	}
+	service := pathrules.NewServiceFromRules(rules)
+
+	printPath := func(path string) {
+		_, _ = fmt.Fprintf(os.Stdout, "%v\t%v\n", path, service.NormalizePath(params.Domain, path))
+	}
+
+	if paths := flags.Args(); len(paths) > 0 {
+		for _, path := range paths {
+			printPath(path)
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if path := scanner.Text(); path != "" {
+			printPath(path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		cliError(err)
+	}
+}
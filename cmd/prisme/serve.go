@@ -12,21 +12,36 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/storage/memory"
 	"github.com/negrel/configue"
 	"github.com/prismelabs/analytics/pkg/clickhouse"
 	"github.com/prismelabs/analytics/pkg/handlers"
 	"github.com/prismelabs/analytics/pkg/log"
 	"github.com/prismelabs/analytics/pkg/middlewares"
+	"github.com/prismelabs/analytics/pkg/services/apps"
+	"github.com/prismelabs/analytics/pkg/services/botscore"
+	"github.com/prismelabs/analytics/pkg/services/bottraffic"
+	"github.com/prismelabs/analytics/pkg/services/currency"
 	"github.com/prismelabs/analytics/pkg/services/eventdb"
+	"github.com/prismelabs/analytics/pkg/services/eventdedup"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
+	"github.com/prismelabs/analytics/pkg/services/eventschema"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/eventtime"
+	"github.com/prismelabs/analytics/pkg/services/ingestionfilter"
 	"github.com/prismelabs/analytics/pkg/services/ipgeolocator"
 	"github.com/prismelabs/analytics/pkg/services/originregistry"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+	"github.com/prismelabs/analytics/pkg/services/privacysignals"
+	"github.com/prismelabs/analytics/pkg/services/ratelimiter"
+	"github.com/prismelabs/analytics/pkg/services/referrerparser"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/sitesearch"
 	"github.com/prismelabs/analytics/pkg/services/stats"
 	"github.com/prismelabs/analytics/pkg/services/teardown"
+	"github.com/prismelabs/analytics/pkg/services/trafficexclusion"
 	"github.com/prismelabs/analytics/pkg/services/uaparser"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -151,8 +166,44 @@ func serve() {
 	if err != nil {
 		cliError(err)
 	}
-	stats := stats.NewService(eventDb, teardownService)
+	currencyService, err := currency.NewService(cfg.Currency, logger)
+	if err != nil {
+		cliError(err)
+	}
+	eventSchema, err := eventschema.NewService(cfg.EventSchema, logger, promRegistry)
+	if err != nil {
+		cliError(err)
+	}
+	eventLimits, err := eventlimits.NewService(cfg.EventLimits, eventDb, logger, promRegistry)
+	if err != nil {
+		cliError(err)
+	}
+	pathRules, err := pathrules.NewService(cfg.PathRules, logger)
+	if err != nil {
+		cliError(err)
+	}
+	urlScrubber := urlscrubber.NewService(cfg.UrlScrubber, logger, promRegistry)
+	siteSearch, err := sitesearch.NewService(cfg.SiteSearch, logger)
+	if err != nil {
+		cliError(err)
+	}
+	ingestionFilter, err := ingestionfilter.NewService(cfg.IngestionFilter, logger, promRegistry)
+	if err != nil {
+		cliError(err)
+	}
+	trafficExclusion, err := trafficexclusion.NewService(cfg.TrafficExclusion, logger, promRegistry)
+	if err != nil {
+		cliError(err)
+	}
+	privacySignals, err := privacysignals.NewService(cfg.PrivacySignals, logger, promRegistry)
+	if err != nil {
+		cliError(err)
+	}
+	botTraffic := bottraffic.NewService(cfg.BotTraffic, eventStore, logger, promRegistry)
+	botScoring := botscore.NewService(cfg.BotScore, logger, promRegistry)
+	stats := stats.NewService(eventDb, currencyService, teardownService)
 	uaParser := uaparser.NewService(logger, promRegistry)
+	referrerParser := referrerparser.NewService(logger, promRegistry)
 	ipGeolocator := ipgeolocator.NewMmdbService(logger, promRegistry)
 	saltManager := saltmanager.NewService(logger)
 	sessionStore := sessionstore.NewService(logger, cfg.Sessionstore, promRegistry)
@@ -160,6 +211,25 @@ func serve() {
 	if err != nil {
 		cliError(err)
 	}
+	appsService, err := apps.NewService(cfg.Apps, logger)
+	if err != nil {
+		cliError(err)
+	}
+	eventDedup := eventdedup.NewService(cfg.EventDedup, logger, promRegistry)
+	eventTime := eventtime.NewService(cfg.EventTime, logger, promRegistry)
+	rateLimiterStorage, err := ratelimiter.NewStorage(cfg.RateLimiter)
+	if err != nil {
+		cliError(err)
+	}
+	rateLimiter, err := ratelimiter.NewService(
+		cfg.RateLimiter,
+		rateLimiterStorage,
+		logger,
+		promRegistry,
+	)
+	if err != nil {
+		cliError(err)
+	}
 
 	// Create fiber app.
 	app := fiber.New(cfg.Fiber)
@@ -191,27 +261,46 @@ func serve() {
 
 		app.Use("/api/v1/healthcheck", handlers.HealthCheck())
 
+		app.Get("/api/v1/opt-out", handlers.GetOptOut(originRegistry))
+		app.Get("/api/v1/opt-in", handlers.GetOptIn(originRegistry))
+
 		eventCors := middlewares.EventsCors()
-		eventRateLimit := middlewares.EventsRateLimiter(
-			cfg.Server,
-			memory.New(memory.Config{
-				GCInterval: 10 * time.Second,
-			}),
-		)
+		appEvents := middlewares.AppEvents(appsService)
+		eventRateLimit := middlewares.EventsRateLimiter(cfg.Server, rateLimiter, saltManager)
 		nonRegisteredOriginFilter := middlewares.NonRegisteredOriginFilter(originRegistry)
+		internalTrafficExclusion := middlewares.InternalTrafficExclusion(cfg.Proxy, trafficExclusion)
+		ingestionFilterMiddleware := middlewares.IngestionFilter(ingestionFilter)
+		privacySignalsMiddleware := middlewares.PrivacySignals(privacySignals)
+		eventDecompression := middlewares.EventsDecompression(eventLimits)
+		eventTimestamp := middlewares.EventsTimestamp(eventTime)
+		eventDeduplication := middlewares.EventsDeduplication(eventDedup)
 		eventTimeout := middlewares.ApiEventsTimeout(cfg.Server)
 
 		app.Use("/api/v1/events/*",
 			eventCors,
+			appEvents,
 			eventRateLimit,
 			nonRegisteredOriginFilter,
+			internalTrafficExclusion,
+			ingestionFilterMiddleware,
+			privacySignalsMiddleware,
+			eventDecompression,
+			eventTimestamp,
+			eventDeduplication,
 			eventTimeout,
 		)
 
 		app.Use("/api/v1/noscript/events/*",
 			eventCors,
+			appEvents,
 			eventRateLimit,
 			nonRegisteredOriginFilter,
+			internalTrafficExclusion,
+			ingestionFilterMiddleware,
+			privacySignalsMiddleware,
+			eventDecompression,
+			eventTimestamp,
+			eventDeduplication,
 			eventTimeout,
 			// Prevent caching of GET responses.
 			middlewares.NoscriptHandlersCache(),
@@ -224,6 +313,14 @@ func serve() {
 				ipGeolocator,
 				saltManager,
 				sessionStore,
+				eventLimits,
+				pathRules,
+				urlScrubber,
+				siteSearch,
+				referrerParser,
+				privacySignals,
+				botTraffic,
+				botScoring,
 			),
 		)
 		app.Get("/api/v1/noscript/events/pageviews",
@@ -233,6 +330,14 @@ func serve() {
 				ipGeolocator,
 				saltManager,
 				sessionStore,
+				eventLimits,
+				pathRules,
+				urlScrubber,
+				siteSearch,
+				referrerParser,
+				privacySignals,
+				botTraffic,
+				botScoring,
 			),
 		)
 
@@ -241,12 +346,22 @@ func serve() {
 				eventStore,
 				saltManager,
 				sessionStore,
+				currencyService,
+				eventSchema,
+				eventLimits,
+				pathRules,
+				urlScrubber,
 			)),
 		)
 		app.Get("/api/v1/noscript/events/custom/:name",
 			handlers.GetNoscriptEventsCustom(eventStore,
 				saltManager,
 				sessionStore,
+				currencyService,
+				eventSchema,
+				eventLimits,
+				pathRules,
+				urlScrubber,
 			),
 		)
 
@@ -255,6 +370,8 @@ func serve() {
 				eventStore,
 				saltManager,
 				sessionStore,
+				pathRules,
+				urlScrubber,
 			),
 		)
 		app.Get("/api/v1/noscript/events/outbound-links",
@@ -262,6 +379,8 @@ func serve() {
 				eventStore,
 				sessionStore,
 				saltManager,
+				pathRules,
+				urlScrubber,
 			),
 		)
 
@@ -270,13 +389,48 @@ func serve() {
 				eventStore,
 				saltManager,
 				sessionStore,
+				pathRules,
+				urlScrubber,
+			),
+		)
+
+		app.Post("/api/v1/events/engagement",
+			handlers.PostEventsEngagement(
+				eventStore,
+				saltManager,
+				sessionStore,
+				pathRules,
+				urlScrubber,
+				botScoring,
+			),
+		)
+
+		app.Post("/api/v1/events/web-vitals",
+			handlers.PostEventsWebVitals(
+				eventStore,
+				saltManager,
+				sessionStore,
+				pathRules,
+				urlScrubber,
+			),
+		)
+
+		app.Post("/api/v1/events/errors",
+			handlers.PostEventsErrors(
+				eventStore,
+				saltManager,
+				sessionStore,
+				pathRules,
+				urlScrubber,
 			),
 		)
 
-		stats := handlers.GetStatsHandlers(stats)
+		stats := handlers.GetStatsHandlers(stats, currencyService)
 		app.Use("/api/v1/stats/*", middlewares.StatsCors(cfg.Server))
 		app.Get("/api/v1/stats/bounces", stats.Bounces)
 		app.Get("/api/v1/stats/visitors", stats.Visitors)
+		app.Get("/api/v1/stats/new-visitors", stats.NewVisitors)
+		app.Get("/api/v1/stats/returning-visitors", stats.ReturningVisitors)
 		app.Get("/api/v1/stats/sessions", stats.Sessions)
 		app.Get("/api/v1/stats/sessions-duration", stats.SessionsDuration)
 		app.Get("/api/v1/stats/pageviews", stats.PageViews)
@@ -285,12 +439,37 @@ func serve() {
 		app.Get("/api/v1/stats/top-entry-pages", stats.TopEntryPages)
 		app.Get("/api/v1/stats/top-exit-pages", stats.TopExitPages)
 		app.Get("/api/v1/stats/top-referrers", stats.TopReferrers)
+		app.Get("/api/v1/stats/top-sources", stats.TopSources)
+		app.Get("/api/v1/stats/top-channels", stats.TopChannels)
 		app.Get("/api/v1/stats/top-utm-sources", stats.TopUtmSources)
 		app.Get("/api/v1/stats/top-utm-mediums", stats.TopUtmMediums)
 		app.Get("/api/v1/stats/top-utm-campaigns", stats.TopUtmCampaigns)
 		app.Get("/api/v1/stats/top-countries", stats.TopCountries)
 		app.Get("/api/v1/stats/top-operating-systems", stats.TopOperatingSystems)
 		app.Get("/api/v1/stats/top-browsers", stats.TopBrowsers)
+		app.Get("/api/v1/stats/new-vs-returning", stats.NewVsReturning)
+		app.Get("/api/v1/stats/pages", stats.PagesReport)
+		app.Get("/api/v1/stats/web-vitals", stats.WebVitals)
+		app.Get("/api/v1/stats/web-vitals/pages", stats.WebVitalsPages)
+		app.Get("/api/v1/stats/web-vitals/browsers", stats.WebVitalsBrowsers)
+		app.Get("/api/v1/stats/web-vitals/countries", stats.WebVitalsCountries)
+		app.Get("/api/v1/stats/top-errors", stats.TopErrors)
+		app.Get("/api/v1/stats/error-rate", stats.ErrorRate)
+		app.Get("/api/v1/stats/revenue", stats.Revenue)
+		app.Get("/api/v1/stats/revenue/sources", stats.RevenueSources)
+		app.Get("/api/v1/stats/revenue/campaigns", stats.RevenueCampaigns)
+		app.Get("/api/v1/stats/revenue/countries", stats.RevenueCountries)
+		app.Get("/api/v1/stats/revenue/pages", stats.RevenuePages)
+		app.Get("/api/v1/stats/custom-property", stats.CustomProperty)
+		app.Get("/api/v1/stats/top-pageview-property-values", stats.TopPageviewPropertyValues)
+		app.Get("/api/v1/stats/top-search-terms", stats.TopSearchTerms)
+		app.Get("/api/v1/stats/searches-per-session", stats.SearchesPerSession)
+		app.Get("/api/v1/stats/top-search-exits", stats.TopSearchExits)
+		app.Get("/api/v1/stats/bot-pageviews", stats.BotPageViews)
+		app.Get("/api/v1/stats/top-bots", stats.TopBots)
+		app.Get("/api/v1/stats/top-crawled-pages", stats.TopCrawledPages)
+		app.Get("/api/v1/stats/top-app-versions", stats.TopAppVersions)
+		app.Get("/api/v1/stats/top-os-versions", stats.TopOsVersions)
 	}
 
 	// Admin and profiling server.
@@ -302,6 +481,9 @@ func serve() {
 				logger.Err("failed to write admin response body", err)
 			}
 		})
+		adminEventSchemas := handlers.AdminEventSchemas(eventSchema)
+		http.Handle("/api/v1/eventschemas", adminEventSchemas)
+		http.Handle("/api/v1/eventschemas/", adminEventSchemas)
 		http.Handle("/metrics", promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{
 			ErrorLog:            log.PrometheusLogger(logger),
 			ErrorHandling:       promhttp.HTTPErrorOnError,
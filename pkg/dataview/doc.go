@@ -1,4 +1,4 @@
 // This package contains common interfaces and implementation to retrieve data
-// from an HTTP request (JSON body, query params).
+// from an HTTP request (JSON or protocol buffers body, query params).
 
 package dataview
@@ -3,6 +3,7 @@ package dataview
 import (
 	"encoding/json/jsontext"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 
@@ -13,37 +14,122 @@ import (
 var (
 	ErrInvalidData   = errors.New("invalid data")
 	ErrNotJsonObject = errors.New("not a JSON object")
+	ErrTooManyKeys   = errors.New("too many keys")
+	ErrKeyTooLong    = errors.New("key too long")
+	ErrValueTooLong  = errors.New("value too long")
 )
 
+// KvLimits defines limits enforced by KvCollector. Zero values means no limit.
+type KvLimits struct {
+	MaxKeys        int
+	MaxKeyLength   int
+	MaxValueLength int
+}
+
+// check checks that a new key value pair can be collected given number of
+// already collected keys.
+func (kvl KvLimits) check(collected int, key string, value []byte) error {
+	if kvl.MaxKeys > 0 && collected >= kvl.MaxKeys {
+		return fmt.Errorf("%w: maximum is %v", ErrTooManyKeys, kvl.MaxKeys)
+	}
+	if kvl.MaxKeyLength > 0 && len(key) > kvl.MaxKeyLength {
+		return fmt.Errorf("%w: maximum length is %v", ErrKeyTooLong, kvl.MaxKeyLength)
+	}
+	if kvl.MaxValueLength > 0 && len(value) > kvl.MaxValueLength {
+		return fmt.Errorf("%w: maximum length is %v", ErrValueTooLong, kvl.MaxValueLength)
+	}
+	return nil
+}
+
 // KvCollector define objects that can collect a set of keys and values.
+// Values are raw JSON encoded values and types contains detected type of each
+// value.
 type KvCollector interface {
-	CollectKeysValues() (keys, values []string, err error)
+	CollectKeysValues() (keys, values []string, types []ValueType, err error)
+}
+
+// ValueType define type of a JSON encoded value.
+type ValueType uint8
+
+// ValueType enum.
+const (
+	NullValue ValueType = iota
+	StringValue
+	NumberValue
+	BoolValue
+	ObjectValue
+	ArrayValue
+)
+
+// String implements fmt.Stringer.
+func (vt ValueType) String() string {
+	switch vt {
+	case NullValue:
+		return "null"
+	case StringValue:
+		return "string"
+	case NumberValue:
+		return "number"
+	case BoolValue:
+		return "bool"
+	case ObjectValue:
+		return "object"
+	case ArrayValue:
+		return "array"
+	default:
+		panic("unknown value type")
+	}
+}
+
+// ValueTypeOf returns type of given valid JSON encoded value.
+func ValueTypeOf(value []byte) ValueType {
+	for _, c := range value {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '"':
+			return StringValue
+		case 't', 'f':
+			return BoolValue
+		case '{':
+			return ObjectValue
+		case '[':
+			return ArrayValue
+		case 'n':
+			return NullValue
+		default:
+			return NumberValue
+		}
+	}
+
+	return NullValue
 }
 
 // NewJsonKvCollector returns a new JsonKvCollector that will read a JSON object
 // from provided io.Reader.
-func NewJsonKvCollector(r io.Reader) JsonKvCollector {
-	return JsonKvCollector{decoder: jsontext.NewDecoder(r)}
+func NewJsonKvCollector(r io.Reader, limits KvLimits) JsonKvCollector {
+	return JsonKvCollector{decoder: jsontext.NewDecoder(r), limits: limits}
 }
 
 // JsonKvCollector collects keys and values from a JSON object.
 // Values are raw JSON encoded value: string values will contains quotes.
 type JsonKvCollector struct {
 	decoder *jsontext.Decoder
+	limits  KvLimits
 }
 
 // CollectKeysValues implements KvCollector.
 // JsonKvCollector collects values as raw JSON encoded string: string value will
 // contains quotes.
-func (jkc JsonKvCollector) CollectKeysValues() (keys, values []string, err error) {
+func (jkc JsonKvCollector) CollectKeysValues() (keys, values []string, types []ValueType, err error) {
 	switch jkc.decoder.PeekKind() {
 	case '{':
 		_, err := jkc.decoder.ReadToken()
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	default:
-		return nil, nil, ErrNotJsonObject
+		return nil, nil, nil, ErrNotJsonObject
 	}
 
 	for jkc.decoder.PeekKind() != '}' {
@@ -52,13 +138,22 @@ func (jkc JsonKvCollector) CollectKeysValues() (keys, values []string, err error
 		if err != nil {
 			return
 		}
-		keys = append(keys, unquote(key.String()))
+		// Key must be copied before next read.
+		unquotedKey := unquote(key.String())
 
 		val, err = jkc.decoder.ReadValue()
 		if err != nil {
 			return
 		}
+
+		err = jkc.limits.check(len(keys), unquotedKey, val)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		keys = append(keys, unquotedKey)
 		values = append(values, val.String())
+		types = append(types, ValueTypeOf(val))
 	}
 
 	return
@@ -75,10 +170,11 @@ type FasthttpArgsKeysValuesCollector struct {
 	Args           *fasthttp.Args
 	Prefix         string
 	ValueValidator func([]byte) bool
+	Limits         KvLimits
 }
 
 // CollectKeysValues implements keysValuesCollector.
-func (fakvc FasthttpArgsKeysValuesCollector) CollectKeysValues() (keys, values []string, err error) {
+func (fakvc FasthttpArgsKeysValuesCollector) CollectKeysValues() (keys, values []string, types []ValueType, err error) {
 	fakvc.Args.VisitAll(func(keyBytes, valueBytes []byte) {
 		if err == nil && len(keyBytes) > len(fakvc.Prefix) &&
 			strings.HasPrefix(utils.UnsafeString(keyBytes), fakvc.Prefix) {
@@ -86,14 +182,20 @@ func (fakvc FasthttpArgsKeysValuesCollector) CollectKeysValues() (keys, values [
 			// Validate arg.
 			if valid := fakvc.ValueValidator(valueBytes); !valid {
 				err = ErrInvalidData
+			} else {
+				err = fakvc.Limits.check(len(keys), utils.UnsafeString(keyBytes[len(fakvc.Prefix):]), valueBytes)
+			}
+			if err != nil {
 				keys = nil
 				values = nil
+				types = nil
 				return
 			}
 
 			// Copy key and value.
 			keys = append(keys, string(keyBytes[len(fakvc.Prefix):]))
 			values = append(values, string(valueBytes))
+			types = append(types, ValueTypeOf(valueBytes))
 		}
 	})
 
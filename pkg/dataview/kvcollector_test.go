@@ -11,30 +11,53 @@ import (
 
 func TestJsonKvCollector(t *testing.T) {
 	t.Run("CollectKeysValues/Empty", func(t *testing.T) {
-		kvCollector := NewJsonKvCollector(bytes.NewReader([]byte(`{}`)))
-		keys, values, err := kvCollector.CollectKeysValues()
+		kvCollector := NewJsonKvCollector(bytes.NewReader([]byte(`{}`)), KvLimits{})
+		keys, values, types, err := kvCollector.CollectKeysValues()
 		require.NoError(t, err)
 
 		require.Nil(t, keys)
 		require.Nil(t, values)
+		require.Nil(t, types)
 	})
 	t.Run("CollectKeysValues/NonEmpty", func(t *testing.T) {
-		kvCollector := NewJsonKvCollector(bytes.NewReader([]byte(`{"foo":"bar","bool":true,"number":1.123,"null":null,"obj":{"foo":"bar","bool":true}}`)))
-		keys, values, err := kvCollector.CollectKeysValues()
+		kvCollector := NewJsonKvCollector(bytes.NewReader([]byte(`{"foo":"bar","bool":true,"number":1.123,"null":null,"obj":{"foo":"bar","bool":true},"arr":[-1]}`)), KvLimits{})
+		keys, values, types, err := kvCollector.CollectKeysValues()
 		require.NoError(t, err)
 
-		require.Equal(t, []string{"foo", "bool", "number", "null", "obj"}, keys)
-		require.Equal(t, []string{`"bar"`, "true", "1.123", "null", `{"foo":"bar","bool":true}`}, values)
+		require.Equal(t, []string{"foo", "bool", "number", "null", "obj", "arr"}, keys)
+		require.Equal(t, []string{`"bar"`, "true", "1.123", "null", `{"foo":"bar","bool":true}`, "[-1]"}, values)
+		require.Equal(t, []ValueType{StringValue, BoolValue, NumberValue, NullValue, ObjectValue, ArrayValue}, types)
 	})
 
 	t.Run("CollectKeysValues/MalformedJson", func(t *testing.T) {
 		// Missing closing brace.
 		kvCollector := NewJsonKvCollector(bytes.NewReader([]byte(
 			`{"foo":"bar","bool":true,"number":1,"null":null,"obj":{"foo":"bar","bool":true}`,
-		)))
-		_, _, err := kvCollector.CollectKeysValues()
+		)), KvLimits{})
+		_, _, _, err := kvCollector.CollectKeysValues()
 		require.Error(t, err)
 	})
+
+	t.Run("CollectKeysValues/Limits", func(t *testing.T) {
+		body := []byte(`{"foo":"bar","number":1}`)
+
+		_, _, _, err := NewJsonKvCollector(bytes.NewReader(body), KvLimits{MaxKeys: 1}).CollectKeysValues()
+		require.ErrorIs(t, err, ErrTooManyKeys)
+
+		_, _, _, err = NewJsonKvCollector(bytes.NewReader(body), KvLimits{MaxKeyLength: 3}).CollectKeysValues()
+		require.ErrorIs(t, err, ErrKeyTooLong)
+
+		_, _, _, err = NewJsonKvCollector(bytes.NewReader(body), KvLimits{MaxValueLength: 4}).CollectKeysValues()
+		require.ErrorIs(t, err, ErrValueTooLong)
+
+		keys, _, _, err := NewJsonKvCollector(bytes.NewReader(body), KvLimits{
+			MaxKeys:        2,
+			MaxKeyLength:   6,
+			MaxValueLength: 5,
+		}).CollectKeysValues()
+		require.NoError(t, err)
+		require.Equal(t, []string{"foo", "number"}, keys)
+	})
 }
 
 func TestFasthttpArgsKvCollector(t *testing.T) {
@@ -46,11 +69,12 @@ func TestFasthttpArgsKvCollector(t *testing.T) {
 		kvCollector.Args.Add("foo", `"bar"`)
 		kvCollector.Args.Add("number", "1")
 
-		keys, values, err := kvCollector.CollectKeysValues()
+		keys, values, types, err := kvCollector.CollectKeysValues()
 		require.NoError(t, err)
 
 		require.Equal(t, []string{"foo", "number"}, keys)
 		require.Equal(t, []string{`"bar"`, "1"}, values)
+		require.Equal(t, []ValueType{StringValue, NumberValue}, types)
 	})
 
 	t.Run("CollectKeysValues/FooPrefix", func(t *testing.T) {
@@ -62,7 +86,7 @@ func TestFasthttpArgsKvCollector(t *testing.T) {
 		kvCollector.Args.Add("foo-foo", `"bar"`)
 		kvCollector.Args.Add("foo-bar", "1")
 
-		keys, values, err := kvCollector.CollectKeysValues()
+		keys, values, _, err := kvCollector.CollectKeysValues()
 		require.NoError(t, err)
 
 		require.Equal(t, []string{"foo", "bar"}, keys)
@@ -78,10 +102,28 @@ func TestFasthttpArgsKvCollector(t *testing.T) {
 		kvCollector.Args.Add("foo-foo", `bar`) // Missing double quote for a string.
 		kvCollector.Args.Add("foo-bar", "1")
 
-		keys, values, err := kvCollector.CollectKeysValues()
+		keys, values, types, err := kvCollector.CollectKeysValues()
 		require.Error(t, err)
 		require.ErrorIs(t, err, ErrInvalidData)
 		require.Nil(t, keys)
 		require.Nil(t, values)
+		require.Nil(t, types)
+	})
+
+	t.Run("CollectKeysValues/Limits", func(t *testing.T) {
+		kvCollector := FasthttpArgsKeysValuesCollector{
+			Args:           &fasthttp.Args{},
+			Prefix:         "foo-",
+			ValueValidator: json.Valid,
+			Limits:         KvLimits{MaxKeys: 1},
+		}
+		kvCollector.Args.Add("foo-foo", `"bar"`)
+		kvCollector.Args.Add("foo-bar", "1")
+
+		keys, values, types, err := kvCollector.CollectKeysValues()
+		require.ErrorIs(t, err, ErrTooManyKeys)
+		require.Nil(t, keys)
+		require.Nil(t, values)
+		require.Nil(t, types)
 	})
 }
@@ -0,0 +1,153 @@
+package dataview
+
+import (
+	"encoding/json/jsontext"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of Properties and Property messages of properties.proto
+// schema published in static directory.
+const (
+	propertiesField = 1
+
+	propertyKeyField    = 1
+	propertyStringField = 2
+	propertyNumberField = 3
+	propertyBoolField   = 4
+	propertyJsonField   = 5
+)
+
+// ProtobufKvCollector collects keys and values from a protocol buffers
+// encoded Properties message (see static/properties.proto). Values are
+// converted to raw JSON encoded values as JsonKvCollector does.
+type ProtobufKvCollector struct {
+	Body   []byte
+	Limits KvLimits
+}
+
+// CollectKeysValues implements KvCollector.
+func (pkc ProtobufKvCollector) CollectKeysValues() (keys, values []string, types []ValueType, err error) {
+	b := pkc.Body
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, nil, nil, ErrInvalidData
+		}
+		b = b[n:]
+
+		// Skip unknown fields.
+		if num != propertiesField || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, nil, nil, ErrInvalidData
+			}
+			b = b[n:]
+			continue
+		}
+
+		property, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, nil, nil, ErrInvalidData
+		}
+		b = b[n:]
+
+		key, value, valueType, err := decodeProperty(property)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		err = pkc.Limits.check(len(keys), key, value)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values = append(values, string(value))
+		types = append(types, valueType)
+	}
+
+	return
+}
+
+// decodeProperty decodes a Property message and returns its key and raw JSON
+// encoded value. Property without value is a null value.
+func decodeProperty(b []byte) (key string, value []byte, valueType ValueType, err error) {
+	value = []byte("null")
+	valueType = NullValue
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", nil, 0, ErrInvalidData
+		}
+		b = b[n:]
+
+		switch {
+		case num == propertyKeyField && typ == protowire.BytesType:
+			var k []byte
+			k, n = protowire.ConsumeBytes(b)
+			if n < 0 || !utf8.Valid(k) {
+				return "", nil, 0, ErrInvalidData
+			}
+			key = string(k)
+
+		case num == propertyStringField && typ == protowire.BytesType:
+			var str []byte
+			str, n = protowire.ConsumeBytes(b)
+			if n < 0 {
+				return "", nil, 0, ErrInvalidData
+			}
+			value, err = jsontext.AppendQuote(nil, str)
+			if err != nil {
+				return "", nil, 0, ErrInvalidData
+			}
+			valueType = StringValue
+
+		case num == propertyNumberField && typ == protowire.Fixed64Type:
+			var bits uint64
+			bits, n = protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return "", nil, 0, ErrInvalidData
+			}
+			f := math.Float64frombits(bits)
+			// NaN and infinities aren't valid JSON numbers.
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return "", nil, 0, ErrInvalidData
+			}
+			value = strconv.AppendFloat(nil, f, 'g', -1, 64)
+			valueType = NumberValue
+
+		case num == propertyBoolField && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			if n < 0 {
+				return "", nil, 0, ErrInvalidData
+			}
+			value = strconv.AppendBool(nil, v != 0)
+			valueType = BoolValue
+
+		case num == propertyJsonField && typ == protowire.BytesType:
+			var raw []byte
+			raw, n = protowire.ConsumeBytes(b)
+			if n < 0 || !jsontext.Value(raw).IsValid() {
+				return "", nil, 0, ErrInvalidData
+			}
+			value = raw
+			valueType = ValueTypeOf(raw)
+
+		default:
+			// Skip unknown fields.
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return "", nil, 0, ErrInvalidData
+			}
+		}
+		b = b[n:]
+	}
+
+	return key, value, valueType, nil
+}
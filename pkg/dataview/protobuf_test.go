@@ -0,0 +1,106 @@
+package dataview
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestProtobufKvCollector(t *testing.T) {
+	property := func(key string, appendValue func([]byte) []byte) []byte {
+		var prop []byte
+		prop = protowire.AppendTag(prop, propertyKeyField, protowire.BytesType)
+		prop = protowire.AppendString(prop, key)
+		if appendValue != nil {
+			prop = appendValue(prop)
+		}
+
+		var b []byte
+		b = protowire.AppendTag(b, propertiesField, protowire.BytesType)
+		return protowire.AppendBytes(b, prop)
+	}
+	stringValue := func(str string) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, propertyStringField, protowire.BytesType)
+			return protowire.AppendString(b, str)
+		}
+	}
+	numberValue := func(f float64) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, propertyNumberField, protowire.Fixed64Type)
+			return protowire.AppendFixed64(b, math.Float64bits(f))
+		}
+	}
+	boolValue := func(v bool) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, propertyBoolField, protowire.VarintType)
+			return protowire.AppendVarint(b, protowire.EncodeBool(v))
+		}
+	}
+	jsonValue := func(raw string) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, propertyJsonField, protowire.BytesType)
+			return protowire.AppendString(b, raw)
+		}
+	}
+
+	t.Run("CollectKeysValues/Empty", func(t *testing.T) {
+		keys, values, types, err := ProtobufKvCollector{}.CollectKeysValues()
+		require.NoError(t, err)
+
+		require.Nil(t, keys)
+		require.Nil(t, values)
+		require.Nil(t, types)
+	})
+
+	t.Run("CollectKeysValues/NonEmpty", func(t *testing.T) {
+		var body []byte
+		body = append(body, property("foo", stringValue(`"bar"`))...)
+		body = append(body, property("bool", boolValue(true))...)
+		body = append(body, property("number", numberValue(1.123))...)
+		body = append(body, property("null", nil)...)
+		body = append(body, property("obj", jsonValue(`{"foo":"bar","bool":true}`))...)
+		body = append(body, property("arr", jsonValue("[-1]"))...)
+		// Unknown field.
+		body = protowire.AppendTag(body, 42, protowire.VarintType)
+		body = protowire.AppendVarint(body, 1)
+
+		keys, values, types, err := ProtobufKvCollector{Body: body}.CollectKeysValues()
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"foo", "bool", "number", "null", "obj", "arr"}, keys)
+		require.Equal(t, []string{`"\"bar\""`, "true", "1.123", "null", `{"foo":"bar","bool":true}`, "[-1]"}, values)
+		require.Equal(t, []ValueType{StringValue, BoolValue, NumberValue, NullValue, ObjectValue, ArrayValue}, types)
+	})
+
+	t.Run("CollectKeysValues/Malformed", func(t *testing.T) {
+		body := property("foo", stringValue("bar"))
+
+		// Truncated message.
+		_, _, _, err := ProtobufKvCollector{Body: body[:len(body)-1]}.CollectKeysValues()
+		require.ErrorIs(t, err, ErrInvalidData)
+
+		// Invalid JSON value.
+		_, _, _, err = ProtobufKvCollector{Body: property("foo", jsonValue("{"))}.CollectKeysValues()
+		require.ErrorIs(t, err, ErrInvalidData)
+
+		// NaN isn't a valid JSON number.
+		_, _, _, err = ProtobufKvCollector{Body: property("foo", numberValue(math.NaN()))}.CollectKeysValues()
+		require.ErrorIs(t, err, ErrInvalidData)
+	})
+
+	t.Run("CollectKeysValues/Limits", func(t *testing.T) {
+		body := append(property("foo", stringValue("bar")), property("number", numberValue(1))...)
+
+		_, _, _, err := ProtobufKvCollector{Body: body, Limits: KvLimits{MaxKeys: 1}}.CollectKeysValues()
+		require.ErrorIs(t, err, ErrTooManyKeys)
+
+		_, _, _, err = ProtobufKvCollector{Body: body, Limits: KvLimits{MaxKeyLength: 3}}.CollectKeysValues()
+		require.ErrorIs(t, err, ErrKeyTooLong)
+
+		_, _, _, err = ProtobufKvCollector{Body: body, Limits: KvLimits{MaxValueLength: 4}}.CollectKeysValues()
+		require.ErrorIs(t, err, ErrValueTooLong)
+	})
+}
@@ -0,0 +1,5 @@
+-- Revenue amount and ISO 4217 currency code of custom events. Events without
+-- revenue have an empty currency.
+ALTER TABLE events_custom
+  ADD COLUMN revenue Decimal(18, 4) DEFAULT 0,
+  ADD COLUMN currency LowCardinality(String) DEFAULT '';
@@ -0,0 +1,20 @@
+-- Typed views of custom events properties values. Arrays are aligned with keys
+-- and values columns, a NULL element means that value has a different type.
+-- Default expressions derive typed values of existing events from raw JSON
+-- values.
+ALTER TABLE events_custom
+  ADD COLUMN value_types Array(Enum8('null' = 0, 'string' = 1, 'number' = 2, 'bool' = 3, 'object' = 4, 'array' = 5))
+    DEFAULT arrayMap(v -> multiIf(
+      startsWith(v, '"'), 'string',
+      v IN ('true', 'false'), 'bool',
+      startsWith(v, '{'), 'object',
+      startsWith(v, '['), 'array',
+      v = 'null', 'null',
+      'number'
+    ), values),
+  ADD COLUMN string_values Array(Nullable(String))
+    DEFAULT arrayMap(v -> if(startsWith(v, '"'), JSONExtractString(concat('[', v, ']'), 1), NULL), values),
+  ADD COLUMN number_values Array(Nullable(Float64))
+    DEFAULT arrayMap(v -> toFloat64OrNull(v), values),
+  ADD COLUMN bool_values Array(Nullable(Bool))
+    DEFAULT arrayMap(v -> if(v IN ('true', 'false'), v = 'true', NULL), values);
@@ -0,0 +1,2 @@
+-- Custom events violating schema registry of their domain.
+ALTER TABLE events_custom ADD COLUMN invalid Bool DEFAULT false;
@@ -0,0 +1,23 @@
+-- Custom properties of pageviews. Properties of sessions rows are the ones of
+-- exit pageview.
+ALTER TABLE sessions
+  ADD COLUMN exit_keys Array(String),
+  ADD COLUMN exit_values Array(String);
+ALTER TABLE pageviews
+  ADD COLUMN keys Array(String),
+  ADD COLUMN values Array(String);
+
+DROP TABLE pageviews_mv;
+
+CREATE MATERIALIZED VIEW pageviews_mv TO pageviews AS
+  SELECT
+    exit_timestamp AS timestamp,
+    domain,
+    exit_path AS path,
+    visitor_id,
+    session_uuid,
+    exit_status AS status,
+    exit_keys AS keys,
+    exit_values AS values
+  FROM sessions
+  WHERE sign = 1;
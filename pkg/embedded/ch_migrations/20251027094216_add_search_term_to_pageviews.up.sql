@@ -0,0 +1,19 @@
+-- Site search term of pageviews, empty if page isn't a search page.
+ALTER TABLE sessions ADD COLUMN exit_search_term String;
+ALTER TABLE pageviews ADD COLUMN search_term String;
+
+DROP TABLE pageviews_mv;
+
+CREATE MATERIALIZED VIEW pageviews_mv TO pageviews AS
+  SELECT
+    exit_timestamp AS timestamp,
+    domain,
+    exit_path AS path,
+    visitor_id,
+    session_uuid,
+    exit_status AS status,
+    exit_keys AS keys,
+    exit_values AS values,
+    exit_search_term AS search_term
+  FROM sessions
+  WHERE sign = 1;
@@ -0,0 +1,6 @@
+-- Normalized traffic source and acquisition channel of sessions. Default
+-- expressions derive them from referrer domain for existing sessions.
+ALTER TABLE sessions
+  ADD COLUMN source LowCardinality(String) DEFAULT referrer_domain,
+  ADD COLUMN channel LowCardinality(String)
+    DEFAULT if(referrer_domain = 'direct', 'direct', 'referral');
@@ -0,0 +1,10 @@
+CREATE TABLE bot_pageviews (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  bot_family LowCardinality(String),
+  status UInt16
+)
+ENGINE = MergeTree
+ORDER BY (domain, bot_family, toDate(timestamp), path)
+PARTITION BY toYYYYMM(timestamp);
@@ -0,0 +1,3 @@
+-- Bot score (0-100) of sessions computed from user agent, datacenter IP
+-- ranges, client hints, device request rate and engagement.
+ALTER TABLE sessions ADD COLUMN bot_score UInt8 DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Native app and operating system versions of app sessions.
+ALTER TABLE sessions
+  ADD COLUMN app_version LowCardinality(String) DEFAULT '',
+  ADD COLUMN os_version LowCardinality(String) DEFAULT '';
@@ -0,0 +1,6 @@
+-- Client supplied (X-Prisme-Event-Id) or random event id. Duplicated events
+-- are dropped by events deduplication middleware before being stored, this
+-- column is kept for debugging purposes.
+ALTER TABLE events_custom ADD COLUMN event_id String DEFAULT '' AFTER session_uuid;
+ALTER TABLE outbound_link_clicks ADD COLUMN event_id String DEFAULT '' AFTER session_uuid;
+ALTER TABLE file_downloads ADD COLUMN event_id String DEFAULT '' AFTER session_uuid;
@@ -0,0 +1,20 @@
+-- Page title of pageviews sent by tracker, empty if none was sent.
+ALTER TABLE sessions ADD COLUMN exit_title String DEFAULT '';
+ALTER TABLE pageviews ADD COLUMN title String;
+
+DROP TABLE pageviews_mv;
+
+CREATE MATERIALIZED VIEW pageviews_mv TO pageviews AS
+  SELECT
+    exit_timestamp AS timestamp,
+    domain,
+    exit_path AS path,
+    visitor_id,
+    session_uuid,
+    exit_status AS status,
+    exit_keys AS keys,
+    exit_values AS values,
+    exit_search_term AS search_term,
+    exit_title AS title
+  FROM sessions
+  WHERE sign = 1;
@@ -0,0 +1,366 @@
+-- Recreate events tables with an event_id column and a ReplacingMergeTree
+-- engine. Event id is client supplied (X-Prisme-Event-Id header or event-id
+-- query parameter) or random. Sorting keys end with event_id and contain at
+-- most the date of events so events delivered twice (tracker retries,
+-- sendBeacon) share the same key and are deduplicated on merge. Stats queries
+-- apply FINAL so duplicates that aren't merged yet aren't counted. Engine and
+-- sorting key can't be changed on an existing table. Existing events get a
+-- random id.
+
+-- Exit pageview event id of sessions rows, pageviews rows inherit it.
+ALTER TABLE sessions ADD COLUMN exit_event_id String DEFAULT '';
+
+RENAME TABLE pageviews TO pageviews_old;
+
+CREATE TABLE pageviews (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  visitor_id String,
+  is_anon Bool ALIAS startsWith(visitor_id, 'prisme_') OR startsWith(visitor_id, 'anon_'),
+  session_uuid UUID,
+  session_timestamp DateTime('UTC') ALIAS UUIDv7ToDateTime(session_uuid, 'UTC'),
+  session_id UInt128 ALIAS toUInt128(session_uuid),
+  event_id String,
+  status UInt16,
+  keys Array(String),
+  values Array(String),
+  search_term String,
+  title String
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (
+  domain,
+  path,
+  toDate(timestamp),
+  -- Due to historical reasons, UUIDs are sorted by their second half. UUIDs
+  -- should therefore not be used directly in a primary key, sorting key, or
+  -- partition key of a table.
+  toUInt128(session_uuid),
+  event_id
+)
+PARTITION BY toYYYYMM(UUIDv7ToDateTime(session_uuid, 'UTC'));
+
+-- Move rows to new table.
+INSERT INTO pageviews
+  SELECT
+    timestamp,
+    domain,
+    path,
+    visitor_id,
+    session_uuid,
+    toString(generateUUIDv4()) AS event_id,
+    status,
+    keys,
+    values,
+    search_term,
+    title
+  FROM pageviews_old;
+
+-- Delete old table.
+DROP TABLE pageviews_old;
+
+DROP TABLE pageviews_mv;
+
+CREATE MATERIALIZED VIEW pageviews_mv TO pageviews AS
+  SELECT
+    exit_timestamp AS timestamp,
+    domain,
+    exit_path AS path,
+    visitor_id,
+    session_uuid,
+    exit_event_id AS event_id,
+    exit_status AS status,
+    exit_keys AS keys,
+    exit_values AS values,
+    exit_search_term AS search_term,
+    exit_title AS title
+  FROM sessions
+  WHERE sign = 1;
+
+RENAME TABLE events_custom TO events_custom_old;
+
+CREATE TABLE events_custom (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  visitor_id String,
+  is_anon Bool ALIAS startsWith(visitor_id, 'prisme_') OR startsWith(visitor_id, 'anon_'),
+  session_uuid UUID,
+  session_timestamp DateTime('UTC') ALIAS UUIDv7ToDateTime(session_uuid, 'UTC'),
+  session_id UInt128 ALIAS toUInt128(session_uuid),
+  event_id String,
+  -- event name
+  name String,
+  -- JSON keys and values string
+  keys Array(String),
+  values Array(String),
+  revenue Decimal(18, 4) DEFAULT 0,
+  currency LowCardinality(String) DEFAULT '',
+  value_types Array(Enum8('null' = 0, 'string' = 1, 'number' = 2, 'bool' = 3, 'object' = 4, 'array' = 5)),
+  string_values Array(Nullable(String)),
+  number_values Array(Nullable(Float64)),
+  bool_values Array(Nullable(Bool)),
+  invalid Bool DEFAULT false
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (
+  domain,
+  -- Due to historical reasons, UUIDs are sorted by their second half. UUIDs
+  -- should therefore not be used directly in a primary key, sorting key, or
+  -- partition key of a table.
+  toUInt128(session_uuid),
+  name,
+  path,
+  event_id
+)
+PARTITION BY toUInt128(session_uuid) % 32;
+
+-- Move rows to new table.
+INSERT INTO events_custom
+  SELECT
+    timestamp,
+    domain,
+    path,
+    visitor_id,
+    session_uuid,
+    if(event_id = '', toString(generateUUIDv4()), event_id) AS event_id,
+    name,
+    keys,
+    values,
+    revenue,
+    currency,
+    value_types,
+    string_values,
+    number_values,
+    bool_values,
+    invalid
+  FROM events_custom_old;
+
+-- Delete old table.
+DROP TABLE events_custom_old;
+
+RENAME TABLE outbound_link_clicks TO outbound_link_clicks_old;
+
+CREATE TABLE outbound_link_clicks (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  visitor_id String,
+  is_anon Bool ALIAS startsWith(visitor_id, 'prisme_') OR startsWith(visitor_id, 'anon_'),
+  session_uuid UUID,
+  session_timestamp DateTime('UTC') ALIAS UUIDv7ToDateTime(session_uuid, 'UTC'),
+  session_id UInt128 ALIAS toUInt128(session_uuid),
+  event_id String,
+  link String
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (
+  domain,
+  -- Due to historical reasons, UUIDs are sorted by their second half. UUIDs
+  -- should therefore not be used directly in a primary key, sorting key, or
+  -- partition key of a table.
+  toUInt128(session_uuid),
+  path,
+  link,
+  event_id
+)
+PARTITION BY toUInt128(session_uuid) % 32;
+
+-- Move rows to new table.
+INSERT INTO outbound_link_clicks
+  SELECT
+    timestamp,
+    domain,
+    path,
+    visitor_id,
+    session_uuid,
+    if(event_id = '', toString(generateUUIDv4()), event_id) AS event_id,
+    link
+  FROM outbound_link_clicks_old;
+
+-- Delete old table.
+DROP TABLE outbound_link_clicks_old;
+
+RENAME TABLE file_downloads TO file_downloads_old;
+
+CREATE TABLE file_downloads (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  visitor_id String,
+  is_anon Bool ALIAS startsWith(visitor_id, 'prisme_') OR startsWith(visitor_id, 'anon_'),
+  session_uuid UUID,
+  session_timestamp DateTime('UTC') ALIAS UUIDv7ToDateTime(session_uuid, 'UTC'),
+  session_id UInt128 ALIAS toUInt128(session_uuid),
+  event_id String,
+  url String
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (
+  domain,
+  -- Due to historical reasons, UUIDs are sorted by their second half. UUIDs
+  -- should therefore not be used directly in a primary key, sorting key, or
+  -- partition key of a table.
+  toUInt128(session_uuid),
+  path,
+  url,
+  event_id
+)
+PARTITION BY toUInt128(session_uuid) % 32;
+
+-- Move rows to new table.
+INSERT INTO file_downloads
+  SELECT
+    timestamp,
+    domain,
+    path,
+    visitor_id,
+    session_uuid,
+    if(event_id = '', toString(generateUUIDv4()), event_id) AS event_id,
+    url
+  FROM file_downloads_old;
+
+-- Delete old table.
+DROP TABLE file_downloads_old;
+
+RENAME TABLE engagements TO engagements_old;
+
+CREATE TABLE engagements (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  visitor_id String,
+  is_anon Bool ALIAS startsWith(visitor_id, 'prisme_') OR startsWith(visitor_id, 'anon_'),
+  session_uuid UUID,
+  session_timestamp DateTime('UTC') ALIAS UUIDv7ToDateTime(session_uuid, 'UTC'),
+  session_id UInt128 ALIAS toUInt128(session_uuid),
+  event_id String,
+  -- Active time in milliseconds.
+  active_time UInt32,
+  -- Max scroll depth in percent.
+  scroll_depth UInt8
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (
+  domain,
+  -- Due to historical reasons, UUIDs are sorted by their second half. UUIDs
+  -- should therefore not be used directly in a primary key, sorting key, or
+  -- partition key of a table.
+  toUInt128(session_uuid),
+  path,
+  event_id
+)
+PARTITION BY toUInt128(session_uuid) % 32;
+
+-- Move rows to new table.
+INSERT INTO engagements
+  SELECT
+    timestamp,
+    domain,
+    path,
+    visitor_id,
+    session_uuid,
+    toString(generateUUIDv4()) AS event_id,
+    active_time,
+    scroll_depth
+  FROM engagements_old;
+
+-- Delete old table.
+DROP TABLE engagements_old;
+
+RENAME TABLE web_vitals TO web_vitals_old;
+
+CREATE TABLE web_vitals (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  visitor_id String,
+  is_anon Bool ALIAS startsWith(visitor_id, 'prisme_') OR startsWith(visitor_id, 'anon_'),
+  session_uuid UUID,
+  session_timestamp DateTime('UTC') ALIAS UUIDv7ToDateTime(session_uuid, 'UTC'),
+  session_id UInt128 ALIAS toUInt128(session_uuid),
+  -- Shared by all metrics sent in the same request.
+  event_id String,
+  metric LowCardinality(String),
+  value Float64
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (
+  domain,
+  metric,
+  toDate(timestamp),
+  path,
+  -- Due to historical reasons, UUIDs are sorted by their second half. UUIDs
+  -- should therefore not be used directly in a primary key, sorting key, or
+  -- partition key of a table.
+  toUInt128(session_uuid),
+  event_id
+)
+PARTITION BY toYYYYMM(timestamp);
+
+-- Move rows to new table.
+INSERT INTO web_vitals
+  SELECT
+    timestamp,
+    domain,
+    path,
+    visitor_id,
+    session_uuid,
+    toString(generateUUIDv4()) AS event_id,
+    metric,
+    value
+  FROM web_vitals_old;
+
+-- Delete old table.
+DROP TABLE web_vitals_old;
+
+RENAME TABLE errors TO errors_old;
+
+CREATE TABLE errors (
+  timestamp DateTime('UTC'),
+  domain String,
+  path String,
+  visitor_id String,
+  is_anon Bool ALIAS startsWith(visitor_id, 'prisme_') OR startsWith(visitor_id, 'anon_'),
+  session_uuid UUID,
+  session_timestamp DateTime('UTC') ALIAS UUIDv7ToDateTime(session_uuid, 'UTC'),
+  session_id UInt128 ALIAS toUInt128(session_uuid),
+  event_id String,
+  message String,
+  source String,
+  line UInt32,
+  column UInt32,
+  fingerprint String
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (
+  domain,
+  fingerprint,
+  toDate(timestamp),
+  -- Due to historical reasons, UUIDs are sorted by their second half. UUIDs
+  -- should therefore not be used directly in a primary key, sorting key, or
+  -- partition key of a table.
+  toUInt128(session_uuid),
+  event_id
+)
+PARTITION BY toYYYYMM(timestamp);
+
+-- Move rows to new table.
+INSERT INTO errors
+  SELECT
+    timestamp,
+    domain,
+    path,
+    visitor_id,
+    session_uuid,
+    toString(generateUUIDv4()) AS event_id,
+    message,
+    source,
+    line,
+    column,
+    fingerprint
+  FROM errors_old;
+
+-- Delete old table.
+DROP TABLE errors_old;
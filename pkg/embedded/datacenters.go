@@ -0,0 +1,9 @@
+package embedded
+
+import _ "embed"
+
+//go:embed datacenters/ipv4.txt
+var DatacenterIpv4Ranges []byte
+
+//go:embed datacenters/ipv6.txt
+var DatacenterIpv6Ranges []byte
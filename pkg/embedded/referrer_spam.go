@@ -0,0 +1,6 @@
+package embedded
+
+import _ "embed"
+
+//go:embed referrer_spam/spammers.txt
+var ReferrerSpammers []byte
@@ -0,0 +1,11 @@
+package embedded
+
+import _ "embed"
+
+// Referrers holds an intentional subset of Snowplow referer-parser database:
+// major search engines, social networks, email and paid providers. "unknown"
+// category is left out as unlisted referrers already belong to referral
+// channel. Run scripts/update-referrers.sh to embed the full database instead.
+//
+//go:embed referrers/referrers.json
+var Referrers []byte
@@ -0,0 +1,16 @@
+package event
+
+import (
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// BotPageView define a page view of a bot or crawler. Bot page views have no
+// session and are kept apart from human traffic.
+type BotPageView struct {
+	Timestamp time.Time `json:"timestamp"`
+	PageUri   uri.Uri   `json:"page_uri"`
+	BotFamily string    `json:"bot_family"`
+	Status    uint16    `json:"status"`
+}
@@ -3,7 +3,9 @@ package event
 import (
 	"time"
 
+	"github.com/prismelabs/analytics/pkg/dataview"
 	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/shopspring/decimal"
 )
 
 // Custom define a user defined event with custom properties.
@@ -11,7 +13,17 @@ type Custom struct {
 	Timestamp time.Time `json:"timestamp"`
 	PageUri   uri.Uri   `json:"page_uri"`
 	Session   Session   `json:"session"`
-	Name      string    `json:"name"`
-	Keys      []string  `json:"keys"`
-	Values    []string  `json:"values"`
+	// Client supplied (X-Prisme-Event-Id) or random event id.
+	EventId string   `json:"event_id"`
+	Name    string   `json:"name"`
+	Keys    []string `json:"keys"`
+	// Raw JSON encoded values.
+	Values []string `json:"values"`
+	// Types of Values.
+	Types []dataview.ValueType `json:"types"`
+	// Revenue amount in Currency. Currency is empty if event has no revenue.
+	Revenue  decimal.Decimal `json:"revenue"`
+	Currency string          `json:"currency"`
+	// Invalid is true if event violates custom events schema of its domain.
+	Invalid bool `json:"invalid"`
 }
@@ -0,0 +1,20 @@
+package event
+
+import (
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// Engagement define a page engagement heartbeat. ActiveTime is the time the
+// page was visible since the last engagement event of the page and ScrollDepth
+// the maximum scroll depth, in percent, reached on the page.
+type Engagement struct {
+	Timestamp time.Time `json:"timestamp"`
+	PageUri   uri.Uri   `json:"page_uri"`
+	Session   Session   `json:"session"`
+	// Client supplied (X-Prisme-Event-Id) or random event id.
+	EventId     string        `json:"event_id"`
+	ActiveTime  time.Duration `json:"active_time"`
+	ScrollDepth uint8         `json:"scroll_depth"`
+}
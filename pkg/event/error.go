@@ -0,0 +1,22 @@
+package event
+
+import (
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// Error define a front end JavaScript error event. Fingerprint identifies
+// occurrences of the same error.
+type Error struct {
+	Timestamp time.Time `json:"timestamp"`
+	PageUri   uri.Uri   `json:"page_uri"`
+	Session   Session   `json:"session"`
+	// Client supplied (X-Prisme-Event-Id) or random event id.
+	EventId     string `json:"event_id"`
+	Message     string `json:"message"`
+	Source      string `json:"source"`
+	Line        uint32 `json:"line"`
+	Column      uint32 `json:"column"`
+	Fingerprint string `json:"fingerprint"`
+}
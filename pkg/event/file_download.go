@@ -11,5 +11,7 @@ type FileDownload struct {
 	Timestamp time.Time `json:"timestamp"`
 	PageUri   uri.Uri   `json:"page_uri"`
 	Session   Session   `json:"session"`
-	FileUrl   uri.Uri   `json:"file_url"`
+	// Client supplied (X-Prisme-Event-Id) or random event id.
+	EventId string  `json:"event_id"`
+	FileUrl uri.Uri `json:"file_url"`
 }
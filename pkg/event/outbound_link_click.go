@@ -11,5 +11,7 @@ type OutboundLinkClick struct {
 	Timestamp time.Time `json:"timestamp"`
 	PageUri   uri.Uri   `json:"page_uri"`
 	Session   Session   `json:"session"`
-	Link      uri.Uri   `json:"link"`
+	// Client supplied (X-Prisme-Event-Id) or random event id.
+	EventId string  `json:"event_id"`
+	Link    uri.Uri `json:"link"`
 }
@@ -12,5 +12,15 @@ type PageView struct {
 	Session   Session   `json:"session"`
 	Timestamp time.Time `json:"timestamp"`
 	PageUri   uri.Uri   `json:"page_uri"`
-	Status    uint16    `json:"status"`
+	// Client supplied (X-Prisme-Event-Id) or random event id.
+	EventId string   `json:"event_id"`
+	Status  uint16   `json:"status"`
+	Keys    []string `json:"keys"`
+	// Raw JSON encoded values.
+	Values []string `json:"values"`
+	// Site search term extracted from page URI, empty if page isn't a search
+	// page.
+	SearchTerm string `json:"search_term"`
+	// Page title sent by tracker, empty if none was sent.
+	Title string `json:"title"`
 }
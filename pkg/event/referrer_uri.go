@@ -22,6 +22,16 @@ func ParseReferrerUri(rawUri []byte) (ReferrerUri, error) {
 	return ReferrerUri{uri}, err
 }
 
+// NewReferrerUri wraps the given uri.Uri into a ReferrerUri.
+func NewReferrerUri(u uri.Uri) ReferrerUri {
+	return ReferrerUri{u}
+}
+
+// Uri returns wrapped uri.Uri.
+func (ru *ReferrerUri) Uri() uri.Uri {
+	return ru.privateUri
+}
+
 // HostOrDirect returns uri host or "direct" if uri is empty.
 func (ru *ReferrerUri) HostOrDirect() string {
 	if !ru.IsValid() {
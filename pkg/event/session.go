@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/prismelabs/analytics/pkg/services/ipgeolocator"
+	"github.com/prismelabs/analytics/pkg/services/referrerparser"
 	"github.com/prismelabs/analytics/pkg/services/uaparser"
 	"github.com/prismelabs/analytics/pkg/uri"
 )
@@ -21,6 +22,10 @@ type Session struct {
 	VisitorId     string                   `json:"visitor_id"`
 	SessionUuid   uuid.UUID                `json:"session_uuid"`
 	Utm           UtmParams                `json:"utm_params"`
+	Source        referrerparser.Source    `json:"source"`
+	BotScore      uint8                    `json:"bot_score"`
+	AppVersion    string                   `json:"app_version"`
+	OsVersion     string                   `json:"os_version"`
 	PageviewCount uint16                   `json:"pageview_count"`
 }
 
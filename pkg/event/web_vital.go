@@ -0,0 +1,37 @@
+package event
+
+import (
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// WebVitalMetric define a Web Vitals metric name.
+// See https://web.dev/articles/vitals
+type WebVitalMetric string
+
+// Supported Web Vitals metrics.
+const (
+	// Largest Contentful Paint in milliseconds.
+	LcpWebVital WebVitalMetric = "LCP"
+	// Interaction to Next Paint in milliseconds.
+	InpWebVital WebVitalMetric = "INP"
+	// Cumulative Layout Shift score (unitless).
+	ClsWebVital WebVitalMetric = "CLS"
+	// Time To First Byte in milliseconds.
+	TtfbWebVital WebVitalMetric = "TTFB"
+	// First Contentful Paint in milliseconds.
+	FcpWebVital WebVitalMetric = "FCP"
+)
+
+// WebVital define a single Web Vitals measure of a page.
+type WebVital struct {
+	Timestamp time.Time `json:"timestamp"`
+	PageUri   uri.Uri   `json:"page_uri"`
+	Session   Session   `json:"session"`
+	// Client supplied (X-Prisme-Event-Id) or random event id, shared by all
+	// metrics sent in the same request.
+	EventId string         `json:"event_id"`
+	Metric  WebVitalMetric `json:"metric"`
+	Value   float64        `json:"value"`
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prismelabs/analytics/pkg/services/eventschema"
+)
+
+// AdminEventSchemas returns an HTTP handler of admin server managing custom
+// events schema registry:
+//
+//	GET    /api/v1/eventschemas          schema of all domains
+//	GET    /api/v1/eventschemas/{domain} schema of a domain
+//	PUT    /api/v1/eventschemas/{domain} replace schema of a domain
+//	DELETE /api/v1/eventschemas/{domain} delete schema of a domain
+//
+// Changes aren't persisted to schema file.
+func AdminEventSchemas(eventSchema eventschema.Service) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/eventschemas", func(w http.ResponseWriter, _ *http.Request) {
+		writeJson(w, eventSchema.Schema())
+	})
+
+	mux.HandleFunc("GET /api/v1/eventschemas/{domain}", func(w http.ResponseWriter, r *http.Request) {
+		schema, ok := eventSchema.DomainSchema(r.PathValue("domain"))
+		if !ok {
+			http.Error(w, "domain has no schema", http.StatusNotFound)
+			return
+		}
+		writeJson(w, schema)
+	})
+
+	mux.HandleFunc("PUT /api/v1/eventschemas/{domain}", func(w http.ResponseWriter, r *http.Request) {
+		var schema eventschema.DomainSchema
+		err := json.NewDecoder(r.Body).Decode(&schema)
+		if err != nil {
+			http.Error(w, "invalid schema: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = eventSchema.SetDomainSchema(r.PathValue("domain"), schema)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJson(w, schema)
+	})
+
+	mux.HandleFunc("DELETE /api/v1/eventschemas/{domain}", func(w http.ResponseWriter, r *http.Request) {
+		eventSchema.DeleteDomainSchema(r.PathValue("domain"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
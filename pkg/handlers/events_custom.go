@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,24 +11,47 @@ import (
 	"github.com/prismelabs/analytics/pkg/dataview"
 	"github.com/prismelabs/analytics/pkg/event"
 	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/currency"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
+	"github.com/prismelabs/analytics/pkg/services/eventschema"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/shopspring/decimal"
 )
 
+// maxRevenue defines maximum absolute revenue amount of a custom event. It
+// matches precision of revenue column (Decimal(18, 4)).
+var maxRevenue = decimal.New(1, 14)
+
 // PostEventsCustom returns a POST /api/v1/events/custom/:name handler.
+// Revenue of event is read from optional X-Prisme-Revenue header
+// (e.g. "X-Prisme-Revenue: 49.90 EUR").
 func PostEventsCustom(
 	eventStore eventstore.Service,
 	saltManagerService saltmanager.Service,
 	sessionStorage sessionstore.Service,
+	currencyService currency.Service,
+	eventSchema eventschema.Service,
+	eventLimits eventlimits.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		// ContentType must be json or protobuf if request has a body.
+		kvCollector, err := hutils.PropertiesKvCollector(c, eventLimits.KvLimits())
+		if err != nil {
+			return err
+		}
 
-		// ContentType must be json if request has a body.
-		if c.Request().Header.ContentLength() != 0 &&
-			utils.UnsafeString(c.Request().Header.ContentType()) != fiber.MIMEApplicationJSON {
-			return fiber.NewError(fiber.StatusBadRequest, "content type is not application/json")
+		// Check body size.
+		err = eventLimits.CheckBodySize(len(c.Body()))
+		if err != nil {
+			eventLimits.ReportRejected(err)
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, "custom event "+err.Error())
 		}
 
 		// Parse referrer.
@@ -36,18 +59,25 @@ func PostEventsCustom(
 		if err != nil {
 			return err
 		}
+		// Normalize and scrub page URI.
+		referrer = urlScrubber.Scrub(pathRules.Normalize(referrer))
 
-		kvCollector := dataview.NewJsonKvCollector(bytes.NewReader(hutils.BodyOrEmptyJsonObj(c)))
 		return eventsCustomHandler(
 			c.UserContext(),
 			eventStore,
 			saltManagerService,
 			sessionStorage,
+			currencyService,
+			eventSchema,
+			eventLimits,
 			referrer,
 			c.Request().Header.UserAgent(),
 			utils.UnsafeBytes(c.IP()),
 			c.Params("name"),
 			kvCollector,
+			utils.UnsafeString(c.Request().Header.Peek("X-Prisme-Revenue")),
+			hutils.EventId(c),
+			hutils.EventTimestamp(c),
 		)
 	}
 }
@@ -57,13 +87,28 @@ func eventsCustomHandler(
 	eventStore eventstore.Service,
 	saltManagerService saltmanager.Service,
 	sessionStorage sessionstore.Service,
+	currencyService currency.Service,
+	eventSchema eventschema.Service,
+	eventLimits eventlimits.Service,
 	requestReferrer uri.Uri,
 	userAgent, ipAddr []byte,
 	eventName string,
 	kvCollector dataview.KvCollector,
+	revenue string,
+	eventId string,
+	timestamp time.Time,
 ) (err error) {
 	customEv := event.Custom{
 		PageUri: requestReferrer,
+		EventId: eventId,
+	}
+
+	// Parse revenue.
+	if revenue != "" {
+		customEv.Revenue, customEv.Currency, err = parseRevenue(revenue, currencyService)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
 	}
 
 	// Compute device id.
@@ -80,12 +125,26 @@ func eventsCustomHandler(
 	}
 
 	// Event date and name.
-	customEv.Timestamp = time.Now().UTC()
+	customEv.Timestamp = hutils.SessionEventTimestamp(&customEv.Session, timestamp)
 	customEv.Name = utils.CopyString(eventName)
 
 	// Collect event properties.
-	customEv.Keys, customEv.Values, err = kvCollector.CollectKeysValues()
+	customEv.Keys, customEv.Values, customEv.Types, err = kvCollector.CollectKeysValues()
+	if err != nil {
+		eventLimits.ReportRejected(err)
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Validate event against schema registry.
+	err = eventSchema.Validate(&customEv)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Check event name length and number of distinct names of domain.
+	err = eventLimits.CheckName(customEv.PageUri.Host(), customEv.Name)
 	if err != nil {
+		eventLimits.ReportRejected(err)
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
@@ -97,3 +156,27 @@ func eventsCustomHandler(
 
 	return nil
 }
+
+// parseRevenue parses a revenue of the form "<amount> <currency>" (e.g.
+// "49.90 EUR"). Currency must be supported by currency service.
+func parseRevenue(str string, currencyService currency.Service) (decimal.Decimal, string, error) {
+	amountStr, code, ok := strings.Cut(strings.TrimSpace(str), " ")
+	if !ok {
+		return decimal.Zero, "", fmt.Errorf("invalid revenue %q: expected amount followed by currency (e.g. 49.90 EUR)", str)
+	}
+
+	amount, err := decimal.NewFromString(amountStr)
+	if err != nil {
+		return decimal.Zero, "", fmt.Errorf("invalid revenue amount %q", amountStr)
+	}
+	if amount.IsNegative() || amount.GreaterThanOrEqual(maxRevenue) {
+		return decimal.Zero, "", fmt.Errorf("revenue amount out of range: %v", amountStr)
+	}
+
+	code = strings.TrimSpace(code)
+	if !currencyService.IsSupported(code) {
+		return decimal.Zero, "", fmt.Errorf("unsupported revenue currency %q", code)
+	}
+
+	return amount.Round(4), utils.CopyString(code), nil
+}
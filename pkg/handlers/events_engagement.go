@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prismelabs/analytics/pkg/event"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/botscore"
+	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+	"github.com/prismelabs/analytics/pkg/services/saltmanager"
+	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
+)
+
+// maxEngagementActiveTime defines maximum active time reported by a single
+// engagement event. Tracker sends engagement events on visibility change so
+// a single event shouldn't cover more than a long reading session.
+const maxEngagementActiveTime = 6 * time.Hour
+
+// PostEventsEngagement returns a POST /api/v1/events/engagement handler.
+func PostEventsEngagement(
+	eventStore eventstore.Service,
+	saltManagerService saltmanager.Service,
+	sessionStorage sessionstore.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
+	botScoring botscore.Service,
+) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var err error
+		engagementEv := event.Engagement{}
+
+		// ContentType must be json.
+		if utils.UnsafeString(c.Request().Header.ContentType()) != fiber.MIMEApplicationJSON {
+			return fiber.NewError(fiber.StatusBadRequest, "content type is not application/json")
+		}
+
+		// Parse body.
+		var body struct {
+			// Active time in milliseconds.
+			ActiveTime  int64 `json:"active_time"`
+			ScrollDepth int64 `json:"scroll_depth"`
+		}
+		err = json.Unmarshal(c.Body(), &body)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid engagement body: %v", err.Error()))
+		}
+		engagementEv.ActiveTime = time.Duration(body.ActiveTime) * time.Millisecond
+		if engagementEv.ActiveTime < 0 || engagementEv.ActiveTime > maxEngagementActiveTime {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid active time")
+		}
+		if body.ScrollDepth < 0 || body.ScrollDepth > 100 {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid scroll depth")
+		}
+		engagementEv.ScrollDepth = uint8(body.ScrollDepth)
+
+		// Parse referrer.
+		engagementEv.PageUri, err = hutils.PeekAndParseReferrerHeader(c)
+		if err != nil {
+			return err
+		}
+		// Normalize and scrub page URI.
+		engagementEv.PageUri = urlScrubber.Scrub(pathRules.Normalize(engagementEv.PageUri))
+
+		// Compute device id.
+		deviceId := hutils.ComputeDeviceId(
+			saltManagerService.StaticSalt().Bytes(), c.Request().Header.UserAgent(),
+			utils.UnsafeBytes(c.IP()), utils.UnsafeBytes(engagementEv.PageUri.Host()),
+		)
+
+		// Retrieve visitor session.
+		ctx := c.UserContext()
+		var ok bool
+		engagementEv.Session, ok = sessionStorage.WaitSession(deviceId, engagementEv.PageUri, hutils.ContextTimeout(ctx))
+		if !ok {
+			return errSessionNotFound
+		}
+		// Only engagement of existing sessions is reported so engagement
+		// events can't be forged to lower bot score of devices.
+		botScoring.ReportEngagement(deviceId)
+
+		engagementEv.Timestamp = hutils.SessionEventTimestamp(&engagementEv.Session, hutils.EventTimestamp(c))
+		engagementEv.EventId = hutils.EventId(c)
+
+		// Store event.
+		err = eventStore.StoreEngagement(ctx, &engagementEv)
+		if err != nil {
+			return fmt.Errorf("failed to store engagement event: %w", err)
+		}
+
+		return nil
+	}
+}
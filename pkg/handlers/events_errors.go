@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prismelabs/analytics/pkg/event"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+	"github.com/prismelabs/analytics/pkg/services/saltmanager"
+	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+const (
+	// Maximum number of distinct errors accepted per device and per window.
+	maxErrorsPerDevice = 10
+	// Duration of rate limiting and deduplication window.
+	errorsWindow = time.Minute
+	// Maximum length of error message and source, longer values are truncated.
+	maxErrorMessageLen = 1024
+	maxErrorSourceLen  = 2048
+)
+
+// PostEventsErrors returns a POST /api/v1/events/errors handler.
+func PostEventsErrors(
+	eventStore eventstore.Service,
+	saltManagerService saltmanager.Service,
+	sessionStorage sessionstore.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
+) fiber.Handler {
+	throttler := newErrorsThrottler(errorsWindow, maxErrorsPerDevice)
+
+	return func(c *fiber.Ctx) error {
+		var err error
+		errorEv := event.Error{}
+
+		// ContentType must be json.
+		if utils.UnsafeString(c.Request().Header.ContentType()) != fiber.MIMEApplicationJSON {
+			return fiber.NewError(fiber.StatusBadRequest, "content type is not application/json")
+		}
+
+		// Parse body.
+		var body struct {
+			Message string `json:"message"`
+			Source  string `json:"source"`
+			Line    uint32 `json:"line"`
+			Column  uint32 `json:"column"`
+		}
+		err = json.Unmarshal(c.Body(), &body)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid error body: %v", err.Error()))
+		}
+		if body.Message == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "error message is missing")
+		}
+		errorEv.Message = truncate(urlScrubber.ScrubText(body.Message), maxErrorMessageLen)
+		errorEv.Source = truncate(scrubErrorSource(urlScrubber, body.Source), maxErrorSourceLen)
+		errorEv.Line = body.Line
+		errorEv.Column = body.Column
+		errorEv.Fingerprint = fmt.Sprintf("%016x", hutils.Xxh3(
+			utils.UnsafeBytes(errorEv.Message), []byte{0},
+			utils.UnsafeBytes(errorEv.Source), []byte{0},
+			strconv.AppendUint(nil, uint64(errorEv.Line), 10),
+		))
+
+		// Parse referrer.
+		errorEv.PageUri, err = hutils.PeekAndParseReferrerHeader(c)
+		if err != nil {
+			return err
+		}
+		// Normalize and scrub page URI.
+		errorEv.PageUri = urlScrubber.Scrub(pathRules.Normalize(errorEv.PageUri))
+
+		// Compute device id.
+		deviceId := hutils.ComputeDeviceId(
+			saltManagerService.StaticSalt().Bytes(), c.Request().Header.UserAgent(),
+			utils.UnsafeBytes(c.IP()), utils.UnsafeBytes(errorEv.PageUri.Host()),
+		)
+
+		// Rate limit and deduplicate errors. Fingerprint is recorded once event
+		// is stored so retries of failed requests aren't dropped.
+		switch throttler.check(deviceId, errorEv.Fingerprint) {
+		case errorDuplicated:
+			return nil
+		case errorRateLimited:
+			return fiber.NewError(fiber.StatusTooManyRequests, "too many errors")
+		}
+
+		// Retrieve visitor session.
+		ctx := c.UserContext()
+		var ok bool
+		errorEv.Session, ok = sessionStorage.WaitSession(deviceId, errorEv.PageUri, hutils.ContextTimeout(ctx))
+		if !ok {
+			return errSessionNotFound
+		}
+
+		errorEv.Timestamp = hutils.SessionEventTimestamp(&errorEv.Session, hutils.EventTimestamp(c))
+		errorEv.EventId = hutils.EventId(c)
+
+		// Store event.
+		err = eventStore.StoreError(ctx, &errorEv)
+		if err != nil {
+			return fmt.Errorf("failed to store error event: %w", err)
+		}
+		throttler.record(deviceId, errorEv.Fingerprint)
+
+		return nil
+	}
+}
+
+// scrubErrorSource scrubs source of an error event. Source is usually the URL
+// of the script that thrown the error but it isn't guaranteed.
+func scrubErrorSource(urlScrubber urlscrubber.Service, source string) string {
+	if u, err := uri.Parse(source); err == nil {
+		return urlScrubber.Scrub(u).String()
+	}
+	return urlScrubber.ScrubText(source)
+}
+
+func truncate(str string, maxLen int) string {
+	if len(str) > maxLen {
+		return strings.ToValidUTF8(str[:maxLen], "")
+	}
+	return str
+}
+
+type errorCheck uint8
+
+const (
+	errorAccepted errorCheck = iota
+	errorDuplicated
+	errorRateLimited
+)
+
+// errorsThrottler rate limits and deduplicates error events per device over
+// fixed windows. Only two windows are kept in memory so memory usage is
+// bounded by the number of devices reporting errors.
+type errorsThrottler struct {
+	mu          sync.Mutex
+	window      time.Duration
+	limit       int
+	windowStart time.Time
+	current     map[uint64][]string
+	previous    map[uint64][]string
+}
+
+func newErrorsThrottler(window time.Duration, limit int) *errorsThrottler {
+	return &errorsThrottler{
+		window:      window,
+		limit:       limit,
+		windowStart: time.Now(),
+		current:     make(map[uint64][]string),
+		previous:    make(map[uint64][]string),
+	}
+}
+
+// check returns whether an error event with given fingerprint and device id
+// is accepted, duplicated or rate limited. Accepted errors must be recorded
+// using record.
+func (et *errorsThrottler) check(deviceId uint64, fingerprint string) errorCheck {
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	et.rotate()
+
+	fingerprints := et.current[deviceId]
+	if slices.Contains(fingerprints, fingerprint) ||
+		slices.Contains(et.previous[deviceId], fingerprint) {
+		return errorDuplicated
+	}
+	if len(fingerprints) >= et.limit {
+		return errorRateLimited
+	}
+
+	return errorAccepted
+}
+
+// record records error event with the given fingerprint and device id.
+func (et *errorsThrottler) record(deviceId uint64, fingerprint string) {
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	et.rotate()
+
+	fingerprints := et.current[deviceId]
+	if !slices.Contains(fingerprints, fingerprint) {
+		et.current[deviceId] = append(fingerprints, fingerprint)
+	}
+}
+
+// rotate rotates windows if current one is over. Caller must hold et.mu.
+func (et *errorsThrottler) rotate() {
+	now := time.Now()
+	if now.Sub(et.windowStart) >= et.window {
+		if now.Sub(et.windowStart) >= 2*et.window {
+			clear(et.current)
+		}
+		et.previous, et.current = et.current, et.previous
+		clear(et.current)
+		et.windowStart = now
+	}
+}
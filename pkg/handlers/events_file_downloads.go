@@ -2,15 +2,16 @@ package handlers
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
 	"github.com/prismelabs/analytics/pkg/event"
 	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 	"github.com/prismelabs/analytics/pkg/uri"
 )
 
@@ -19,6 +20,8 @@ func PostEventsFileDownloads(
 	eventStore eventstore.Service,
 	saltManagerService saltmanager.Service,
 	sessionStorage sessionstore.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var err error
@@ -54,6 +57,9 @@ func PostEventsFileDownloads(
 			}
 		}
 
+		// Normalize and scrub page URI.
+		fileDownloadEv.PageUri = urlScrubber.Scrub(pathRules.Normalize(fileDownloadEv.PageUri))
+
 		// Compute device id.
 		deviceId := hutils.ComputeDeviceId(
 			saltManagerService.StaticSalt().Bytes(), c.Request().Header.UserAgent(),
@@ -75,8 +81,9 @@ func PostEventsFileDownloads(
 		}
 
 		// Add event data.
-		fileDownloadEv.Timestamp = time.Now().UTC()
-		fileDownloadEv.FileUrl = fileUri
+		fileDownloadEv.Timestamp = hutils.SessionEventTimestamp(&fileDownloadEv.Session, hutils.EventTimestamp(c))
+		fileDownloadEv.EventId = hutils.EventId(c)
+		fileDownloadEv.FileUrl = urlScrubber.Scrub(fileUri)
 
 		// Store event.
 		err = eventStore.StoreFileDownload(ctx, &fileDownloadEv)
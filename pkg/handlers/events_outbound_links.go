@@ -2,15 +2,16 @@ package handlers
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
 	"github.com/prismelabs/analytics/pkg/event"
 	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 	"github.com/prismelabs/analytics/pkg/uri"
 )
 
@@ -19,6 +20,8 @@ func PostEventsOutboundLinks(
 	eventStore eventstore.Service,
 	saltManagerService saltmanager.Service,
 	sessionStorage sessionstore.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var err error
@@ -57,6 +60,10 @@ func PostEventsOutboundLinks(
 				))
 			}
 		}
+
+		// Normalize and scrub page URI.
+		outboundLinkClickEv.PageUri = urlScrubber.Scrub(pathRules.Normalize(outboundLinkClickEv.PageUri))
+
 		// Check that link is external.
 		if outboundUri.Host() == outboundLinkClickEv.PageUri.Host() {
 			return fiber.NewError(fiber.StatusBadRequest, "internal link")
@@ -83,8 +90,9 @@ func PostEventsOutboundLinks(
 		}
 
 		// Add event data.
-		outboundLinkClickEv.Timestamp = time.Now().UTC()
-		outboundLinkClickEv.Link = outboundUri
+		outboundLinkClickEv.Timestamp = hutils.SessionEventTimestamp(&outboundLinkClickEv.Session, hutils.EventTimestamp(c))
+		outboundLinkClickEv.EventId = hutils.EventId(c)
+		outboundLinkClickEv.Link = urlScrubber.Scrub(outboundUri)
 
 		// Store event.
 		err = eventStore.StoreOutboundLinkClick(ctx, &outboundLinkClickEv)
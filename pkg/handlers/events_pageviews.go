@@ -9,33 +9,67 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
-	"github.com/google/uuid"
+	"github.com/prismelabs/analytics/pkg/dataview"
 	"github.com/prismelabs/analytics/pkg/event"
 	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/apps"
+	"github.com/prismelabs/analytics/pkg/services/botscore"
+	"github.com/prismelabs/analytics/pkg/services/bottraffic"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
 	"github.com/prismelabs/analytics/pkg/services/ipgeolocator"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+	"github.com/prismelabs/analytics/pkg/services/privacysignals"
+	"github.com/prismelabs/analytics/pkg/services/referrerparser"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/sitesearch"
 	"github.com/prismelabs/analytics/pkg/services/uaparser"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 	"github.com/prismelabs/analytics/pkg/uri"
 	"github.com/valyala/fasthttp"
 )
 
 // PostEventsPageViews returns a POST /api/v1/events/pageviews handler.
+// Optional JSON object body contains custom properties of pageview.
 func PostEventsPageViews(
 	eventStore eventstore.Service,
 	uaParserService uaparser.Service,
 	ipGeolocatorService ipgeolocator.Service,
 	saltManagerService saltmanager.Service,
 	sessionStorage sessionstore.Service,
+	eventLimits eventlimits.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
+	siteSearch sitesearch.Service,
+	referrerParser referrerparser.Service,
+	privacySignals privacysignals.Service,
+	botTraffic bottraffic.Service,
+	botScoring botscore.Service,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		// ContentType must be json or protobuf if request has a body.
+		kvCollector, err := hutils.PropertiesKvCollector(c, eventLimits.KvLimits())
+		if err != nil {
+			return err
+		}
+
+		// Check body size.
+		err = eventLimits.CheckBodySize(len(c.Body()))
+		if err != nil {
+			eventLimits.ReportRejected(err)
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, "pageview "+err.Error())
+		}
+
 		// Referrer of the POST request, that is the viewed page.
 		requestReferrer, err := hutils.PeekAndParseReferrerHeader(c)
 		if err != nil {
 			return err
 		}
-
+		title, err := hutils.PeekPageTitleHeader(c)
+		if err != nil {
+			return err
+		}
 		return eventsPageviewsHandler(
 			c.UserContext(),
 			eventStore,
@@ -50,6 +84,18 @@ func PostEventsPageViews(
 			utils.UnsafeBytes(c.IP()),
 			utils.UnsafeString(c.Request().Header.Peek("X-Prisme-Status")),
 			utils.UnsafeString(c.Request().Header.Peek("X-Prisme-Visitor-Id")),
+			title,
+			hutils.EventId(c),
+			hutils.EventTimestamp(c),
+			eventLimits,
+			pathRules,
+			urlScrubber,
+			siteSearch,
+			referrerParser,
+			privacySignals,
+			botTraffic,
+			botScoring,
+			kvCollector,
 		)
 	}
 }
@@ -64,14 +110,34 @@ func eventsPageviewsHandler(
 	headers *fasthttp.RequestHeader,
 	requestReferrer uri.Uri,
 	documentReferrer, userAgent, ipAddr []byte,
-	status, visitorId string,
+	status, visitorId, title, eventId string,
+	timestamp time.Time,
+	eventLimits eventlimits.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
+	siteSearch sitesearch.Service,
+	referrerParser referrerparser.Service,
+	privacySignals privacysignals.Service,
+	botTraffic bottraffic.Service,
+	botScoring botscore.Service,
+	kvCollector dataview.KvCollector,
 ) (err error) {
 	var referrerUri event.ReferrerUri
 	pageView := event.PageView{
-		PageUri: requestReferrer,
-		Status:  fiber.StatusOK,
+		Timestamp: timestamp,
+		EventId:   eventId,
+		Status:    fiber.StatusOK,
+		Title:     title,
 	}
 
+	// Extract site search term before query string is scrubbed and scrub it
+	// as visitors may search personal data.
+	requestReferrer, pageView.SearchTerm = siteSearch.Extract(requestReferrer)
+	pageView.SearchTerm = urlScrubber.ScrubText(pageView.SearchTerm)
+
+	// Normalize and scrub page URI.
+	pageView.PageUri = urlScrubber.Scrub(pathRules.Normalize(requestReferrer))
+
 	// Retrive pageview status code.
 	if status != "" {
 		pvStatus, err := strconv.ParseUint(status, 10, 16)
@@ -81,26 +147,85 @@ func eventsPageviewsHandler(
 		pageView.Status = uint16(pvStatus)
 	}
 
+	// Collect pageview properties.
+	pageView.Keys, pageView.Values, _, err = kvCollector.CollectKeysValues()
+	if err != nil {
+		eventLimits.ReportRejected(err)
+		return fiber.NewError(fiber.StatusBadRequest, "invalid pageview properties: "+err.Error())
+	}
+
 	// Parse referrer URI.
 	referrerUri, err = event.ParseReferrerUri(documentReferrer)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid document referrer")
 	}
 
+	isInternalTraffic := referrerUri.IsValid() && referrerUri.Host() == pageView.PageUri.Host()
+	// Internal referrer is the previous page of session, normalize it as well so
+	// session can be found.
+	if referrerUri.IsValid() {
+		refUri := referrerUri.Uri()
+		if isInternalTraffic {
+			refUri = pathRules.Normalize(refUri)
+		}
+		referrerUri = event.NewReferrerUri(urlScrubber.Scrub(refUri))
+	}
+
+	// Visitor sent a privacy signal, record pageview as a single pageview
+	// session that can't be linked to any other event.
+	if privacySignals.Policy(pageView.PageUri.Host(), headers) == privacysignals.AnonymizePolicy {
+		// Filter bot.
+		client := uaParserService.ParseUserAgent(utils.UnsafeString(userAgent))
+		if client.IsBot {
+			return botPageview(ctx, botTraffic, client, &pageView)
+		}
+		privacySignals.ReportApplied(privacysignals.AnonymizePolicy)
+
+		sessionUuid, err := hutils.NewSessionUuid(timestamp)
+		if err != nil {
+			return fmt.Errorf("failed to generate session uuid: %w", err)
+		}
+
+		utmParams, source := sessionSource(referrerParser, pageView.PageUri, referrerUri, isInternalTraffic)
+		pageView.Session = event.Session{
+			PageUri:     pageView.PageUri,
+			ReferrerUri: referrerUri,
+			Client: uaparser.Client{
+				BrowserFamily:   "Other",
+				OperatingSystem: "Other",
+				Device:          "Other",
+			},
+			CountryCode:   ipgeolocator.UnknownCountryCode,
+			VisitorId:     hutils.RandomVisitorId(),
+			SessionUuid:   sessionUuid,
+			Utm:           utmParams,
+			Source:        source,
+			PageviewCount: 1,
+		}
+		pageView.Timestamp = pageView.Session.SessionTime()
+
+		err = eventStore.StorePageView(ctx, &pageView)
+		if err != nil {
+			return fmt.Errorf("failed to store pageview event: %w", err)
+		}
+
+		return nil
+	}
+
 	// Compute device id.
 	deviceId := hutils.ComputeDeviceId(
 		saltManagerService.StaticSalt().Bytes(), userAgent,
 		ipAddr, utils.UnsafeBytes(pageView.PageUri.Host()),
 	)
+	botScoring.ReportPageview(deviceId)
 
-	isInternalTraffic := referrerUri.IsValid() && referrerUri.Host() == pageView.PageUri.Host()
 	newSession := !isInternalTraffic
 
 	// Internal traffic, session may already exists.
 	if isInternalTraffic {
 		var sessionExists bool
 		// Increment pageview count.
-		pageView.Session, sessionExists = sessionStorage.AddPageview(deviceId, referrerUri, pageView.PageUri)
+		pageView.Session, pageView.Timestamp, sessionExists = sessionStorage.AddPageview(deviceId, referrerUri, pageView.PageUri, timestamp)
 
 		if !sessionExists {
 			// Session with the given referrer URI doesn't exists but ones with
@@ -114,7 +239,7 @@ func eventsPageviewsHandler(
 				session, found := sessionStorage.WaitSession(deviceId, pageView.PageUri, time.Duration(0))
 				if found {
 					var err error
-					session.SessionUuid, err = uuid.NewV7()
+					session.SessionUuid, err = hutils.NewSessionUuid(timestamp)
 					if err != nil {
 						return fmt.Errorf("failed to generate session uuid: %w", err)
 					}
@@ -131,8 +256,6 @@ func eventsPageviewsHandler(
 			// Otherwise, simply create a new session.
 			newSession = true
 		} else {
-			pageView.Timestamp = time.Now().UTC()
-
 			// Update session visitor ID if needed.
 			if visitorId != "" && pageView.Session.VisitorId != visitorId {
 				visitorId = utils.CopyString(visitorId)
@@ -150,12 +273,19 @@ func eventsPageviewsHandler(
 		client := uaParserService.ParseUserAgent(
 			utils.UnsafeString(userAgent),
 		)
-		if client.IsBot {
-			return fiber.NewError(fiber.StatusBadRequest, "bot session filtered")
+		botScore, isBot := botScoring.Score(botscore.Request{
+			DeviceId: deviceId,
+			IpAddr:   utils.UnsafeString(ipAddr),
+			Client:   client,
+			Headers:  headers,
+			Secure:   pageView.PageUri.Scheme() == "https",
+		})
+		if isBot {
+			return botPageview(ctx, botTraffic, client, &pageView)
 		}
 		hutils.ExtractClientHints(headers, &client)
 
-		sessionUuid, err := uuid.NewV7()
+		sessionUuid, err := hutils.NewSessionUuid(timestamp)
 		if err != nil {
 			return fmt.Errorf("failed to generate session uuid: %w", err)
 		}
@@ -181,10 +311,7 @@ func eventsPageviewsHandler(
 			visitorId = utils.CopyString(visitorId)
 		}
 
-		// Parse page uri args.
-		args := fasthttp.Args{}
-		args.Parse(pageView.PageUri.QueryString())
-
+		utmParams, source := sessionSource(referrerParser, pageView.PageUri, referrerUri, isInternalTraffic)
 		pageView.Session = event.Session{
 			PageUri:       pageView.PageUri,
 			ReferrerUri:   referrerUri,
@@ -192,9 +319,15 @@ func eventsPageviewsHandler(
 			CountryCode:   ipGeolocatorService.FindCountryCodeForIP(utils.UnsafeString(ipAddr)),
 			VisitorId:     visitorId,
 			SessionUuid:   sessionUuid,
-			Utm:           hutils.ExtractUtmParams(&args),
+			Utm:           utmParams,
+			Source:        source,
+			BotScore:      botScore,
 			PageviewCount: 1,
 		}
+		if pageView.PageUri.Scheme() == apps.Scheme {
+			pageView.Session.AppVersion = string(headers.Peek(apps.AppVersionHeader))
+			pageView.Session.OsVersion = string(headers.Peek(apps.OsVersionHeader))
+		}
 		pageView.Timestamp = pageView.Session.SessionTime()
 
 		sessionStorage.InsertSession(deviceId, pageView.Session)
@@ -208,3 +341,55 @@ func eventsPageviewsHandler(
 
 	return nil
 }
+
+// sessionSource returns UTM parameters and traffic source of a new session.
+func sessionSource(
+	referrerParser referrerparser.Service,
+	pageUri uri.Uri,
+	referrerUri event.ReferrerUri,
+	isInternalTraffic bool,
+) (event.UtmParams, referrerparser.Source) {
+	// Parse page uri args.
+	args := fasthttp.Args{}
+	args.Parse(pageUri.QueryString())
+	utmParams := hutils.ExtractUtmParams(&args)
+
+	// Classify traffic source, internal referrers are direct traffic.
+	referrerDomain := ""
+	if referrerUri.IsValid() && !isInternalTraffic {
+		referrerDomain = referrerUri.Host()
+	}
+	source := referrerParser.ParseSource(referrerDomain, utmParams.Source, utmParams.Medium)
+
+	return utmParams, source
+}
+
+// botPageview records pageview of a bot client if bot traffic recording is
+// enabled or filters it otherwise.
+func botPageview(
+	ctx context.Context,
+	botTraffic bottraffic.Service,
+	client uaparser.Client,
+	pageView *event.PageView,
+) error {
+	botFamily := client.BotFamily
+	// Bot detected by bot score only.
+	if !client.IsBot {
+		botFamily = uaparser.OtherBotFamily
+	}
+
+	recorded, err := botTraffic.Record(ctx, &event.BotPageView{
+		Timestamp: pageView.Timestamp,
+		PageUri:   pageView.PageUri,
+		BotFamily: botFamily,
+		Status:    pageView.Status,
+	})
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return fiber.NewError(fiber.StatusBadRequest, "bot session filtered")
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prismelabs/analytics/pkg/event"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+	"github.com/prismelabs/analytics/pkg/services/saltmanager"
+	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
+)
+
+// PostEventsWebVitals returns a POST /api/v1/events/web-vitals handler.
+// Request body is a JSON object mapping Web Vitals metric name to its value.
+func PostEventsWebVitals(
+	eventStore eventstore.Service,
+	saltManagerService saltmanager.Service,
+	sessionStorage sessionstore.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
+) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var err error
+
+		// ContentType must be json.
+		if utils.UnsafeString(c.Request().Header.ContentType()) != fiber.MIMEApplicationJSON {
+			return fiber.NewError(fiber.StatusBadRequest, "content type is not application/json")
+		}
+
+		// Parse body.
+		var body map[event.WebVitalMetric]float64
+		err = json.Unmarshal(c.Body(), &body)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid web vitals body: %v", err.Error()))
+		}
+		if len(body) == 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "no web vitals metrics")
+		}
+		for metric, value := range body {
+			if !isValidWebVitalMetric(metric) {
+				return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown web vitals metric %q", metric))
+			}
+			if value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+				return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid %v value", metric))
+			}
+		}
+
+		// Parse referrer.
+		pageUri, err := hutils.PeekAndParseReferrerHeader(c)
+		if err != nil {
+			return err
+		}
+		// Normalize and scrub page URI.
+		pageUri = urlScrubber.Scrub(pathRules.Normalize(pageUri))
+
+		// Compute device id.
+		deviceId := hutils.ComputeDeviceId(
+			saltManagerService.StaticSalt().Bytes(), c.Request().Header.UserAgent(),
+			utils.UnsafeBytes(c.IP()), utils.UnsafeBytes(pageUri.Host()),
+		)
+
+		// Retrieve visitor session.
+		ctx := c.UserContext()
+		session, ok := sessionStorage.WaitSession(deviceId, pageUri, hutils.ContextTimeout(ctx))
+		if !ok {
+			return errSessionNotFound
+		}
+
+		// Store one event per metric.
+		timestamp := hutils.SessionEventTimestamp(&session, hutils.EventTimestamp(c))
+		eventId := hutils.EventId(c)
+		for metric, value := range body {
+			err = eventStore.StoreWebVital(ctx, &event.WebVital{
+				Timestamp: timestamp,
+				PageUri:   pageUri,
+				Session:   session,
+				EventId:   eventId,
+				Metric:    metric,
+				Value:     value,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to store web vital event: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func isValidWebVitalMetric(metric event.WebVitalMetric) bool {
+	switch metric {
+	case event.LcpWebVital, event.InpWebVital, event.ClsWebVital,
+		event.TtfbWebVital, event.FcpWebVital:
+		return true
+	default:
+		return false
+	}
+}
@@ -8,17 +8,27 @@ import (
 	"github.com/prismelabs/analytics/pkg/dataview"
 	"github.com/prismelabs/analytics/pkg/embedded"
 	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/currency"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
+	"github.com/prismelabs/analytics/pkg/services/eventschema"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 )
 
 // GetNoscriptEventsCustom returns a GET /api/v1/noscript/events/custom/:name
-// handler.
+// handler. Revenue of event is read from optional "revenue" query parameter.
 func GetNoscriptEventsCustom(
 	eventStore eventstore.Service,
 	saltManagerService saltmanager.Service,
 	sessionStorage sessionstore.Service,
+	currencyService currency.Service,
+	eventSchema eventschema.Service,
+	eventLimits eventlimits.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		err := c.Send(embedded.NoscriptGif)
@@ -31,12 +41,17 @@ func GetNoscriptEventsCustom(
 		if err != nil {
 			return err
 		}
+		// Normalize and scrub page URI.
+		requestReferrer = urlScrubber.Scrub(pathRules.Normalize(requestReferrer))
 
 		return eventsCustomHandler(
 			c.UserContext(),
 			eventStore,
 			saltManagerService,
 			sessionStorage,
+			currencyService,
+			eventSchema,
+			eventLimits,
 			requestReferrer,
 			c.Request().Header.UserAgent(),
 			utils.UnsafeBytes(c.IP()),
@@ -45,7 +60,11 @@ func GetNoscriptEventsCustom(
 				Args:           c.Context().QueryArgs(),
 				Prefix:         "prop-",
 				ValueValidator: json.Valid,
+				Limits:         eventLimits.KvLimits(),
 			},
+			c.Query("revenue"),
+			hutils.EventId(c),
+			hutils.EventTimestamp(c),
 		)
 	}
 }
@@ -1,25 +1,45 @@
 package handlers
 
 import (
+	"encoding/json"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prismelabs/analytics/pkg/dataview"
 	"github.com/prismelabs/analytics/pkg/embedded"
 	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/botscore"
+	"github.com/prismelabs/analytics/pkg/services/bottraffic"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
 	"github.com/prismelabs/analytics/pkg/services/ipgeolocator"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
+	"github.com/prismelabs/analytics/pkg/services/privacysignals"
+	"github.com/prismelabs/analytics/pkg/services/referrerparser"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/sitesearch"
 	"github.com/prismelabs/analytics/pkg/services/uaparser"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 )
 
 // GetNoscriptEventsPageview returns a GET /api/v1/noscript/events/pageview
-// handler.
+// handler. Pageview properties are read from query parameters prefixed with
+// "prop-".
 func GetNoscriptEventsPageviews(
 	eventStore eventstore.Service,
 	uaParserService uaparser.Service,
 	ipGeolocatorService ipgeolocator.Service,
 	saltManagerService saltmanager.Service,
 	sessionStorage sessionstore.Service,
+	eventLimits eventlimits.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
+	siteSearch sitesearch.Service,
+	referrerParser referrerparser.Service,
+	privacySignals privacysignals.Service,
+	botTraffic bottraffic.Service,
+	botScoring botscore.Service,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		err := c.Send(embedded.NoscriptGif)
@@ -32,7 +52,6 @@ func GetNoscriptEventsPageviews(
 		if err != nil {
 			return err
 		}
-
 		return eventsPageviewsHandler(
 			c.UserContext(),
 			eventStore,
@@ -47,6 +66,23 @@ func GetNoscriptEventsPageviews(
 			utils.UnsafeBytes(c.IP()),
 			c.Query("status"),
 			c.Query("visitor-id"),
+			"",
+			hutils.EventId(c),
+			hutils.EventTimestamp(c),
+			eventLimits,
+			pathRules,
+			urlScrubber,
+			siteSearch,
+			referrerParser,
+			privacySignals,
+			botTraffic,
+			botScoring,
+			dataview.FasthttpArgsKeysValuesCollector{
+				Args:           c.Context().QueryArgs(),
+				Prefix:         "prop-",
+				ValueValidator: json.Valid,
+				Limits:         eventLimits.KvLimits(),
+			},
 		)
 	}
 }
@@ -2,15 +2,16 @@ package handlers
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
 	"github.com/prismelabs/analytics/pkg/event"
 	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/pathrules"
 	"github.com/prismelabs/analytics/pkg/services/saltmanager"
 	"github.com/prismelabs/analytics/pkg/services/sessionstore"
+	"github.com/prismelabs/analytics/pkg/services/urlscrubber"
 	"github.com/prismelabs/analytics/pkg/uri"
 )
 
@@ -20,6 +21,8 @@ func GetNoscriptEventsOutboundLinks(
 	eventStore eventstore.Service,
 	sessionStorage sessionstore.Service,
 	saltManagerService saltmanager.Service,
+	pathRules pathrules.Service,
+	urlScrubber urlscrubber.Service,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var err error
@@ -29,6 +32,8 @@ func GetNoscriptEventsOutboundLinks(
 		if err != nil {
 			return err
 		}
+		// Normalize and scrub page URI.
+		outboundLinkClickEv.PageUri = urlScrubber.Scrub(pathRules.Normalize(outboundLinkClickEv.PageUri))
 
 		outboundUri, err := uri.Parse(c.Query("url"))
 		if err != nil {
@@ -64,8 +69,9 @@ func GetNoscriptEventsOutboundLinks(
 		}
 
 		// Add event data.
-		outboundLinkClickEv.Timestamp = time.Now().UTC()
-		outboundLinkClickEv.Link = outboundUri
+		outboundLinkClickEv.Timestamp = hutils.SessionEventTimestamp(&outboundLinkClickEv.Session, hutils.EventTimestamp(c))
+		outboundLinkClickEv.EventId = hutils.EventId(c)
+		outboundLinkClickEv.Link = urlScrubber.Scrub(outboundUri)
 
 		// Store event.
 		err = eventStore.StoreOutboundLinkClick(ctx, &outboundLinkClickEv)
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prismelabs/analytics/pkg/services/originregistry"
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// GetOptOut returns a GET /api/v1/opt-out handler that redirects browser to
+// "redirect" query parameter URL with a #prisme-opt-out fragment. Tracker
+// saves opt-out in local storage of redirect URL origin and stops sending
+// events. Local storage is the only opt-out mechanism: a cookie wouldn't be
+// sent along cross origin events requests. Redirect URL must be an URL of a
+// registered origin.
+func GetOptOut(originRegistry originregistry.Service) fiber.Handler {
+	return optOutHandler(originRegistry, "prisme-opt-out")
+}
+
+// GetOptIn returns a GET /api/v1/opt-in handler reverting GetOptOut.
+func GetOptIn(originRegistry originregistry.Service) fiber.Handler {
+	return optOutHandler(originRegistry, "prisme-opt-in")
+}
+
+func optOutHandler(originRegistry originregistry.Service, fragment string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		redirect, err := uri.Parse(c.Query("redirect"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, `invalid "redirect" query parameter`)
+		}
+
+		registered, err := originRegistry.IsOriginRegistered(c.UserContext(), redirect.HostName())
+		if err != nil {
+			return fmt.Errorf("failed to verify if origin is registered: %w", err)
+		}
+		if !registered {
+			return fiber.NewError(fiber.StatusBadRequest, "origin not registered")
+		}
+
+		target := redirect.Scheme() + "://" + redirect.Host() + redirect.Path()
+		if query := redirect.QueryString(); query != "" {
+			target += "?" + query
+		}
+		return c.Redirect(target+"#"+fragment, fiber.StatusSeeOther)
+	}
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/prismelabs/analytics/pkg/event"
 	"github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/currency"
 	"github.com/prismelabs/analytics/pkg/services/stats"
+	"github.com/shopspring/decimal"
 )
 
 type DataFrame[T any] struct {
@@ -20,6 +24,8 @@ type DataFrame[T any] struct {
 type Stats struct {
 	Bounces             fiber.Handler
 	Visitors            fiber.Handler
+	NewVisitors         fiber.Handler
+	ReturningVisitors   fiber.Handler
 	Sessions            fiber.Handler
 	SessionsDuration    fiber.Handler
 	PageViews           fiber.Handler
@@ -28,15 +34,125 @@ type Stats struct {
 	TopEntryPages       fiber.Handler
 	TopExitPages        fiber.Handler
 	TopReferrers        fiber.Handler
+	TopSources          fiber.Handler
+	TopChannels         fiber.Handler
 	TopUtmSources       fiber.Handler
 	TopUtmMediums       fiber.Handler
 	TopUtmCampaigns     fiber.Handler
 	TopCountries        fiber.Handler
 	TopOperatingSystems fiber.Handler
 	TopBrowsers         fiber.Handler
+	NewVsReturning      fiber.Handler
+	PagesReport         fiber.Handler
+	WebVitals           fiber.Handler
+	WebVitalsPages      fiber.Handler
+	WebVitalsBrowsers   fiber.Handler
+	WebVitalsCountries  fiber.Handler
+	TopErrors           fiber.Handler
+	ErrorRate           fiber.Handler
+	Revenue             fiber.Handler
+	RevenueSources      fiber.Handler
+	RevenueCampaigns    fiber.Handler
+	RevenueCountries    fiber.Handler
+	RevenuePages        fiber.Handler
+	CustomProperty      fiber.Handler
+	// Top values of a pageview property.
+	TopPageviewPropertyValues fiber.Handler
+	TopSearchTerms            fiber.Handler
+	SearchesPerSession        fiber.Handler
+	TopSearchExits            fiber.Handler
+	BotPageViews              fiber.Handler
+	TopBots                   fiber.Handler
+	TopCrawledPages           fiber.Handler
+	TopAppVersions            fiber.Handler
+	TopOsVersions             fiber.Handler
 }
 
-func GetStatsHandlers(s stats.Service) Stats {
+// FloatDataFrame is a DataFrame of floating point values.
+type FloatDataFrame[T any] struct {
+	From   int64     `json:"from"`
+	To     int64     `json:"to"`
+	Keys   []T       `json:"keys"`
+	Values []float64 `json:"values"`
+}
+
+// ErrorReport is the JSON representation of a stats.ErrorReport.
+type ErrorReport struct {
+	Fingerprint string `json:"fingerprint"`
+	Message     string `json:"message"`
+	Source      string `json:"source"`
+	Line        uint32 `json:"line"`
+	Occurrences uint64 `json:"occurrences"`
+	Sessions    uint64 `json:"sessions"`
+	LastSeen    int64  `json:"last_seen"`
+}
+
+// Percentiles is the JSON representation of a stats.Percentiles.
+type Percentiles struct {
+	Count uint64  `json:"count"`
+	P75   float64 `json:"p75"`
+	P90   float64 `json:"p90"`
+}
+
+// PercentilesDataFrame is a DataFrame of Percentiles.
+type PercentilesDataFrame[T any] struct {
+	From   int64         `json:"from"`
+	To     int64         `json:"to"`
+	Keys   []T           `json:"keys"`
+	Values []Percentiles `json:"values"`
+}
+
+// PageReport is the JSON representation of a stats.PageReport.
+type PageReport struct {
+	Path           string  `json:"path"`
+	PageViews      uint64  `json:"pageviews"`
+	Visitors       uint64  `json:"visitors"`
+	Entries        uint64  `json:"entries"`
+	Exits          uint64  `json:"exits"`
+	ExitRate       float64 `json:"exit_rate"`
+	BounceRate     float64 `json:"bounce_rate"`
+	AvgTimeOnPage  float64 `json:"avg_time_on_page"`
+	AvgActiveTime  float64 `json:"avg_active_time"`
+	AvgScrollDepth float64 `json:"avg_scroll_depth"`
+}
+
+// PropertyAggregates is the JSON representation of a
+// stats.PropertyAggregates.
+type PropertyAggregates struct {
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+	Avg   float64 `json:"avg"`
+	P50   float64 `json:"p50"`
+	P75   float64 `json:"p75"`
+	P90   float64 `json:"p90"`
+}
+
+// PropertyAggregatesDataFrame is a DataFrame of PropertyAggregates.
+type PropertyAggregatesDataFrame[T any] struct {
+	From   int64                `json:"from"`
+	To     int64                `json:"to"`
+	Keys   []T                  `json:"keys"`
+	Values []PropertyAggregates `json:"values"`
+}
+
+// Revenue is the JSON representation of a stats.Revenue. Amounts are encoded
+// as decimal strings.
+type Revenue struct {
+	Total             decimal.Decimal `json:"total"`
+	Orders            uint64          `json:"orders"`
+	AverageOrderValue decimal.Decimal `json:"average_order_value"`
+}
+
+// RevenueDataFrame is a DataFrame of Revenue expressed in Currency.
+type RevenueDataFrame[T any] struct {
+	From     int64     `json:"from"`
+	To       int64     `json:"to"`
+	Currency string    `json:"currency"`
+	Keys     []T       `json:"keys"`
+	Values   []Revenue `json:"values"`
+}
+
+func GetStatsHandlers(s stats.Service, currencyService currency.Service) Stats {
 	type TimeSerieFunc = func(
 		stats.Service,
 		context.Context,
@@ -100,9 +216,59 @@ func GetStatsHandlers(s stats.Service) Stats {
 		}
 	}
 
+	newWebVitalsBreakdownHandler := func(dimension stats.WebVitalsDimension) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			filters, limit, err := utils.ExtractStatsFiltersAndLimit(c)
+			if err != nil {
+				return err
+			}
+
+			metric, err := queryWebVitalMetric(c)
+			if err != nil {
+				return err
+			}
+
+			df, err := s.WebVitalsBreakdown(c.UserContext(), filters, metric, dimension, limit)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(PercentilesDataFrame[string]{
+				From:   filters.TimeRange.Start.Unix(),
+				To:     filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Keys:   df.Keys,
+				Values: toPercentiles(df.Values),
+			})
+		}
+	}
+
+	newRevenueBreakdownHandler := func(dimension stats.RevenueDimension) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			filters, limit, err := utils.ExtractStatsFiltersAndLimit(c)
+			if err != nil {
+				return err
+			}
+
+			df, err := s.RevenueBreakdown(c.UserContext(), filters, dimension, limit)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(RevenueDataFrame[string]{
+				From:     filters.TimeRange.Start.Unix(),
+				To:       filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Currency: currencyService.ReportingCurrency(),
+				Keys:     df.Keys,
+				Values:   toRevenues(df.Values),
+			})
+		}
+	}
+
 	return Stats{
 		Bounces:             newTimeSerieHandler(stats.Service.Bounces),
 		Visitors:            newTimeSerieHandler(stats.Service.Visitors),
+		NewVisitors:         newTimeSerieHandler(stats.Service.NewVisitors),
+		ReturningVisitors:   newTimeSerieHandler(stats.Service.ReturningVisitors),
 		Sessions:            newTimeSerieHandler(stats.Service.Sessions),
 		SessionsDuration:    newTimeSerieHandler(stats.Service.SessionsDuration),
 		PageViews:           newTimeSerieHandler(stats.Service.PageViews),
@@ -111,13 +277,257 @@ func GetStatsHandlers(s stats.Service) Stats {
 		TopEntryPages:       newTopHandler(stats.Service.TopEntryPages),
 		TopExitPages:        newTopHandler(stats.Service.TopExitPages),
 		TopReferrers:        newTopHandler(stats.Service.TopReferrers),
+		TopSources:          newTopHandler(stats.Service.TopSources),
+		TopChannels:         newTopHandler(stats.Service.TopChannels),
 		TopUtmSources:       newTopHandler(stats.Service.TopUtmSources),
 		TopUtmMediums:       newTopHandler(stats.Service.TopUtmMediums),
 		TopUtmCampaigns:     newTopHandler(stats.Service.TopUtmCampaigns),
 		TopCountries:        newTopHandler(stats.Service.TopCountries),
 		TopOperatingSystems: newTopHandler(stats.Service.TopOperatingSystems),
 		TopBrowsers:         newTopHandler(stats.Service.TopBrowsers),
+		NewVsReturning:      newTopHandler(stats.Service.NewVsReturning),
+		PagesReport: func(c *fiber.Ctx) error {
+			filters, limit, err := utils.ExtractStatsFiltersAndLimit(c)
+			if err != nil {
+				return err
+			}
+
+			sortBy, err := stats.ParsePagesReportSort(c.Query("sort", ""))
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+
+			var asc bool
+			switch c.Query("order", "desc") {
+			case "asc":
+				asc = true
+			case "desc":
+				asc = false
+			default:
+				return fiber.NewError(fiber.StatusBadRequest, "invalid query parameter 'order'")
+			}
+
+			offset, err := strconv.ParseUint(c.Query("offset", "0"), 10, 64)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid query parameter 'offset'")
+			}
+
+			report, err := s.PagesReport(c.UserContext(), filters, stats.PagesReportOptions{
+				SortBy: sortBy,
+				Asc:    asc,
+				Limit:  limit,
+				Offset: offset,
+			})
+			if err != nil {
+				return err
+			}
+
+			pages := make([]PageReport, len(report))
+			for i, p := range report {
+				pages[i] = PageReport(p)
+			}
+
+			return c.JSON(pages)
+		},
+		WebVitals: func(c *fiber.Ctx) error {
+			filters, err := utils.ExtractStatsFilters(c)
+			if err != nil {
+				return err
+			}
+
+			metric, err := queryWebVitalMetric(c)
+			if err != nil {
+				return err
+			}
+
+			df, err := s.WebVitals(c.UserContext(), filters, metric)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(PercentilesDataFrame[int64]{
+				From:   filters.TimeRange.Start.Unix(),
+				To:     filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Keys:   timeToTimestamps(df.Keys),
+				Values: toPercentiles(df.Values),
+			})
+		},
+		WebVitalsPages:     newWebVitalsBreakdownHandler(stats.WebVitalsByPage),
+		WebVitalsBrowsers:  newWebVitalsBreakdownHandler(stats.WebVitalsByBrowser),
+		WebVitalsCountries: newWebVitalsBreakdownHandler(stats.WebVitalsByCountry),
+		TopErrors: func(c *fiber.Ctx) error {
+			filters, limit, err := utils.ExtractStatsFiltersAndLimit(c)
+			if err != nil {
+				return err
+			}
+
+			report, err := s.TopErrors(c.UserContext(), filters, limit)
+			if err != nil {
+				return err
+			}
+
+			errors := make([]ErrorReport, len(report))
+			for i, e := range report {
+				errors[i] = ErrorReport{
+					Fingerprint: e.Fingerprint,
+					Message:     e.Message,
+					Source:      e.Source,
+					Line:        e.Line,
+					Occurrences: e.Occurrences,
+					Sessions:    e.Sessions,
+					LastSeen:    e.LastSeen.Unix(),
+				}
+			}
+
+			return c.JSON(errors)
+		},
+		ErrorRate: func(c *fiber.Ctx) error {
+			filters, err := utils.ExtractStatsFilters(c)
+			if err != nil {
+				return err
+			}
+
+			df, err := s.ErrorRate(c.UserContext(), filters)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(FloatDataFrame[int64]{
+				From:   filters.TimeRange.Start.Unix(),
+				To:     filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Keys:   timeToTimestamps(df.Keys),
+				Values: df.Values,
+			})
+		},
+		Revenue: func(c *fiber.Ctx) error {
+			filters, err := utils.ExtractStatsFilters(c)
+			if err != nil {
+				return err
+			}
+
+			df, err := s.Revenue(c.UserContext(), filters)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(RevenueDataFrame[int64]{
+				From:     filters.TimeRange.Start.Unix(),
+				To:       filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Currency: currencyService.ReportingCurrency(),
+				Keys:     timeToTimestamps(df.Keys),
+				Values:   toRevenues(df.Values),
+			})
+		},
+		RevenueSources:   newRevenueBreakdownHandler(stats.RevenueBySource),
+		RevenueCampaigns: newRevenueBreakdownHandler(stats.RevenueByCampaign),
+		RevenueCountries: newRevenueBreakdownHandler(stats.RevenueByCountry),
+		RevenuePages:     newRevenueBreakdownHandler(stats.RevenueByPage),
+		CustomProperty: func(c *fiber.Ctx) error {
+			filters, err := utils.ExtractStatsFilters(c)
+			if err != nil {
+				return err
+			}
+
+			eventName := c.Query("event", "")
+			if eventName == "" {
+				return fiber.NewError(fiber.StatusBadRequest, "query parameter 'event' is missing")
+			}
+			key := c.Query("key", "")
+			if key == "" {
+				return fiber.NewError(fiber.StatusBadRequest, "query parameter 'key' is missing")
+			}
+
+			df, err := s.CustomProperty(c.UserContext(), filters, eventName, key)
+			if err != nil {
+				return err
+			}
+
+			values := make([]PropertyAggregates, len(df.Values))
+			for i, v := range df.Values {
+				values[i] = PropertyAggregates(v)
+			}
+
+			return c.JSON(PropertyAggregatesDataFrame[int64]{
+				From:   filters.TimeRange.Start.Unix(),
+				To:     filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Keys:   timeToTimestamps(df.Keys),
+				Values: values,
+			})
+		},
+		TopSearchTerms: newTopHandler(stats.Service.TopSearchTerms),
+		SearchesPerSession: func(c *fiber.Ctx) error {
+			filters, err := utils.ExtractStatsFilters(c)
+			if err != nil {
+				return err
+			}
+
+			df, err := s.SearchesPerSession(c.UserContext(), filters)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(FloatDataFrame[int64]{
+				From:   filters.TimeRange.Start.Unix(),
+				To:     filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Keys:   timeToTimestamps(df.Keys),
+				Values: df.Values,
+			})
+		},
+		TopSearchExits:  newTopHandler(stats.Service.TopSearchExits),
+		BotPageViews:    newTimeSerieHandler(stats.Service.BotPageViews),
+		TopBots:         newTopHandler(stats.Service.TopBots),
+		TopCrawledPages: newTopHandler(stats.Service.TopCrawledPages),
+		TopAppVersions:  newTopHandler(stats.Service.TopAppVersions),
+		TopOsVersions:   newTopHandler(stats.Service.TopOsVersions),
+		TopPageviewPropertyValues: func(c *fiber.Ctx) error {
+			filters, limit, err := utils.ExtractStatsFiltersAndLimit(c)
+			if err != nil {
+				return err
+			}
+
+			key := c.Query("key", "")
+			if key == "" {
+				return fiber.NewError(fiber.StatusBadRequest, "query parameter 'key' is missing")
+			}
+
+			df, err := s.TopPageviewPropertyValues(c.UserContext(), filters, key, limit)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(DataFrame[string]{
+				From:   filters.TimeRange.Start.Unix(),
+				To:     filters.TimeRange.Start.Add(filters.TimeRange.Dur).Unix(),
+				Keys:   df.Keys,
+				Values: df.Values,
+			})
+		},
+	}
+}
+
+func queryWebVitalMetric(c *fiber.Ctx) (event.WebVitalMetric, error) {
+	metric := event.WebVitalMetric(c.Query("metric", ""))
+	if !isValidWebVitalMetric(metric) {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid query parameter 'metric'")
+	}
+
+	return metric, nil
+}
+
+func toPercentiles(values []stats.Percentiles) []Percentiles {
+	result := make([]Percentiles, len(values))
+	for i, v := range values {
+		result[i] = Percentiles(v)
+	}
+	return result
+}
+
+func toRevenues(values []stats.Revenue) []Revenue {
+	result := make([]Revenue, len(values))
+	for i, v := range values {
+		result[i] = Revenue(v)
 	}
+	return result
 }
 
 func timeToTimestamps(ti []time.Time) []int64 {
@@ -1,16 +1,25 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+	"github.com/prismelabs/analytics/pkg/dataview"
 	"github.com/prismelabs/analytics/pkg/event"
+	"github.com/prismelabs/analytics/pkg/services/eventdedup"
 	"github.com/prismelabs/analytics/pkg/services/stats"
 	"github.com/prismelabs/analytics/pkg/services/uaparser"
 	"github.com/prismelabs/analytics/pkg/timexpr"
@@ -19,7 +28,8 @@ import (
 )
 
 var (
-	emptyJsonObj = []byte{'{', '}'}
+	emptyJsonObj       = []byte{'{', '}'}
+	errInvalidPagePath = errors.New("invalid virtual page path")
 )
 
 // BodyOrEmptyJsonObj returns request body or an empty JSON object buffer
@@ -33,6 +43,29 @@ func BodyOrEmptyJsonObj(c *fiber.Ctx) []byte {
 	return body
 }
 
+// MIMEApplicationProtobuf is the content type of protocol buffers encoded
+// properties bodies (see static/properties.proto).
+const MIMEApplicationProtobuf = "application/x-protobuf"
+
+// PropertiesKvCollector returns a dataview.KvCollector for properties body of
+// request based on its content type. Request without body is treated as an
+// empty JSON object. In case of unsupported content type, a fiber error with
+// status 400 bad request is returned.
+func PropertiesKvCollector(c *fiber.Ctx, limits dataview.KvLimits) (dataview.KvCollector, error) {
+	if c.Request().Header.ContentLength() == 0 {
+		return dataview.NewJsonKvCollector(bytes.NewReader(BodyOrEmptyJsonObj(c)), limits), nil
+	}
+
+	switch utils.UnsafeString(c.Request().Header.ContentType()) {
+	case fiber.MIMEApplicationJSON:
+		return dataview.NewJsonKvCollector(bytes.NewReader(BodyOrEmptyJsonObj(c)), limits), nil
+	case MIMEApplicationProtobuf:
+		return dataview.ProtobufKvCollector{Body: c.Body(), Limits: limits}, nil
+	default:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "content type is not application/json or "+MIMEApplicationProtobuf)
+	}
+}
+
 // PeekReferrerHeader peek X-Prisme-Referrer header and fallback to
 // standard Referer header otherwise.
 func PeekReferrerHeader(c *fiber.Ctx) []byte {
@@ -47,8 +80,24 @@ func PeekReferrerHeader(c *fiber.Ctx) []byte {
 	return referrer
 }
 
+// Virtual page headers sent by tracker for single page applications views.
+const (
+	// Path (and optional query string) of virtual page, it overrides path of
+	// referrer.
+	PagePathHeader = "X-Prisme-Page-Path"
+	// URI encoded title of page.
+	PageTitleHeader = "X-Prisme-Page-Title"
+)
+
+// Maximum length of virtual page path and page title.
+const (
+	MaxPagePathLength  = 2048
+	MaxPageTitleLength = 512
+)
+
 // PeekAndParseReferrerHeader retrieves and parses prisme or standard referrer
-// header. In case of error, a fiber error with status 400 bad request is
+// header. Path of referrer is replaced by virtual page path header if request
+// has one. In case of error, a fiber error with status 400 bad request is
 // returned.
 func PeekAndParseReferrerHeader(c *fiber.Ctx) (uri.Uri, error) {
 	referrer := PeekReferrerHeader(c)
@@ -56,9 +105,65 @@ func PeekAndParseReferrerHeader(c *fiber.Ctx) (uri.Uri, error) {
 	if err != nil {
 		return uri.Uri{}, fiber.NewError(fiber.StatusBadRequest, `invalid "Referer" or "X-Prisme-Referrer"`)
 	}
+
+	if path := c.Request().Header.Peek(PagePathHeader); len(path) > 0 {
+		result, err = virtualPageUri(result, utils.UnsafeString(path))
+		if err != nil {
+			return uri.Uri{}, fiber.NewError(fiber.StatusBadRequest, `invalid "`+PagePathHeader+`"`)
+		}
+	}
+
 	return result, nil
 }
 
+// virtualPageUri returns referrer URI with path and query string replaced by
+// the given virtual page path. Query string of referrer is kept if virtual
+// page path has none so UTM parameters of landing page aren't lost.
+func virtualPageUri(referrer uri.Uri, path string) (uri.Uri, error) {
+	if len(path) > MaxPagePathLength || path[0] != '/' || strings.HasPrefix(path, "//") {
+		return uri.Uri{}, errInvalidPagePath
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] <= ' ' || path[i] == 0x7f || path[i] == '#' || path[i] == '\\' {
+			return uri.Uri{}, errInvalidPagePath
+		}
+	}
+
+	str := referrer.Origin() + path
+	if strings.IndexByte(path, '?') == -1 && referrer.QueryString() != "" {
+		str += "?" + referrer.QueryString()
+	}
+
+	result, err := uri.Parse(str)
+	if err != nil {
+		return uri.Uri{}, err
+	}
+	if result.Host() != referrer.Host() {
+		return uri.Uri{}, errInvalidPagePath
+	}
+
+	return result, nil
+}
+
+// PeekPageTitleHeader returns decoded and trimmed page title header or an
+// empty string if request has none. In case of error, a fiber error with
+// status 400 bad request is returned.
+func PeekPageTitleHeader(c *fiber.Ctx) (string, error) {
+	header := c.Request().Header.Peek(PageTitleHeader)
+	if len(header) == 0 {
+		return "", nil
+	}
+
+	title, err := url.PathUnescape(string(header))
+	if err != nil || !utf8.ValidString(title) ||
+		utf8.RuneCountInString(title) > MaxPageTitleLength ||
+		strings.ContainsFunc(title, unicode.IsControl) {
+		return "", fiber.NewError(fiber.StatusBadRequest, `invalid "`+PageTitleHeader+`"`)
+	}
+
+	return strings.TrimSpace(title), nil
+}
+
 // PeekReferrerQueryOrHeader peek referrer from "referrer" query parameter
 // and fallback to standard Referer header otherwise.
 func PeekReferrerQueryOrHeader(c *fiber.Ctx) []byte {
@@ -88,6 +193,77 @@ func PeekAndParseReferrerQueryHeader(c *fiber.Ctx) (uri.Uri, error) {
 	return result, nil
 }
 
+// PeekEventIdQueryOrHeader peek client supplied event id from "event-id" query
+// parameter and fallback to X-Prisme-Event-Id header otherwise. Query
+// parameter is used by requests that can't set headers (e.g. sendBeacon).
+func PeekEventIdQueryOrHeader(c *fiber.Ctx) []byte {
+	eventId := c.Context().QueryArgs().Peek(eventdedup.IdQueryParam)
+	if len(eventId) == 0 {
+		eventId = c.Request().Header.Peek(eventdedup.IdHeader)
+	}
+
+	return eventId
+}
+
+// EventId returns a copy of client supplied event id or a random UUID if
+// request has none. Events without client supplied id can't be deduplicated.
+// Event ids are validated by events deduplication middleware.
+func EventId(c *fiber.Ctx) string {
+	eventId := PeekEventIdQueryOrHeader(c)
+	if len(eventId) == 0 {
+		return uuid.NewString()
+	}
+	return string(eventId)
+}
+
+// eventTimestampLocal is the fiber.Ctx local key holding corrected client
+// timestamp of event.
+const eventTimestampLocal = "prisme_event_timestamp"
+
+// SetEventTimestamp sets client supplied event timestamp of request.
+func SetEventTimestamp(c *fiber.Ctx, timestamp time.Time) {
+	c.Locals(eventTimestampLocal, timestamp)
+}
+
+// EventTimestamp returns client supplied event timestamp of request set by
+// events timestamp middleware or current time otherwise.
+func EventTimestamp(c *fiber.Ctx) time.Time {
+	timestamp, ok := c.Locals(eventTimestampLocal).(time.Time)
+	if !ok {
+		return time.Now().UTC()
+	}
+	return timestamp
+}
+
+// SessionEventTimestamp returns given event timestamp or session creation
+// date if event timestamp is before it. Late events with a client timestamp
+// can't predate their session.
+func SessionEventTimestamp(session *event.Session, timestamp time.Time) time.Time {
+	sessionTime := session.SessionTime().UTC()
+	if timestamp.Before(sessionTime) {
+		return sessionTime
+	}
+	return timestamp
+}
+
+// NewSessionUuid returns a new UUIDv7 session id whose timestamp is the given
+// one. Session creation date of late pageviews is therefore their client
+// timestamp.
+func NewSessionUuid(timestamp time.Time) (uuid.UUID, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return id, err
+	}
+
+	// First 48 bits of UUIDv7 are a big-endian unix timestamp in milliseconds.
+	ms := uint64(timestamp.UnixMilli())
+	for i := range 6 {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+
+	return id, nil
+}
+
 // ComputeDeviceId computes xxh3 hash of the given byte slices.
 // This is the same as Xxh3 function.
 func ComputeDeviceId(bytesSlice ...[]byte) uint64 {
@@ -100,6 +276,12 @@ func ComputeVisitorId(bytesSlice ...[]byte) string {
 	return fmt.Sprintf("prisme_%X", Xxh3(bytesSlice...))
 }
 
+// RandomVisitorId returns a random visitor id that can't be linked to any
+// other event.
+func RandomVisitorId() string {
+	return fmt.Sprintf("prisme_%X", rand.Uint64())
+}
+
 // Xxh3 computes xxh3 hash of the given byte slices.
 func Xxh3(bytesSlice ...[]byte) uint64 {
 	hash := xxhash.New()
@@ -199,24 +381,57 @@ func ExtractStatsFilters(c *fiber.Ctx) (stats.Filters, error) {
 		return f, fiber.NewError(fiber.StatusBadRequest, "'from' date must be before 'to' date")
 	}
 
+	visitorType := filterEmptyTrimmedString(strings.Split(c.Query("visitor-type", ""), ","))
+	for _, vt := range visitorType {
+		if vt != stats.NewVisitorType && vt != stats.ReturningVisitorType {
+			return f, fiber.NewError(fiber.StatusBadRequest, "invalid query parameter 'visitor-type'")
+		}
+	}
+
+	var properties []stats.PropertyFilter
+	for _, str := range c.Context().QueryArgs().PeekMulti("property") {
+		property, err := stats.ParsePropertyFilter(string(str))
+		if err != nil {
+			return f, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		properties = append(properties, property)
+	}
+
+	var pageviewProperties []stats.PropertyFilter
+	for _, str := range c.Context().QueryArgs().PeekMulti("pageview-property") {
+		property, err := stats.ParsePropertyFilter(string(str))
+		if err != nil {
+			return f, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		pageviewProperties = append(pageviewProperties, property)
+	}
+
 	return stats.Filters{
 		TimeRange: stats.TimeRange{
 			Start: fromTime,
 			Dur:   toTime.Sub(fromTime),
 		},
-		Domain:          filterEmptyTrimmedString(strings.Split(c.Query("domain", ""), ",")),
-		Path:            filterEmptyTrimmedString(strings.Split(c.Query("path", ""), ",")),
-		EntryPath:       filterEmptyTrimmedString(strings.Split(c.Query("entry-path", ""), ",")),
-		ExitPath:        filterEmptyTrimmedString(strings.Split(c.Query("exit-path", ""), ",")),
-		Referrers:       filterEmptyTrimmedString(strings.Split(c.Query("referrer", ""), ",")),
-		OperatingSystem: filterEmptyTrimmedString(strings.Split(c.Query("os", ""), ",")),
-		BrowserFamily:   filterEmptyTrimmedString(strings.Split(c.Query("browser", ""), ",")),
-		Country:         filterEmptyTrimmedString(strings.Split(c.Query("country", ""), ",")),
-		UtmSource:       filterEmptyTrimmedString(strings.Split(c.Query("utm-source", ""), ",")),
-		UtmMedium:       filterEmptyTrimmedString(strings.Split(c.Query("utm-medium", ""), ",")),
-		UtmCampaign:     filterEmptyTrimmedString(strings.Split(c.Query("utm-campaign", ""), ",")),
-		UtmTerm:         filterEmptyTrimmedString(strings.Split(c.Query("utm-term", ""), ",")),
-		UtmContent:      filterEmptyTrimmedString(strings.Split(c.Query("utm-content", ""), ",")),
+		Domain:             filterEmptyTrimmedString(strings.Split(c.Query("domain", ""), ",")),
+		Path:               filterEmptyTrimmedString(strings.Split(c.Query("path", ""), ",")),
+		EntryPath:          filterEmptyTrimmedString(strings.Split(c.Query("entry-path", ""), ",")),
+		ExitPath:           filterEmptyTrimmedString(strings.Split(c.Query("exit-path", ""), ",")),
+		Referrers:          filterEmptyTrimmedString(strings.Split(c.Query("referrer", ""), ",")),
+		Sources:            filterEmptyTrimmedString(strings.Split(c.Query("source", ""), ",")),
+		Channels:           filterEmptyTrimmedString(strings.Split(c.Query("channel", ""), ",")),
+		OperatingSystem:    filterEmptyTrimmedString(strings.Split(c.Query("os", ""), ",")),
+		BrowserFamily:      filterEmptyTrimmedString(strings.Split(c.Query("browser", ""), ",")),
+		Country:            filterEmptyTrimmedString(strings.Split(c.Query("country", ""), ",")),
+		UtmSource:          filterEmptyTrimmedString(strings.Split(c.Query("utm-source", ""), ",")),
+		UtmMedium:          filterEmptyTrimmedString(strings.Split(c.Query("utm-medium", ""), ",")),
+		UtmCampaign:        filterEmptyTrimmedString(strings.Split(c.Query("utm-campaign", ""), ",")),
+		UtmTerm:            filterEmptyTrimmedString(strings.Split(c.Query("utm-term", ""), ",")),
+		UtmContent:         filterEmptyTrimmedString(strings.Split(c.Query("utm-content", ""), ",")),
+		VisitorType:        visitorType,
+		AppVersion:         filterEmptyTrimmedString(strings.Split(c.Query("app-version", ""), ",")),
+		OsVersion:          filterEmptyTrimmedString(strings.Split(c.Query("os-version", ""), ",")),
+		Properties:         properties,
+		PageviewProperties: pageviewProperties,
+		Bots:               filterEmptyTrimmedString(strings.Split(c.Query("bot", ""), ",")),
 	}, nil
 }
 
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prismelabs/analytics/pkg/services/apps"
+)
+
+// appEventLocal is the fiber.Ctx local key set on authenticated app events
+// requests.
+const appEventLocal = "prisme_app_event"
+
+// AppEvents returns a middleware that authenticates events requests of native
+// apps using X-Prisme-App-Id and X-Prisme-App-Key headers. Screen names are
+// converted to app://APP_ID/SCREEN page URIs so handlers process app events
+// like web events. Requests without app id header are left untouched.
+func AppEvents(appsService apps.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		appId := utils.UnsafeString(c.Request().Header.Peek(apps.AppIdHeader))
+		if appId == "" {
+			return c.Next()
+		}
+
+		key := utils.UnsafeString(c.Request().Header.Peek(apps.AppKeyHeader))
+		if !appsService.Authenticate(appId, key) {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid app key")
+		}
+
+		headers := &c.Request().Header
+		screen := utils.CopyString(utils.UnsafeString(headers.Peek(apps.ScreenHeader)))
+		headers.Set("X-Prisme-Referrer", apps.ScreenUri(appId, screen))
+		headers.Del("X-Prisme-Document-Referrer")
+		if previous := headers.Peek(apps.PreviousScreenHeader); len(previous) > 0 {
+			headers.Set("X-Prisme-Document-Referrer",
+				apps.ScreenUri(appId, utils.CopyString(utils.UnsafeString(previous))))
+		}
+		c.Request().URI().QueryArgs().Del("referrer")
+		c.Request().URI().QueryArgs().Del("document-referrer")
+
+		c.Locals(appEventLocal, true)
+
+		return c.Next()
+	}
+}
+
+// isAppEvent returns true if request was authenticated by AppEvents
+// middleware.
+func isAppEvent(c *fiber.Ctx) bool {
+	isApp, _ := c.Locals(appEventLocal).(bool)
+	return isApp
+}
@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
+)
+
+// decompressionChunkSize defines size of chunks read from decompressor before
+// decompressed body size is checked.
+const decompressionChunkSize = 4096
+
+// EventsDecompression returns a middleware that decompresses gzip and zstd
+// encoded (Content-Encoding header) events requests body. Decompression stops
+// as soon as decompressed body exceeds maximum body size of events limits so
+// small compressed payloads can't be used as decompression bombs. zstd decoders
+// memory and window size are also limited to maximum body size. Requests
+// with another encoding are rejected.
+func EventsDecompression(eventLimits eventlimits.Service) fiber.Handler {
+	maxBodySize := eventLimits.MaxBodySize()
+	zstdDecoders := sync.Pool{
+		New: func() any {
+			// Decoder can't fail with these options, window size is clamped to
+			// accepted range.
+			decoder, _ := zstd.NewReader(nil,
+				zstd.WithDecoderConcurrency(1),
+				zstd.WithDecoderLowmem(true),
+				zstd.WithDecoderMaxMemory(maxBodySize),
+				zstd.WithDecoderMaxWindow(
+					min(max(maxBodySize, zstd.MinWindowSize), zstd.MaxWindowSize),
+				),
+			)
+			return decoder
+		},
+	}
+
+	return func(c *fiber.Ctx) error {
+		encoding := utils.UnsafeString(c.Request().Header.Peek(fiber.HeaderContentEncoding))
+		if encoding == "" || encoding == "identity" {
+			return c.Next()
+		}
+
+		var (
+			r   io.Reader
+			err error
+		)
+		body := bytes.NewReader(c.Request().Body())
+		switch encoding {
+		case "gzip":
+			r, err = gzip.NewReader(body)
+		case "zstd":
+			decoder := zstdDecoders.Get().(*zstd.Decoder)
+			defer func() {
+				_ = decoder.Reset(nil)
+				zstdDecoders.Put(decoder)
+			}()
+			err = decoder.Reset(body)
+			r = decoder
+		default:
+			return fiber.NewError(fiber.StatusUnsupportedMediaType, "unsupported content encoding "+strconv.Quote(encoding))
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid "+encoding+" body")
+		}
+
+		decompressed, err := decompress(r, eventLimits)
+		if err != nil {
+			if errors.Is(err, eventlimits.ErrBodyTooLarge) {
+				eventLimits.ReportRejected(err)
+				return fiber.NewError(fiber.StatusRequestEntityTooLarge, "event "+err.Error())
+			}
+			return fiber.NewError(fiber.StatusBadRequest, "invalid "+encoding+" body")
+		}
+
+		// Replace body so handlers read decompressed body.
+		c.Request().Header.Del(fiber.HeaderContentEncoding)
+		c.Request().SetBodyRaw(decompressed)
+		c.Request().Header.SetContentLength(len(decompressed))
+
+		return c.Next()
+	}
+}
+
+// decompress reads r until EOF and checks decompressed size after each chunk.
+func decompress(r io.Reader, eventLimits eventlimits.Service) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		_, readErr := io.CopyN(&buf, r, decompressionChunkSize)
+
+		err := eventLimits.CheckBodySize(buf.Len())
+		if err != nil {
+			return nil, err
+		}
+
+		// zstd decoder memory or window limit exceeded.
+		if errors.Is(readErr, zstd.ErrDecoderSizeExceeded) ||
+			errors.Is(readErr, zstd.ErrWindowSizeExceeded) {
+			return nil, eventLimits.CheckBodySize(math.MaxInt)
+		}
+		if errors.Is(readErr, io.EOF) {
+			return buf.Bytes(), nil
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/services/eventlimits"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsDecompressionMiddleware(t *testing.T) {
+	logger := log.New("events_decompression_test", io.Discard, false)
+	eventLimits, err := eventlimits.NewService(eventlimits.Config{MaxBodySize: 1024}, nil, logger, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	gzipBody := func(body []byte) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(body)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	zstdBody := func(body []byte) []byte {
+		encoder, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		return encoder.EncodeAll(body, nil)
+	}
+
+	send := func(encoding string, body []byte) (*http.Response, []byte) {
+		var received []byte
+
+		app := fiber.New()
+		app.Use(EventsDecompression(eventLimits))
+		app.Use(func(c *fiber.Ctx) error {
+			require.Empty(t, c.Get(fiber.HeaderContentEncoding))
+			received = bytes.Clone(c.Body())
+			return nil
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		if encoding != "" {
+			req.Header.Set(fiber.HeaderContentEncoding, encoding)
+		}
+		res, err := app.Test(req)
+		require.NoError(t, err)
+
+		return res, received
+	}
+
+	body := []byte(`{"foo":"bar"}`)
+
+	t.Run("Identity", func(t *testing.T) {
+		res, received := send("", body)
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+		require.Equal(t, body, received)
+	})
+
+	t.Run("Gzip", func(t *testing.T) {
+		res, received := send("gzip", gzipBody(body))
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+		require.Equal(t, body, received)
+	})
+
+	t.Run("Zstd", func(t *testing.T) {
+		res, received := send("zstd", zstdBody(body))
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+		require.Equal(t, body, received)
+	})
+
+	t.Run("DecompressionBomb", func(t *testing.T) {
+		bomb := make([]byte, 16*1024*1024)
+
+		res, _ := send("gzip", gzipBody(bomb))
+		require.Equal(t, fiber.StatusRequestEntityTooLarge, res.StatusCode)
+
+		res, _ = send("zstd", zstdBody(bomb))
+		require.Equal(t, fiber.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	t.Run("InvalidBody", func(t *testing.T) {
+		res, _ := send("gzip", body)
+		require.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		res, _ = send("zstd", body)
+		require.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("UnsupportedEncoding", func(t *testing.T) {
+		res, _ := send("br", body)
+		require.Equal(t, fiber.StatusUnsupportedMediaType, res.StatusCode)
+	})
+}
@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/eventdedup"
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// EventsDeduplication returns a middleware that drops events requests whose
+// event id (X-Prisme-Event-Id header or event-id query parameter) was already
+// seen within deduplication window.
+// Dropped requests receive a 204 No Content response so trackers don't retry
+// them. Duplicates of requests still being processed receive a 409 Conflict
+// response as first request may still fail. Event id is released if request
+// fails so it can be retried.
+func EventsDeduplication(dedup eventdedup.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		eventId := utils.UnsafeString(hutils.PeekEventIdQueryOrHeader(c))
+		if eventId == "" {
+			return c.Next()
+		}
+
+		err := eventdedup.ValidateId(eventId)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		ev := eventdedup.Event{
+			Endpoint: eventsEndpoint(c.Path()),
+			Id:       utils.CopyString(eventId),
+		}
+		// Unknown endpoint, route doesn't exist.
+		if ev.Endpoint == "" {
+			return c.Next()
+		}
+		// Invalid URIs are ignored, they're rejected by handlers.
+		pageUri, err := uri.ParseBytes(hutils.PeekReferrerQueryOrHeader(c))
+		if err == nil {
+			ev.Domain = pageUri.Host()
+		}
+
+		switch dedup.Claim(ev) {
+		case eventdedup.Duplicate:
+			return c.SendStatus(fiber.StatusNoContent)
+		case eventdedup.InFlight:
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return fiber.NewError(fiber.StatusConflict, "event is being processed")
+		}
+
+		err = c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			dedup.Release(ev)
+		} else {
+			dedup.Complete(ev)
+		}
+
+		return err
+	}
+}
@@ -1,28 +1,74 @@
 package middlewares
 
 import (
-	"math"
-	"time"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/storage"
+	"github.com/gofiber/fiber/v2/utils"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
 	"github.com/prismelabs/analytics/pkg/options"
+	"github.com/prismelabs/analytics/pkg/services/ratelimiter"
+	"github.com/prismelabs/analytics/pkg/services/saltmanager"
+	"github.com/prismelabs/analytics/pkg/uri"
 )
 
 // EventsRateLimiter returns a rate limiter middleware for /api/*/events/* handlers.
-func EventsRateLimiter(cfg options.Server, storage storage.Storage) fiber.Handler {
-	max := 60
+// Requests are not rate limited in debug mode.
+func EventsRateLimiter(
+	cfg options.Server,
+	limiter ratelimiter.Service,
+	saltManager saltmanager.Service,
+) fiber.Handler {
 	if cfg.Debug {
-		max = math.MaxInt
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
 	}
 
-	return limiter.New(limiter.Config{
-		Max: max,
-		KeyGenerator: func(c *fiber.Ctx) string {
-			return c.IP()
-		},
-		Expiration: time.Minute,
-		Storage:    storage,
-	})
+	return func(c *fiber.Ctx) error {
+		req := ratelimiter.Request{
+			Endpoint: eventsEndpoint(c.Path()),
+		}
+
+		// Invalid URIs are ignored, they're rejected by handlers.
+		pageUri, err := uri.ParseBytes(hutils.PeekReferrerQueryOrHeader(c))
+		if err == nil {
+			req.Domain = pageUri.Host()
+		}
+
+		switch limiter.Key() {
+		case ratelimiter.DeviceKey:
+			req.Key = strconv.FormatUint(hutils.ComputeDeviceId(
+				saltManager.StaticSalt().Bytes(), c.Request().Header.UserAgent(),
+				utils.UnsafeBytes(c.IP()), utils.UnsafeBytes(req.Domain),
+			), 16)
+		case ratelimiter.OriginKey:
+			req.Key = req.Domain
+		default:
+			req.Key = c.IP()
+		}
+
+		if !limiter.Allow(req) {
+			return c.SendStatus(fiber.StatusTooManyRequests)
+		}
+
+		return c.Next()
+	}
+}
+
+// eventsEndpoint returns endpoint name of an /api/*/events/* or
+// /api/*/noscript/events/* path (e.g. "pageviews" or "custom"). An empty
+// string is returned if endpoint isn't one of ratelimiter.Endpoints.
+func eventsEndpoint(path string) string {
+	_, endpoint, found := strings.Cut(path, "/events/")
+	if !found {
+		return ""
+	}
+	endpoint, _, _ = strings.Cut(endpoint, "/")
+	if !slices.Contains(ratelimiter.Endpoints, endpoint) {
+		return ""
+	}
+	return endpoint
 }
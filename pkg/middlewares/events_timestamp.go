@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/eventtime"
+)
+
+// EventsTimestamp returns a middleware that validates client supplied event
+// timestamp of events requests (X-Prisme-Timestamp and X-Prisme-Client-Time
+// headers) and makes it available to handlers. Requests with an invalid
+// timestamp or a timestamp outside accepted window are rejected.
+func EventsTimestamp(eventTime eventtime.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timestamp, ok, err := eventTime.Timestamp(&c.Request().Header)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if ok {
+			hutils.SetEventTimestamp(c, timestamp)
+		}
+
+		return c.Next()
+	}
+}
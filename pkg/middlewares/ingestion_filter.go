@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/ingestionfilter"
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// IngestionFilter returns a middleware that filter events requests blocked by
+// ingestion filter.
+func IngestionFilter(filter ingestionfilter.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := ingestionfilter.Request{
+			IpAddr:    c.IP(),
+			UserAgent: utils.UnsafeString(c.Context().UserAgent()),
+		}
+
+		// Invalid URIs are ignored, they're rejected by handlers.
+		pageUri, err := uri.ParseBytes(hutils.PeekReferrerQueryOrHeader(c))
+		if err == nil {
+			req.PagePath = pageUri.Path()
+		}
+
+		documentReferrer := c.Request().Header.Peek("X-Prisme-Document-Referrer")
+		if len(documentReferrer) == 0 {
+			documentReferrer = utils.UnsafeBytes(c.Query("document-referrer"))
+		}
+		if len(documentReferrer) > 0 {
+			referrerUri, err := uri.ParseBytes(documentReferrer)
+			if err == nil {
+				req.ReferrerDomain = referrerUri.Host()
+			}
+		}
+
+		if _, blocked := filter.Filter(req); blocked {
+			return fiber.NewError(fiber.StatusBadRequest, "event blocked")
+		}
+
+		return c.Next()
+	}
+}
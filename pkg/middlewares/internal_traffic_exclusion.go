@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/options"
+	"github.com/prismelabs/analytics/pkg/services/trafficexclusion"
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// InternalTrafficExclusion returns a middleware that drops events requests of
+// internal traffic. Excluded requests receive a 204 No Content response and
+// never reach handlers. Client IP address is the first address of proxy
+// header if proxy is trusted and peer address otherwise.
+func InternalTrafficExclusion(cfg options.Proxy, exclusion trafficexclusion.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := trafficexclusion.Request{
+			IpAddr: c.Context().RemoteIP().String(),
+		}
+
+		// Peer address is the one of trusted proxy (which may belong to an
+		// internal range), use client address forwarded by proxy instead.
+		if cfg.Trust {
+			if forwardedFor := c.Request().Header.Peek(cfg.ForwardedForHeader); len(forwardedFor) > 0 {
+				req.IpAddr, _, _ = strings.Cut(utils.UnsafeString(forwardedFor), ",")
+			}
+		}
+
+		// Invalid URIs are ignored, they're rejected by handlers.
+		pageUri, err := uri.ParseBytes(hutils.PeekReferrerQueryOrHeader(c))
+		if err == nil {
+			req.Domain = pageUri.Host()
+		}
+
+		if _, excluded := exclusion.Exclude(req); excluded {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		return c.Next()
+	}
+}
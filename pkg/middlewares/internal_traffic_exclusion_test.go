@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/options"
+	"github.com/prismelabs/analytics/pkg/services/trafficexclusion"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalTrafficExclusionMiddleware(t *testing.T) {
+	logger := log.New("internal_traffic_exclusion_test", io.Discard, false)
+	// Peer address of fiber test requests is 0.0.0.0, it is the address of
+	// proxy.
+	exclusion, err := trafficexclusion.NewService(
+		trafficexclusion.Config{IPs: []string{"0.0.0.0/8", "10.0.0.0/8"}},
+		logger, prometheus.NewRegistry(),
+	)
+	require.NoError(t, err)
+
+	send := func(trust bool, forwardedFor string) int {
+		proxy := options.Proxy{Trust: trust, ForwardedForHeader: "X-Forwarded-For"}
+
+		app := fiber.New()
+		app.Use(InternalTrafficExclusion(proxy, exclusion))
+		app.Use(func(c *fiber.Ctx) error {
+			return nil
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		res, err := app.Test(req)
+		require.NoError(t, err)
+
+		return res.StatusCode
+	}
+
+	t.Run("TrustedProxy", func(t *testing.T) {
+		require.Equal(t, fiber.StatusOK, send(true, "8.8.8.8"))
+		require.Equal(t, fiber.StatusOK, send(true, "8.8.8.8, 10.0.0.1"))
+		require.Equal(t, fiber.StatusNoContent, send(true, "10.0.0.1"))
+	})
+
+	t.Run("UntrustedProxy", func(t *testing.T) {
+		require.Equal(t, fiber.StatusNoContent, send(false, "8.8.8.8"))
+		require.Equal(t, fiber.StatusNoContent, send(false, ""))
+	})
+}
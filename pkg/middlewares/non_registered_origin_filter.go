@@ -10,9 +10,14 @@ import (
 )
 
 // NonRegisteredOriginFilter returns a middleware that filter request with non
-// registered origins.
+// registered origins. App events authenticated by AppEvents middleware are not
+// filtered.
 func NonRegisteredOriginFilter(originRegistry originregistry.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if isAppEvent(c) {
+			return c.Next()
+		}
+
 		origin := utils.UnsafeString(c.Request().Header.Peek(fiber.HeaderOrigin))
 		origin, found := strings.CutPrefix(origin, "https://")
 		if !found {
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	hutils "github.com/prismelabs/analytics/pkg/handlers/utils"
+	"github.com/prismelabs/analytics/pkg/services/privacysignals"
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// PrivacySignals returns a middleware that drops events requests carrying a
+// privacy signal according to domain policy. Pageviews anonymization is
+// handled by pageviews handlers, other events are dropped as they can't be
+// recorded without linking them to a visitor session. Dropped requests
+// receive a 204 No Content response.
+func PrivacySignals(privacySignals privacysignals.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Invalid URIs are ignored, they're rejected by handlers.
+		pageUri, err := uri.ParseBytes(hutils.PeekReferrerQueryOrHeader(c))
+		if err != nil {
+			return c.Next()
+		}
+
+		domain := pageUri.Host()
+		policy := privacySignals.Policy(domain, &c.Request().Header)
+		isPageview := strings.HasSuffix(c.Path(), "/pageviews")
+		if policy == privacysignals.DropPolicy ||
+			(policy == privacysignals.AnonymizePolicy && !isPageview) {
+			privacySignals.ReportApplied(policy)
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		return c.Next()
+	}
+}
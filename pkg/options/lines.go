@@ -0,0 +1,44 @@
+package options
+
+import (
+	"strings"
+
+	"github.com/negrel/configue"
+)
+
+var _ configue.Value = &Lines{}
+
+// Lines defines a newline separated list option that implements
+// configue.Option. Unlike comma separated lists, items may contain commas
+// (e.g. regular expressions). Empty lines are ignored and option may be
+// repeated to append items.
+type Lines struct {
+	Lines *[]string
+	// True if Set has already been called once.
+	isDefined bool
+}
+
+// Set implements configue.Value.
+func (l *Lines) Set(str string) error {
+	if !l.isDefined {
+		l.isDefined = true
+		*l.Lines = nil
+	}
+
+	for _, line := range strings.Split(str, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			*l.Lines = append(*l.Lines, line)
+		}
+	}
+
+	return nil
+}
+
+// String implements configue.Value.
+func (l *Lines) String() string {
+	if l == nil || l.Lines == nil {
+		return ""
+	}
+	return strings.Join(*l.Lines, "\n")
+}
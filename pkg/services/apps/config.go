@@ -0,0 +1,21 @@
+package apps
+
+import (
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	Keys []string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringSliceVar(&c.Keys, "apps.keys", nil, "comma separated `list` of APP_ID=KEY of native apps allowed to send events, app id is used as domain (e.g. com.example.ios=secret)")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	_, err := parseKeys(c.Keys)
+	return err
+}
@@ -0,0 +1,107 @@
+package apps
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/log"
+)
+
+// Scheme of app page URIs. App events are ingested as pageviews of
+// app://APP_ID/SCREEN pages so app id is used as domain and screen name as
+// path.
+const Scheme = "app"
+
+// Headers of app events requests.
+const (
+	AppIdHeader          = "X-Prisme-App-Id"
+	AppKeyHeader         = "X-Prisme-App-Key"
+	ScreenHeader         = "X-Prisme-Screen"
+	PreviousScreenHeader = "X-Prisme-Previous-Screen"
+	AppVersionHeader     = "X-Prisme-App-Version"
+	OsVersionHeader      = "X-Prisme-Os-Version"
+)
+
+var appIdRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Service define a native apps registry.
+type Service interface {
+	// Authenticate returns true if given key is the key of given app.
+	Authenticate(appId, key string) bool
+}
+
+type service struct {
+	keys map[string]string
+}
+
+// NewService returns a new apps Service.
+func NewService(cfg Config, logger log.Logger) (Service, error) {
+	logger = logger.With("service", "apps")
+
+	keys, err := parseKeys(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("apps registry configured", "apps", len(keys))
+
+	return &service{keys: keys}, nil
+}
+
+// Authenticate implements Service.
+func (s *service) Authenticate(appId, key string) bool {
+	expected, ok := s.keys[appId]
+	if !ok || key == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(key)) == 1
+}
+
+func parseKeys(keys []string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, k := range keys {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+
+		appId, key, found := strings.Cut(k, "=")
+		appId = strings.TrimSpace(appId)
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid app key %q: expected APP_ID=KEY", appId)
+		}
+		if !appIdRegex.MatchString(appId) {
+			return nil, fmt.Errorf("invalid app id %q: only lowercase letters, digits, dots, dashes and underscores are allowed", appId)
+		}
+
+		result[appId] = key
+	}
+
+	return result, nil
+}
+
+// ScreenPath returns path of given screen name.
+func ScreenPath(screen string) string {
+	screen = strings.Trim(strings.TrimSpace(screen), "/")
+	if screen == "" {
+		return "/"
+	}
+
+	segments := strings.Split(screen, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// ScreenUri returns page URI of given app screen.
+func ScreenUri(appId, screen string) string {
+	return Scheme + "://" + appId + ScreenPath(screen)
+}
@@ -0,0 +1,56 @@
+package apps
+
+import (
+	"io"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/testutils"
+	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("apps_service_test", io.Discard, false)
+
+	t.Run("Authenticate", func(t *testing.T) {
+		srv, err := NewService(Config{
+			Keys: []string{"com.example.ios=secret", "com.example.android=other"},
+		}, logger)
+		require.NoError(t, err)
+
+		require.True(t, srv.Authenticate("com.example.ios", "secret"))
+		require.False(t, srv.Authenticate("com.example.ios", "other"))
+		require.False(t, srv.Authenticate("com.example.ios", ""))
+		require.False(t, srv.Authenticate("com.example.web", "secret"))
+	})
+
+	t.Run("ScreenUri", func(t *testing.T) {
+		type testCase struct {
+			screen, path string
+		}
+		for _, tcase := range []testCase{
+			{"", "/"},
+			{"Home", "/Home"},
+			{"/settings/profile/", "/settings/profile"},
+			{"Home Screen", "/Home%20Screen"},
+			{"Cart?step=1", "/Cart%3Fstep=1"},
+		} {
+			u := testutils.Must(uri.Parse)(ScreenUri("com.example.ios", tcase.screen))
+			require.Equal(t, "com.example.ios", u.Host(), tcase.screen)
+			require.Equal(t, tcase.path, u.Path(), tcase.screen)
+		}
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		for _, keys := range [][]string{
+			{"com.example.ios"},
+			{"com.example.ios="},
+			{"Com.Example=secret"},
+			{"com example=secret"},
+		} {
+			cfg := Config{Keys: keys}
+			require.Error(t, cfg.Validate(), keys)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package botscore
+
+import (
+	"errors"
+
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	Threshold     uint64
+	MaxDeviceRate uint64
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.Uint64Var(&c.Threshold, "botscore.threshold", 80, "bot `score` (1-100) from which sessions are excluded as bot traffic")
+	f.Uint64Var(&c.MaxDeviceRate, "botscore.max.device.rate", 60, "maximum `number` of pageviews per minute of a single device before it is considered a bot")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Threshold < 1 || c.Threshold > MaxScore {
+		errs = append(errs, errors.New("bot score threshold must be between 1 and 100"))
+	}
+	if c.MaxDeviceRate < 1 {
+		errs = append(errs, errors.New("bot score maximum device rate must be greater than or equal to 1"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package botscore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// datacenters holds sorted and non overlapping datacenter IP ranges.
+type datacenters []netip.Prefix
+
+// parseDatacenters parses and merges lists of CIDR, one per line. Empty lines
+// and lines starting with a # are ignored.
+func parseDatacenters(lists ...[]byte) (datacenters, error) {
+	var prefixes []netip.Prefix
+
+	for _, data := range lists {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			prefix, err := netip.ParsePrefix(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid datacenter IP range %q: %w", line, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(prefixes, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+
+	// Remove ranges nested in previous one so ranges don't overlap.
+	var result datacenters
+	for _, p := range prefixes {
+		if len(result) > 0 && result[len(result)-1].Contains(p.Addr()) {
+			continue
+		}
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+// Contains returns true if given IP address belongs to a datacenter IP range.
+func (d datacenters) Contains(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	// Find last range starting before or at ip.
+	i, found := slices.BinarySearchFunc(d, ip, func(p netip.Prefix, ip netip.Addr) int {
+		return p.Addr().Compare(ip)
+	})
+	if found {
+		return true
+	}
+	if i == 0 {
+		return false
+	}
+
+	return d[i-1].Contains(ip)
+}
@@ -0,0 +1,28 @@
+package botscore
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	signals  *prometheus.CounterVec
+	sessions *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		signals: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "botscore_signals_total",
+			Help: "Number of bot signals detected on new sessions",
+		}, []string{"signal"}),
+		sessions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "botscore_sessions_total",
+			Help: "Number of scored sessions",
+		}, []string{"excluded"}),
+	}
+
+	promRegistry.MustRegister(
+		m.signals,
+		m.sessions,
+	)
+
+	return m
+}
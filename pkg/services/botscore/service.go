@@ -0,0 +1,290 @@
+package botscore
+
+import (
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prismelabs/analytics/pkg/embedded"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/services/uaparser"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// MaxScore is the maximum bot score, it is the score of clients identified as
+// bot by their user agent.
+const MaxScore = 100
+
+// Signal define a bot signal and its weight in bot score.
+type Signal struct {
+	Name   string
+	Weight uint8
+}
+
+// Supported signals.
+var (
+	// User agent is a known bot or headless browser.
+	UserAgentSignal = Signal{"user_agent", MaxScore}
+	// IP address belongs to a datacenter.
+	DatacenterSignal = Signal{"datacenter", 40}
+	// Chromium based browser without Sec-CH-UA client hints on a secure page.
+	MissingClientHintsSignal = Signal{"missing_client_hints", 20}
+	// Sec-CH-UA-Platform client hint doesn't match user agent.
+	MismatchedClientHintsSignal = Signal{"mismatched_client_hints", 40}
+	// Device pageview rate exceeds configured maximum.
+	RequestRateSignal = Signal{"request_rate", 40}
+	// Device viewed several pages without any engagement event.
+	ZeroEngagementSignal = Signal{"zero_engagement", 20}
+)
+
+const (
+	// Duration of device activity window.
+	activityWindow = time.Minute
+	// Minimum number of pageviews without engagement to emit
+	// ZeroEngagementSignal.
+	zeroEngagementPageviews = 3
+)
+
+// Request holds data used to score a new session.
+type Request struct {
+	DeviceId uint64
+	IpAddr   string
+	Client   uaparser.Client
+	Headers  *fasthttp.RequestHeader
+	// Secure is true if page is served over HTTPS, client hints are only sent
+	// to secure contexts.
+	Secure bool
+}
+
+// Service define a bot scoring service. Bot score combines multiple signals
+// in a score between 0 and MaxScore.
+//
+// Sessions are scored once, when they're created. Signals based on device
+// activity (RequestRateSignal and ZeroEngagementSignal) only reflect activity
+// prior to session creation: a device that starts behaving like a bot during
+// a session keeps its classification until it starts a new session.
+type Service interface {
+	// Score computes bot score of a new session and returns it along a
+	// boolean flag that is true if score is above configured threshold.
+	Score(Request) (uint8, bool)
+	// ReportPageview reports a pageview of given device.
+	ReportPageview(deviceId uint64)
+	// ReportEngagement reports an engagement event of given device.
+	ReportEngagement(deviceId uint64)
+}
+
+type deviceActivity struct {
+	pageviews   uint64
+	engagements uint64
+}
+
+type service struct {
+	metrics       metrics
+	threshold     uint8
+	maxDeviceRate uint64
+	datacenters   datacenters
+	now           func() time.Time
+
+	mu sync.Mutex
+	// Devices activity of current window.
+	devices map[uint64]*deviceActivity
+	// Devices activity of previous window.
+	prevDevices map[uint64]*deviceActivity
+	windowStart time.Time
+}
+
+// NewService returns a new bot scoring Service.
+func NewService(cfg Config, logger log.Logger, promRegistry *prometheus.Registry) Service {
+	logger = logger.With("service", "botscore")
+
+	dc, err := parseDatacenters(embedded.DatacenterIpv4Ranges, embedded.DatacenterIpv6Ranges)
+	if err != nil {
+		logger.Fatal("failed to load datacenter IP ranges", err)
+	}
+
+	logger.Info(
+		"bot scoring configured",
+		"threshold", cfg.Threshold,
+		"max_device_rate", cfg.MaxDeviceRate,
+		"datacenter_ranges", len(dc),
+	)
+
+	return newService(cfg, dc, promRegistry, time.Now)
+}
+
+func newService(
+	cfg Config,
+	dc datacenters,
+	promRegistry *prometheus.Registry,
+	now func() time.Time,
+) *service {
+	return &service{
+		metrics:       newMetrics(promRegistry),
+		threshold:     uint8(cfg.Threshold),
+		maxDeviceRate: cfg.MaxDeviceRate,
+		datacenters:   dc,
+		now:           now,
+		devices:       make(map[uint64]*deviceActivity),
+		prevDevices:   make(map[uint64]*deviceActivity),
+		windowStart:   now(),
+	}
+}
+
+// Score implements Service.
+func (s *service) Score(req Request) (uint8, bool) {
+	var score uint
+	addSignal := func(signal Signal) {
+		score += uint(signal.Weight)
+		s.metrics.signals.With(prometheus.Labels{"signal": signal.Name}).Inc()
+	}
+
+	if req.Client.IsBot {
+		addSignal(UserAgentSignal)
+	}
+
+	if ip, err := netip.ParseAddr(req.IpAddr); err == nil && s.datacenters.Contains(ip) {
+		addSignal(DatacenterSignal)
+	}
+
+	if req.Headers != nil {
+		platform := utils.UnsafeString(req.Headers.Peek("Sec-Ch-Ua-Platform"))
+		if req.Secure && chromiumFamilies[req.Client.BrowserFamily] &&
+			len(req.Headers.Peek("Sec-Ch-Ua")) == 0 {
+			addSignal(MissingClientHintsSignal)
+		} else if platform != "" && isPlatformMismatch(platform, req.Client.OperatingSystem) {
+			addSignal(MismatchedClientHintsSignal)
+		}
+	}
+
+	activity := s.deviceActivity(req.DeviceId)
+	if activity.pageviews > s.maxDeviceRate {
+		addSignal(RequestRateSignal)
+	}
+	if activity.pageviews >= zeroEngagementPageviews && activity.engagements == 0 {
+		addSignal(ZeroEngagementSignal)
+	}
+
+	score = min(score, MaxScore)
+	excluded := score >= uint(s.threshold)
+	s.metrics.sessions.With(prometheus.Labels{
+		"excluded": strconv.FormatBool(excluded),
+	}).Inc()
+
+	return uint8(score), excluded
+}
+
+// ReportPageview implements Service.
+func (s *service) ReportPageview(deviceId uint64) {
+	s.mu.Lock()
+	s.currentActivity(deviceId).pageviews++
+	s.mu.Unlock()
+}
+
+// ReportEngagement implements Service.
+func (s *service) ReportEngagement(deviceId uint64) {
+	s.mu.Lock()
+	s.currentActivity(deviceId).engagements++
+	s.mu.Unlock()
+}
+
+// deviceActivity returns device activity of current and previous windows.
+func (s *service) deviceActivity(deviceId uint64) deviceActivity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotateWindows()
+
+	var result deviceActivity
+	if activity, ok := s.devices[deviceId]; ok {
+		result = *activity
+	}
+	// Engagement events of previous pages may have been sent during previous
+	// window.
+	if activity, ok := s.prevDevices[deviceId]; ok {
+		result.engagements += activity.engagements
+	}
+
+	return result
+}
+
+// currentActivity returns activity of device in current window. Caller must
+// hold s.mu.
+func (s *service) currentActivity(deviceId uint64) *deviceActivity {
+	s.rotateWindows()
+
+	activity, ok := s.devices[deviceId]
+	if !ok {
+		activity = &deviceActivity{}
+		s.devices[deviceId] = activity
+	}
+
+	return activity
+}
+
+// rotateWindows discards devices activity older than one window. Caller must
+// hold s.mu.
+func (s *service) rotateWindows() {
+	now := s.now()
+	elapsed := now.Sub(s.windowStart)
+	if elapsed < activityWindow {
+		return
+	}
+
+	if elapsed < 2*activityWindow {
+		s.prevDevices = s.devices
+	} else {
+		s.prevDevices = make(map[uint64]*deviceActivity)
+	}
+	s.devices = make(map[uint64]*deviceActivity)
+	s.windowStart = now
+}
+
+var (
+	// Browser families sending Sec-CH-UA client hints.
+	chromiumFamilies = map[string]bool{
+		"Chrome":        true,
+		"Chrome Mobile": true,
+		"Chromium":      true,
+		"Edge":          true,
+		"Opera":         true,
+	}
+
+	// Canonical platform of Sec-CH-UA-Platform client hints.
+	clientHintsPlatforms = map[string]string{
+		`"Android"`:     "android",
+		`"Chrome OS"`:   "chromeos",
+		`"Chromium OS"`: "chromeos",
+		`"Windows"`:     "windows",
+		`"iOS"`:         "ios",
+		`"macOS"`:       "macos",
+		`"Linux"`:       "linux",
+	}
+
+	// Canonical platform of user agent operating system families. Linux
+	// distributions are omitted as they have many families.
+	userAgentPlatforms = map[string]string{
+		"Android":   "android",
+		"Chrome OS": "chromeos",
+		"Windows":   "windows",
+		"iOS":       "ios",
+		"Mac OS X":  "macos",
+	}
+)
+
+// isPlatformMismatch returns true if Sec-CH-UA-Platform client hint and user
+// agent operating system are known and different.
+func isPlatformMismatch(clientHint string, operatingSystem string) bool {
+	hintPlatform, ok := clientHintsPlatforms[clientHint]
+	if !ok {
+		return false
+	}
+	uaPlatform, ok := userAgentPlatforms[operatingSystem]
+	if !ok {
+		return false
+	}
+
+	return hintPlatform != uaPlatform
+}
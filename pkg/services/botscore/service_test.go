@@ -0,0 +1,147 @@
+package botscore
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/embedded"
+	"github.com/prismelabs/analytics/pkg/services/uaparser"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestService(t *testing.T) {
+	cfg := Config{Threshold: 80, MaxDeviceRate: 5}
+	dc, err := parseDatacenters([]byte("# Comment\n10.0.0.0/8\n10.1.0.0/16\n\n2001:db8::/32\n"))
+	require.NoError(t, err)
+
+	chrome := uaparser.Client{
+		BrowserFamily:   "Chrome",
+		OperatingSystem: "Windows",
+		Device:          "Other",
+	}
+	headers := func(kv ...string) *fasthttp.RequestHeader {
+		h := &fasthttp.RequestHeader{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	chromeHeaders := headers(
+		"Sec-Ch-Ua", `"Chromium";v="124", "Google Chrome";v="124"`,
+		"Sec-Ch-Ua-Platform", `"Windows"`,
+	)
+
+	t.Run("Human", func(t *testing.T) {
+		srv := newService(cfg, dc, prometheus.NewRegistry(), time.Now)
+		srv.ReportPageview(1)
+
+		score, excluded := srv.Score(Request{
+			DeviceId: 1,
+			IpAddr:   "192.0.2.1",
+			Client:   chrome,
+			Headers:  chromeHeaders,
+			Secure:   true,
+		})
+		require.Equal(t, uint8(0), score)
+		require.False(t, excluded)
+	})
+
+	t.Run("UserAgent", func(t *testing.T) {
+		srv := newService(cfg, dc, prometheus.NewRegistry(), time.Now)
+
+		score, excluded := srv.Score(Request{
+			IpAddr:  "10.1.2.3",
+			Client:  uaparser.Client{BrowserFamily: "bot", IsBot: true, BotFamily: "Googlebot"},
+			Headers: headers(),
+		})
+		require.Equal(t, uint8(MaxScore), score)
+		require.True(t, excluded)
+	})
+
+	t.Run("DatacenterAndClientHints", func(t *testing.T) {
+		srv := newService(cfg, dc, prometheus.NewRegistry(), time.Now)
+
+		score, excluded := srv.Score(Request{
+			IpAddr:  "10.1.2.3",
+			Client:  chrome,
+			Headers: headers(),
+			Secure:  true,
+		})
+		require.Equal(t, DatacenterSignal.Weight+MissingClientHintsSignal.Weight, score)
+		require.False(t, excluded)
+
+		score, excluded = srv.Score(Request{
+			IpAddr: "2001:db8::1",
+			Client: chrome,
+			Headers: headers(
+				"Sec-Ch-Ua", `"Chromium";v="124"`,
+				"Sec-Ch-Ua-Platform", `"Linux"`,
+			),
+			Secure: true,
+		})
+		require.Equal(t, DatacenterSignal.Weight+MismatchedClientHintsSignal.Weight, score)
+		require.True(t, excluded)
+
+		signals := srv.metrics.signals
+		require.Equal(t, 2.0, testutil.ToFloat64(signals.WithLabelValues(DatacenterSignal.Name)))
+		require.Equal(t, 1.0, testutil.ToFloat64(signals.WithLabelValues(MissingClientHintsSignal.Name)))
+		require.Equal(t, 1.0, testutil.ToFloat64(signals.WithLabelValues(MismatchedClientHintsSignal.Name)))
+		sessions := srv.metrics.sessions
+		require.Equal(t, 1.0, testutil.ToFloat64(sessions.WithLabelValues("true")))
+		require.Equal(t, 1.0, testutil.ToFloat64(sessions.WithLabelValues("false")))
+	})
+
+	t.Run("RequestRateAndZeroEngagement", func(t *testing.T) {
+		now := time.Now()
+		srv := newService(cfg, dc, prometheus.NewRegistry(), func() time.Time { return now })
+		req := Request{
+			DeviceId: 1,
+			IpAddr:   "192.0.2.1",
+			Client:   chrome,
+			Headers:  chromeHeaders,
+			Secure:   true,
+		}
+
+		for range 3 {
+			srv.ReportPageview(1)
+		}
+		score, _ := srv.Score(req)
+		require.Equal(t, ZeroEngagementSignal.Weight, score)
+
+		// Engagement during previous window.
+		srv.ReportEngagement(1)
+		now = now.Add(activityWindow)
+		for range 6 {
+			srv.ReportPageview(1)
+		}
+		score, _ = srv.Score(req)
+		require.Equal(t, RequestRateSignal.Weight, score)
+
+		// Activity expires.
+		now = now.Add(2 * activityWindow)
+		srv.ReportPageview(1)
+		score, _ = srv.Score(req)
+		require.Equal(t, uint8(0), score)
+	})
+
+	t.Run("EmbeddedDatacenters", func(t *testing.T) {
+		dc, err := parseDatacenters(embedded.DatacenterIpv4Ranges, embedded.DatacenterIpv6Ranges)
+		require.NoError(t, err)
+		require.NotEmpty(t, dc)
+		require.True(t, dc.Contains(netip.MustParseAddr("52.1.2.3")))
+		require.False(t, dc.Contains(netip.MustParseAddr("192.0.2.1")))
+		require.True(t, dc.Contains(netip.MustParseAddr("2a01:4f8::1")))
+		require.False(t, dc.Contains(netip.MustParseAddr("2001:db8::1")))
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		require.Error(t, (&Config{Threshold: 0, MaxDeviceRate: 1}).Validate())
+		require.Error(t, (&Config{Threshold: 101, MaxDeviceRate: 1}).Validate())
+		require.Error(t, (&Config{Threshold: 80, MaxDeviceRate: 0}).Validate())
+		require.NoError(t, cfg.Validate())
+	})
+}
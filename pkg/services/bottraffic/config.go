@@ -0,0 +1,20 @@
+package bottraffic
+
+import (
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	Record bool
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.BoolVar(&c.Record, "bottraffic.record", false, "record bot and crawler pageviews in bot_pageviews table instead of discarding them")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	return nil
+}
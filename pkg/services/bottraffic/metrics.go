@@ -0,0 +1,22 @@
+package bottraffic
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	pageviews *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		pageviews: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bottraffic_pageviews_total",
+			Help: "Number of bot pageviews recorded or discarded",
+		}, []string{"bot_family", "recorded"}),
+	}
+
+	promRegistry.MustRegister(
+		m.pageviews,
+	)
+
+	return m
+}
@@ -0,0 +1,77 @@
+package bottraffic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/prismelabs/analytics/pkg/event"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/uaparser"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Service define a bot traffic service. Bot pageviews are stored apart from
+// human traffic so they never affect sessions and visitors statistics.
+type Service interface {
+	// Record stores given bot pageview if bot traffic recording is enabled.
+	// It returns false if pageview was discarded.
+	Record(context.Context, *event.BotPageView) (bool, error)
+}
+
+type service struct {
+	metrics    metrics
+	eventStore eventstore.Service
+	record     bool
+	// Bot families used as metrics label values.
+	families map[string]struct{}
+}
+
+// NewService returns a new bot traffic Service.
+func NewService(
+	cfg Config,
+	eventStore eventstore.Service,
+	logger log.Logger,
+	promRegistry *prometheus.Registry,
+) Service {
+	logger = logger.With("service", "bottraffic")
+	logger.Info("bot traffic service configured", "record", cfg.Record)
+
+	families := make(map[string]struct{})
+	for _, family := range uaparser.BotFamilies() {
+		families[family] = struct{}{}
+	}
+
+	return &service{
+		metrics:    newMetrics(promRegistry),
+		eventStore: eventStore,
+		record:     cfg.Record,
+		families:   families,
+	}
+}
+
+// Record implements Service.
+func (s *service) Record(ctx context.Context, ev *event.BotPageView) (bool, error) {
+	// Bound metrics cardinality.
+	family := ev.BotFamily
+	if _, ok := s.families[family]; !ok {
+		family = uaparser.OtherBotFamily
+	}
+
+	s.metrics.pageviews.With(prometheus.Labels{
+		"bot_family": family,
+		"recorded":   strconv.FormatBool(s.record),
+	}).Inc()
+
+	if !s.record {
+		return false, nil
+	}
+
+	err := s.eventStore.StoreBotPageView(ctx, ev)
+	if err != nil {
+		return false, fmt.Errorf("failed to store bot pageview event: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package bottraffic
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/event"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/uaparser"
+	"github.com/prismelabs/analytics/pkg/testutils"
+	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type eventStoreStub struct {
+	eventstore.Service
+	botPageviews []*event.BotPageView
+}
+
+func (es *eventStoreStub) StoreBotPageView(_ context.Context, ev *event.BotPageView) error {
+	es.botPageviews = append(es.botPageviews, ev)
+	return nil
+}
+
+func TestService(t *testing.T) {
+	logger := log.New("bottraffic_service_test", io.Discard, false)
+
+	uaParser := uaparser.NewService(logger, prometheus.NewRegistry())
+	client := uaParser.ParseUserAgent("Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; Googlebot/2.1; +http://www.google.com/bot.html) Chrome/131.0.6778.204 Safari/537.36")
+	require.True(t, client.IsBot)
+
+	ev := &event.BotPageView{
+		Timestamp: time.Now().UTC(),
+		PageUri:   testutils.Must(uri.Parse)("https://example.com/docs"),
+		BotFamily: client.BotFamily,
+		Status:    200,
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		store := &eventStoreStub{}
+		srv := NewService(Config{}, store, logger, prometheus.NewRegistry())
+
+		recorded, err := srv.Record(context.Background(), ev)
+		require.NoError(t, err)
+		require.False(t, recorded)
+		require.Empty(t, store.botPageviews)
+
+		pageviews := srv.(*service).metrics.pageviews
+		require.Equal(t, 1.0, testutil.ToFloat64(pageviews.WithLabelValues("Googlebot", "false")))
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		store := &eventStoreStub{}
+		srv := NewService(Config{Record: true}, store, logger, prometheus.NewRegistry())
+
+		recorded, err := srv.Record(context.Background(), ev)
+		require.NoError(t, err)
+		require.True(t, recorded)
+		require.Equal(t, []*event.BotPageView{ev}, store.botPageviews)
+
+		pageviews := srv.(*service).metrics.pageviews
+		require.Equal(t, 1.0, testutil.ToFloat64(pageviews.WithLabelValues("Googlebot", "true")))
+	})
+
+	t.Run("UnknownFamily", func(t *testing.T) {
+		store := &eventStoreStub{}
+		srv := NewService(Config{Record: true}, store, logger, prometheus.NewRegistry())
+
+		unknown := *ev
+		unknown.BotFamily = "MyCustomCrawler"
+		recorded, err := srv.Record(context.Background(), &unknown)
+		require.NoError(t, err)
+		require.True(t, recorded)
+
+		pageviews := srv.(*service).metrics.pageviews
+		require.Equal(t, 1, testutil.CollectAndCount(pageviews))
+		require.Equal(t, 1.0, testutil.ToFloat64(pageviews.WithLabelValues(uaparser.OtherBotFamily, "true")))
+	})
+}
@@ -0,0 +1,32 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	Reporting string
+	Rates     []string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringVar(&c.Reporting, "currency.reporting", "USD", "ISO 4217 `code` of currency used in revenue reports")
+	f.StringSliceVar(&c.Rates, "currency.rates", nil, "comma separated `list` of CODE=RATE conversion rates to reporting currency (e.g. EUR=1.08,GBP=1.27)")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	var errs []error
+	if !IsValidCode(c.Reporting) {
+		errs = append(errs, fmt.Errorf("invalid reporting currency %q", c.Reporting))
+	}
+	if _, err := parseRates(c.Reporting, c.Rates); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
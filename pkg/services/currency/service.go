@@ -0,0 +1,124 @@
+package currency
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/shopspring/decimal"
+)
+
+// Service define a currency conversion service based on a static rate table.
+type Service interface {
+	// ReportingCurrency returns ISO 4217 code of currency used in reports.
+	ReportingCurrency() string
+	// IsSupported returns true if given currency can be converted to
+	// reporting currency.
+	IsSupported(code string) bool
+	// Convert converts amount in given currency to reporting currency. False is
+	// returned if currency isn't supported.
+	Convert(amount decimal.Decimal, code string) (decimal.Decimal, bool)
+	// Rates returns conversion rates to reporting currency of supported
+	// currencies.
+	Rates() map[string]decimal.Decimal
+}
+
+type service struct {
+	reporting string
+	rates     map[string]decimal.Decimal
+}
+
+// NewService returns a new currency Service.
+func NewService(cfg Config, logger log.Logger) (Service, error) {
+	logger = logger.With("service", "currency")
+
+	rates, err := parseRates(cfg.Reporting, cfg.Rates)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("currency conversion rates loaded",
+		"reporting_currency", cfg.Reporting,
+		"rates", rates,
+	)
+
+	return &service{
+		reporting: cfg.Reporting,
+		rates:     rates,
+	}, nil
+}
+
+// ReportingCurrency implements Service.
+func (s *service) ReportingCurrency() string {
+	return s.reporting
+}
+
+// IsSupported implements Service.
+func (s *service) IsSupported(code string) bool {
+	_, ok := s.rates[code]
+	return ok
+}
+
+// Convert implements Service.
+func (s *service) Convert(amount decimal.Decimal, code string) (decimal.Decimal, bool) {
+	rate, ok := s.rates[code]
+	if !ok {
+		return decimal.Zero, false
+	}
+
+	return amount.Mul(rate), true
+}
+
+// Rates implements Service.
+func (s *service) Rates() map[string]decimal.Decimal {
+	return maps.Clone(s.rates)
+}
+
+// IsValidCode returns true if code looks like an ISO 4217 currency code, that
+// is, three uppercase ASCII letters.
+func IsValidCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range []byte(code) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRates parses a list of CODE=RATE conversion rates. Reporting currency
+// is always supported with a rate of 1.
+func parseRates(reporting string, list []string) (map[string]decimal.Decimal, error) {
+	rates := map[string]decimal.Decimal{
+		reporting: decimal.NewFromInt(1),
+	}
+
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		code, rateStr, ok := strings.Cut(entry, "=")
+		code = strings.TrimSpace(code)
+		if !ok || !IsValidCode(code) {
+			return nil, fmt.Errorf("invalid currency rate %q: expected CODE=RATE (e.g. EUR=1.08)", entry)
+		}
+
+		rate, err := decimal.NewFromString(strings.TrimSpace(rateStr))
+		if err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("invalid currency rate %q: rate must be a positive decimal number", entry)
+		}
+
+		if code == reporting && !rate.Equal(decimal.NewFromInt(1)) {
+			return nil, fmt.Errorf("invalid currency rate %q: reporting currency rate must be 1", entry)
+		}
+
+		rates[code] = rate
+	}
+
+	return rates, nil
+}
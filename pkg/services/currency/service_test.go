@@ -0,0 +1,85 @@
+package currency
+
+import (
+	"io"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("currency_service_test", io.Discard, false)
+
+	t.Run("NewService", func(t *testing.T) {
+		t.Run("Error", func(t *testing.T) {
+			for _, rates := range [][]string{
+				{"EUR"},
+				{"eur=1.08"},
+				{"EURO=1.08"},
+				{"EUR=abc"},
+				{"EUR=0"},
+				{"EUR=-1"},
+				{"USD=2"},
+			} {
+				t.Run(rates[0], func(t *testing.T) {
+					service, err := NewService(Config{Reporting: "USD", Rates: rates}, logger)
+					require.Error(t, err)
+					require.Nil(t, service)
+				})
+			}
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			service, err := NewService(Config{
+				Reporting: "USD",
+				Rates:     []string{"EUR=1.08", " GBP = 1.27 ", ""},
+			}, logger)
+			require.NoError(t, err)
+			require.NotNil(t, service)
+		})
+	})
+
+	t.Run("Convert", func(t *testing.T) {
+		service, err := NewService(Config{
+			Reporting: "USD",
+			Rates:     []string{"EUR=1.08"},
+		}, logger)
+		require.NoError(t, err)
+
+		t.Run("ReportingCurrency", func(t *testing.T) {
+			amount, ok := service.Convert(decimal.RequireFromString("12.34"), "USD")
+			require.True(t, ok)
+			require.Equal(t, "12.34", amount.String())
+		})
+
+		t.Run("SupportedCurrency", func(t *testing.T) {
+			amount, ok := service.Convert(decimal.RequireFromString("10"), "EUR")
+			require.True(t, ok)
+			require.Equal(t, "10.8", amount.String())
+		})
+
+		t.Run("UnsupportedCurrency", func(t *testing.T) {
+			require.False(t, service.IsSupported("JPY"))
+			_, ok := service.Convert(decimal.RequireFromString("10"), "JPY")
+			require.False(t, ok)
+		})
+	})
+	t.Run("Rates", func(t *testing.T) {
+		service, err := NewService(Config{
+			Reporting: "USD",
+			Rates:     []string{"EUR=1.08"},
+		}, logger)
+		require.NoError(t, err)
+
+		rates := service.Rates()
+		require.Len(t, rates, 2)
+		require.Equal(t, "1", rates["USD"].String())
+		require.Equal(t, "1.08", rates["EUR"].String())
+
+		// Returned map is a copy.
+		delete(rates, "EUR")
+		require.True(t, service.IsSupported("EUR"))
+	})
+}
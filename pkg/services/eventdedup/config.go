@@ -0,0 +1,32 @@
+package eventdedup
+
+import (
+	"errors"
+	"time"
+
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	Window     time.Duration
+	MaxEntries uint64
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.DurationVar(&c.Window, "eventdedup.window", 5*time.Minute, "`duration` during which events with the same id are deduplicated, 0 disables in-memory deduplication")
+	f.Uint64Var(&c.MaxEntries, "eventdedup.max.entries", 100_000, "maximum `number` of event ids kept in memory")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Window < 0 {
+		errs = append(errs, errors.New("event deduplication window must be positive"))
+	}
+	if c.MaxEntries < 1 {
+		errs = append(errs, errors.New("event deduplication maximum entries must be greater than or equal to 1"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,28 @@
+package eventdedup
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	duplicates *prometheus.CounterVec
+	evicted    prometheus.Counter
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		duplicates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventdedup_duplicates_total",
+			Help: "Number of duplicate events dropped or rejected as in flight",
+		}, []string{"endpoint", "state"}),
+		evicted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "eventdedup_evicted_total",
+			Help: "Number of event ids evicted before the end of deduplication window",
+		}),
+	}
+
+	promRegistry.MustRegister(
+		m.duplicates,
+		m.evicted,
+	)
+
+	return m
+}
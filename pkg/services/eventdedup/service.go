@@ -0,0 +1,219 @@
+package eventdedup
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// IdHeader is the header holding client supplied event id.
+const IdHeader = "X-Prisme-Event-Id"
+
+// IdQueryParam is the query parameter holding client supplied event id of
+// requests that can't set headers. It takes precedence over IdHeader.
+const IdQueryParam = "event-id"
+
+// MaxIdLength defines maximum length of an event id.
+const MaxIdLength = 64
+
+var idRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// Event holds identity of a deduplicated event.
+type Event struct {
+	Domain   string
+	Endpoint string
+	Id       string
+}
+
+// ClaimResult define result of an event claim.
+type ClaimResult uint8
+
+// Claim results.
+const (
+	// Event wasn't claimed within deduplication window and is now claimed.
+	Claimed ClaimResult = iota
+	// Event was claimed but isn't completed yet. Claim may still be released
+	// if event processing fails so request must be retried later.
+	InFlight
+	// Event was claimed and completed within deduplication window.
+	Duplicate
+)
+
+// Service define an events deduplication service. Event ids are kept in a
+// bounded in-memory window, an event is a duplicate if an event with the same
+// id was claimed for the same domain and endpoint within the window. Oldest ids
+// are evicted when maximum number of entries is reached.
+//
+// Deduplication window isn't shared between replicas.
+type Service interface {
+	// Claim claims given event if it wasn't already claimed within
+	// deduplication window. InFlight and Duplicate results are reported in
+	// metrics.
+	Claim(Event) ClaimResult
+	// Complete marks a claimed event as processed, following claims of the
+	// same event are duplicates.
+	Complete(Event)
+	// Release releases a claimed event so it can be claimed again (e.g. when
+	// event was rejected and will be retried).
+	Release(Event)
+}
+
+type claim struct {
+	seq  uint64
+	done bool
+}
+
+type entry struct {
+	key       string
+	seq       uint64
+	claimedAt time.Time
+}
+
+type service struct {
+	logger     log.Logger
+	metrics    metrics
+	window     time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu  sync.Mutex
+	seq uint64
+	// Claims of event keys.
+	claims map[string]claim
+	// Claimed keys in claim order. Released keys are removed from claims map
+	// only and are skipped when they're dequeued.
+	queue []entry
+}
+
+// NewService returns a new events deduplication Service.
+func NewService(cfg Config, logger log.Logger, promRegistry *prometheus.Registry) Service {
+	logger = logger.With("service", "eventdedup")
+
+	logger.Info(
+		"event deduplication configured",
+		"window", cfg.Window,
+		"max_entries", cfg.MaxEntries,
+	)
+
+	return &service{
+		logger:     logger,
+		metrics:    newMetrics(promRegistry),
+		window:     cfg.Window,
+		maxEntries: int(cfg.MaxEntries),
+		now:        time.Now,
+		claims:     make(map[string]claim),
+	}
+}
+
+// Claim implements Service.
+func (s *service) Claim(ev Event) ClaimResult {
+	if s.window == 0 || ev.Id == "" {
+		return Claimed
+	}
+
+	key := ev.key()
+
+	s.mu.Lock()
+	now := s.now()
+	s.evict(now, false)
+	result := Claimed
+	if c, ok := s.claims[key]; ok {
+		result = InFlight
+		if c.done {
+			result = Duplicate
+		}
+	} else {
+		s.evict(now, true)
+		s.seq++
+		s.claims[key] = claim{seq: s.seq}
+		s.queue = append(s.queue, entry{key: key, seq: s.seq, claimedAt: now})
+	}
+	s.mu.Unlock()
+
+	switch result {
+	case InFlight:
+		s.metrics.duplicates.With(prometheus.Labels{
+			"endpoint": ev.Endpoint,
+			"state":    "in_flight",
+		}).Inc()
+	case Duplicate:
+		s.metrics.duplicates.With(prometheus.Labels{
+			"endpoint": ev.Endpoint,
+			"state":    "done",
+		}).Inc()
+	}
+
+	return result
+}
+
+// Complete implements Service.
+func (s *service) Complete(ev Event) {
+	if s.window == 0 || ev.Id == "" {
+		return
+	}
+
+	key := ev.key()
+
+	s.mu.Lock()
+	if c, ok := s.claims[key]; ok {
+		c.done = true
+		s.claims[key] = c
+	}
+	s.mu.Unlock()
+}
+
+// Release implements Service.
+func (s *service) Release(ev Event) {
+	if s.window == 0 || ev.Id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.claims, ev.key())
+	s.mu.Unlock()
+}
+
+// evict dequeues expired entries and, if room is true, oldest entries until
+// there is room for a new one. Caller must hold s.mu.
+func (s *service) evict(now time.Time, room bool) {
+	for len(s.queue) > 0 {
+		head := s.queue[0]
+		expired := now.Sub(head.claimedAt) >= s.window
+		if !expired && (!room || len(s.queue) < s.maxEntries) {
+			break
+		}
+
+		s.queue[0] = entry{}
+		s.queue = s.queue[1:]
+
+		// Entry was released and maybe claimed again.
+		c, ok := s.claims[head.key]
+		if !ok || c.seq != head.seq {
+			continue
+		}
+
+		delete(s.claims, head.key)
+		if !expired {
+			s.metrics.evicted.Inc()
+		}
+	}
+}
+
+func (ev Event) key() string {
+	return ev.Domain + ":" + ev.Endpoint + ":" + ev.Id
+}
+
+// ValidateId returns an error if given client supplied event id is invalid.
+func ValidateId(id string) error {
+	if len(id) > MaxIdLength {
+		return fmt.Errorf("event id is too long (max %v)", MaxIdLength)
+	}
+	if !idRegex.MatchString(id) {
+		return fmt.Errorf("invalid event id %q", id)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package eventdedup
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("eventdedup_service_test", io.Discard, false)
+
+	newService := func(t *testing.T, cfg Config) (*service, *time.Time) {
+		require.NoError(t, cfg.Validate())
+		srv := NewService(cfg, logger, prometheus.NewRegistry())
+
+		now := time.Now()
+		srv.(*service).now = func() time.Time { return now }
+		return srv.(*service), &now
+	}
+
+	ev := Event{Domain: "example.com", Endpoint: "custom", Id: "0192f2b4-5a3c-7def-8123-456789abcdef"}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		srv, _ := newService(t, Config{Window: time.Minute, MaxEntries: 10})
+
+		require.Equal(t, Claimed, srv.Claim(ev))
+		srv.Complete(ev)
+		require.Equal(t, Duplicate, srv.Claim(ev))
+		require.Equal(t, Duplicate, srv.Claim(ev))
+
+		// Same id on another domain or endpoint isn't a duplicate.
+		require.Equal(t, Claimed, srv.Claim(Event{Domain: "example.org", Endpoint: ev.Endpoint, Id: ev.Id}))
+		require.Equal(t, Claimed, srv.Claim(Event{Domain: ev.Domain, Endpoint: "pageviews", Id: ev.Id}))
+
+		require.Equal(t, 2.0, testutil.ToFloat64(srv.metrics.duplicates.WithLabelValues("custom", "done")))
+	})
+
+	t.Run("InFlight", func(t *testing.T) {
+		srv, _ := newService(t, Config{Window: time.Minute, MaxEntries: 10})
+
+		require.Equal(t, Claimed, srv.Claim(ev))
+		require.Equal(t, InFlight, srv.Claim(ev))
+
+		// First request failed, retry is accepted.
+		srv.Release(ev)
+		require.Equal(t, Claimed, srv.Claim(ev))
+
+		require.Equal(t, 1.0, testutil.ToFloat64(srv.metrics.duplicates.WithLabelValues("custom", "in_flight")))
+	})
+
+	t.Run("WindowExpired", func(t *testing.T) {
+		srv, now := newService(t, Config{Window: time.Minute, MaxEntries: 10})
+
+		require.Equal(t, Claimed, srv.Claim(ev))
+		srv.Complete(ev)
+		*now = now.Add(30 * time.Second)
+		require.Equal(t, Duplicate, srv.Claim(ev))
+		*now = now.Add(30 * time.Second)
+		require.Equal(t, Claimed, srv.Claim(ev))
+		require.Equal(t, 0.0, testutil.ToFloat64(srv.metrics.evicted))
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		srv, _ := newService(t, Config{Window: time.Minute, MaxEntries: 2})
+
+		require.Equal(t, Claimed, srv.Claim(ev))
+		srv.Release(ev)
+		require.Equal(t, Claimed, srv.Claim(ev))
+
+		// Released entry is skipped and doesn't evict new claim.
+		require.Equal(t, Claimed, srv.Claim(Event{Domain: ev.Domain, Endpoint: ev.Endpoint, Id: "other"}))
+		require.Equal(t, InFlight, srv.Claim(ev))
+		require.Equal(t, 0.0, testutil.ToFloat64(srv.metrics.evicted))
+	})
+
+	t.Run("MaxEntries", func(t *testing.T) {
+		srv, _ := newService(t, Config{Window: time.Minute, MaxEntries: 2})
+
+		ids := []string{"a", "b", "c"}
+		for _, id := range ids {
+			require.Equal(t, Claimed, srv.Claim(Event{Domain: ev.Domain, Endpoint: ev.Endpoint, Id: id}))
+		}
+
+		// "a" was evicted.
+		require.Equal(t, Claimed, srv.Claim(Event{Domain: ev.Domain, Endpoint: ev.Endpoint, Id: "a"}))
+		require.Equal(t, 2.0, testutil.ToFloat64(srv.metrics.evicted))
+		require.LessOrEqual(t, len(srv.claims), 2)
+		require.LessOrEqual(t, len(srv.queue), 2)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		srv, _ := newService(t, Config{Window: 0, MaxEntries: 10})
+
+		require.Equal(t, Claimed, srv.Claim(ev))
+		require.Equal(t, Claimed, srv.Claim(ev))
+	})
+}
+
+func TestValidateId(t *testing.T) {
+	for _, id := range []string{"0192f2b4-5a3c-7def-8123-456789abcdef", "1730451234.42", "a:b_c"} {
+		require.NoError(t, ValidateId(id), id)
+	}
+	for _, id := range []string{"", "foo bar", "<script>", strings.Repeat("a", MaxIdLength+1)} {
+		require.Error(t, ValidateId(id), id)
+	}
+}
@@ -0,0 +1,45 @@
+package eventlimits
+
+import (
+	"errors"
+	"time"
+
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	MaxBodySize       uint64
+	MaxProperties     uint64
+	MaxKeyLength      uint64
+	MaxValueLength    uint64
+	MaxNameLength     uint64
+	MaxNamesPerDomain uint64
+	NamesSyncInterval time.Duration
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.Uint64Var(&c.MaxBodySize, "eventlimits.max.body.size", 16*1024, "maximum `size` in bytes of custom events and pageviews body, compressed bodies are limited once decompressed")
+	f.Uint64Var(&c.MaxProperties, "eventlimits.max.properties", 64, "maximum `number` of properties per custom event or pageview")
+	f.Uint64Var(&c.MaxKeyLength, "eventlimits.max.key.length", 128, "maximum `length` of custom events and pageviews property keys")
+	f.Uint64Var(&c.MaxValueLength, "eventlimits.max.value.length", 2048, "maximum `length` of custom events and pageviews JSON encoded property values")
+	f.Uint64Var(&c.MaxNameLength, "eventlimits.max.name.length", 128, "maximum `length` of custom events name")
+	f.Uint64Var(&c.MaxNamesPerDomain, "eventlimits.max.names.per.domain", 1000, "maximum `number` of distinct custom events name per domain")
+	f.DurationVar(&c.NamesSyncInterval, "eventlimits.names.sync.interval", 5*time.Minute, "`interval` between synchronizations of distinct custom events name with event database, 0 disables periodic synchronization")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.MaxBodySize < 2 {
+		errs = append(errs, errors.New("custom events maximum body size must be greater than or equal to 2"))
+	}
+	if c.MaxNameLength < 1 {
+		errs = append(errs, errors.New("custom events maximum name length must be greater than or equal to 1"))
+	}
+	if c.MaxNamesPerDomain < 1 {
+		errs = append(errs, errors.New("custom events maximum names per domain must be greater than or equal to 1"))
+	}
+	return errors.Join(errs...)
+}
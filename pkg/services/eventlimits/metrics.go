@@ -0,0 +1,22 @@
+package eventlimits
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	rejectedEvents *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		rejectedEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventlimits_rejected_events_total",
+			Help: "Number of custom events rejected due to limits",
+		}, []string{"reason"}),
+	}
+
+	promRegistry.MustRegister(
+		m.rejectedEvents,
+	)
+
+	return m
+}
@@ -0,0 +1,212 @@
+package eventlimits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/dataview"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/sql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ErrBodyTooLarge = errors.New("body too large")
+	ErrNameTooLong  = errors.New("custom event name too long")
+	ErrTooManyNames = errors.New("too many distinct custom event names")
+)
+
+// Service define a custom events limits service.
+type Service interface {
+	// CheckBodySize returns an error wrapping ErrBodyTooLarge if body size
+	// exceeds limit. Error message doesn't mention event kind so callers
+	// should prefix it.
+	CheckBodySize(size int) error
+	// MaxBodySize returns maximum size in bytes of events body.
+	MaxBodySize() uint64
+	// KvLimits returns limits to apply on custom events properties.
+	KvLimits() dataview.KvLimits
+	// CheckName returns an error wrapping ErrNameTooLong or ErrTooManyNames if
+	// event name is too long or if domain already reached its maximum number
+	// of distinct event names.
+	CheckName(domain, name string) error
+	// ReportRejected records an event rejected due to limits error.
+	ReportRejected(err error)
+}
+
+type service struct {
+	logger  log.Logger
+	cfg     Config
+	metrics metrics
+	mu      sync.RWMutex
+	// Distinct event names per domain. Names are loaded from events_custom
+	// table on startup and periodically so limit holds across restarts and
+	// replicas.
+	names map[string]map[string]struct{}
+}
+
+// NewService returns a new custom events limits Service. Distinct event names
+// already stored in db are loaded before returning and then synchronized
+// every Config.NamesSyncInterval. If db is nil, names are only tracked in
+// memory.
+func NewService(
+	cfg Config,
+	db sql.DB,
+	logger log.Logger,
+	promRegistry *prometheus.Registry,
+) (Service, error) {
+	s := &service{
+		logger:  logger.With("service", "eventlimits"),
+		cfg:     cfg,
+		metrics: newMetrics(promRegistry),
+		names:   make(map[string]map[string]struct{}),
+	}
+
+	if db == nil {
+		return s, nil
+	}
+
+	err := s.syncNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.NamesSyncInterval > 0 {
+		go s.syncNamesLoop(db)
+	}
+
+	return s, nil
+}
+
+// syncNames adds distinct custom event names stored in db to tracked names.
+// At most Config.MaxNamesPerDomain names are loaded per domain.
+func (s *service) syncNames(db sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var b sql.Builder
+	b.Strs("SELECT domain, name",
+		"FROM events_custom",
+		"GROUP BY domain, name").
+		Fmt("LIMIT %v BY domain", s.cfg.MaxNamesPerDomain)
+	query, args := b.Finish()
+
+	result, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load custom events names: %w", err)
+	}
+	defer result.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for result.Next() {
+		var domain, name string
+		err := result.Scan(&domain, &name)
+		if err != nil {
+			return fmt.Errorf("failed to load custom events names: %w", err)
+		}
+
+		names := s.names[domain]
+		if names == nil {
+			names = make(map[string]struct{})
+			s.names[domain] = names
+		}
+		names[name] = struct{}{}
+	}
+
+	return nil
+}
+
+func (s *service) syncNamesLoop(db sql.DB) {
+	tick := time.NewTicker(s.cfg.NamesSyncInterval)
+
+	for range tick.C {
+		err := s.syncNames(db)
+		if err != nil {
+			s.logger.Err("failed to synchronize custom events names", err)
+		}
+	}
+}
+
+// CheckBodySize implements Service.
+func (s *service) CheckBodySize(size int) error {
+	if size > 0 && uint64(size) > s.cfg.MaxBodySize {
+		return fmt.Errorf("%w: maximum size is %v bytes", ErrBodyTooLarge, s.cfg.MaxBodySize)
+	}
+	return nil
+}
+
+// MaxBodySize implements Service.
+func (s *service) MaxBodySize() uint64 {
+	return s.cfg.MaxBodySize
+}
+
+// KvLimits implements Service.
+func (s *service) KvLimits() dataview.KvLimits {
+	return dataview.KvLimits{
+		MaxKeys:        int(s.cfg.MaxProperties),
+		MaxKeyLength:   int(s.cfg.MaxKeyLength),
+		MaxValueLength: int(s.cfg.MaxValueLength),
+	}
+}
+
+// CheckName implements Service.
+func (s *service) CheckName(domain, name string) error {
+	if uint64(len(name)) > s.cfg.MaxNameLength {
+		return fmt.Errorf("%w: maximum length is %v", ErrNameTooLong, s.cfg.MaxNameLength)
+	}
+
+	s.mu.RLock()
+	_, ok := s.names[domain][name]
+	s.mu.RUnlock()
+	if ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := s.names[domain]
+	if names == nil {
+		names = make(map[string]struct{})
+		s.names[domain] = names
+	}
+	if _, ok := names[name]; ok {
+		return nil
+	}
+	if uint64(len(names)) >= s.cfg.MaxNamesPerDomain {
+		s.logger.Warn("domain reached maximum number of distinct custom event names",
+			"domain", domain, "event_name", name)
+		return fmt.Errorf("%w: maximum is %v", ErrTooManyNames, s.cfg.MaxNamesPerDomain)
+	}
+	names[name] = struct{}{}
+
+	return nil
+}
+
+// ReportRejected implements Service.
+func (s *service) ReportRejected(err error) {
+	var reason string
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		reason = "body_size"
+	case errors.Is(err, ErrNameTooLong):
+		reason = "name_length"
+	case errors.Is(err, ErrTooManyNames):
+		reason = "distinct_names"
+	case errors.Is(err, dataview.ErrTooManyKeys):
+		reason = "properties"
+	case errors.Is(err, dataview.ErrKeyTooLong):
+		reason = "key_length"
+	case errors.Is(err, dataview.ErrValueTooLong):
+		reason = "value_length"
+	default:
+		return
+	}
+
+	s.metrics.rejectedEvents.With(prometheus.Labels{"reason": reason}).Inc()
+}
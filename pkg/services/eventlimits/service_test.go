@@ -0,0 +1,120 @@
+package eventlimits
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/dataview"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("eventlimits_service_test", io.Discard, false)
+	cfg := Config{
+		MaxBodySize:       16,
+		MaxProperties:     2,
+		MaxKeyLength:      8,
+		MaxValueLength:    8,
+		MaxNameLength:     8,
+		MaxNamesPerDomain: 3,
+	}
+	newService := func(t *testing.T, db sql.DB) Service {
+		srv, err := NewService(cfg, db, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+		return srv
+	}
+
+	t.Run("CheckBodySize", func(t *testing.T) {
+		srv := newService(t, nil)
+		require.Equal(t, uint64(16), srv.MaxBodySize())
+		require.NoError(t, srv.CheckBodySize(0))
+		require.NoError(t, srv.CheckBodySize(16))
+		require.ErrorIs(t, srv.CheckBodySize(17), ErrBodyTooLarge)
+	})
+
+	t.Run("KvLimits", func(t *testing.T) {
+		srv := newService(t, nil)
+		require.Equal(t, dataview.KvLimits{
+			MaxKeys:        2,
+			MaxKeyLength:   8,
+			MaxValueLength: 8,
+		}, srv.KvLimits())
+	})
+
+	t.Run("CheckName", func(t *testing.T) {
+		srv := newService(t, nil)
+
+		require.ErrorIs(t, srv.CheckName("example.com", "very_long_name"), ErrNameTooLong)
+
+		for i := range 3 {
+			require.NoError(t, srv.CheckName("example.com", fmt.Sprint("event", i)))
+		}
+		// Known name.
+		require.NoError(t, srv.CheckName("example.com", "event0"))
+		// New name.
+		require.ErrorIs(t, srv.CheckName("example.com", "event3"), ErrTooManyNames)
+		// Other domain.
+		require.NoError(t, srv.CheckName("example.org", "event3"))
+	})
+
+	t.Run("LoadNames", func(t *testing.T) {
+		srv := newService(t, &namesDB{rows: [][2]string{
+			{"example.com", "event0"},
+			{"example.com", "event1"},
+			{"example.com", "event2"},
+		}})
+
+		// Known name.
+		require.NoError(t, srv.CheckName("example.com", "event0"))
+		// New name.
+		require.ErrorIs(t, srv.CheckName("example.com", "event3"), ErrTooManyNames)
+		// Other domain.
+		require.NoError(t, srv.CheckName("example.org", "event3"))
+	})
+
+	t.Run("ReportRejected", func(t *testing.T) {
+		srv := newService(t, nil)
+		srv.ReportRejected(srv.CheckBodySize(17))
+		srv.ReportRejected(fmt.Errorf("%w: maximum is 2", dataview.ErrTooManyKeys))
+		srv.ReportRejected(dataview.ErrInvalidData)
+
+		rejected := srv.(*service).metrics.rejectedEvents
+		require.Equal(t, 2, testutil.CollectAndCount(rejected))
+		require.Equal(t, 1.0, testutil.ToFloat64(rejected.WithLabelValues("body_size")))
+		require.Equal(t, 1.0, testutil.ToFloat64(rejected.WithLabelValues("properties")))
+	})
+}
+
+// namesDB is an sql.DB returning rows of events name query.
+type namesDB struct {
+	sql.DB
+	rows [][2]string
+}
+
+func (db *namesDB) Query(context.Context, string, ...any) (sql.QueryResult, error) {
+	return &namesResult{rows: db.rows}, nil
+}
+
+type namesResult struct {
+	rows [][2]string
+	i    int
+}
+
+func (r *namesResult) Next() bool {
+	r.i++
+	return r.i <= len(r.rows)
+}
+
+func (r *namesResult) Scan(dest ...any) error {
+	*dest[0].(*string) = r.rows[r.i-1][0]
+	*dest[1].(*string) = r.rows[r.i-1][1]
+	return nil
+}
+
+func (r *namesResult) Close() error { return nil }
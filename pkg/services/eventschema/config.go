@@ -0,0 +1,20 @@
+package eventschema
+
+import (
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	File string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringVar(&c.File, "eventschema.file", "", "`filepath` to JSON custom events schema registry, custom events aren't validated if empty")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	return nil
+}
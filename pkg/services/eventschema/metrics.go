@@ -0,0 +1,22 @@
+package eventschema
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	violations *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		violations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventschema_violations_total",
+			Help: "Number of custom events schema violations",
+		}, []string{"mode", "reason"}),
+	}
+
+	promRegistry.MustRegister(
+		m.violations,
+	)
+
+	return m
+}
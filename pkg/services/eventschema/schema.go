@@ -0,0 +1,94 @@
+package eventschema
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prismelabs/analytics/pkg/dataview"
+)
+
+// Mode defines how custom events violating schema are handled.
+type Mode string
+
+// Supported Mode.
+const (
+	// Events violating schema are rejected.
+	RejectMode Mode = "reject"
+	// Events violating schema are stored and tagged as invalid.
+	TagMode Mode = "tag"
+	// Unknown properties are removed from events, other violations are
+	// handled as in TagMode.
+	StripMode Mode = "strip"
+)
+
+// PropertyType defines expected type of a custom event property. An empty
+// PropertyType accepts any type.
+type PropertyType string
+
+// Supported PropertyType.
+const (
+	AnyType    PropertyType = ""
+	StringType PropertyType = "string"
+	NumberType PropertyType = "number"
+	BoolType   PropertyType = "bool"
+	ObjectType PropertyType = "object"
+	ArrayType  PropertyType = "array"
+)
+
+// Schema defines custom events schema of multiple domains.
+type Schema struct {
+	Domains map[string]DomainSchema `json:"domains"`
+}
+
+// DomainSchema defines allowed custom events of a domain.
+type DomainSchema struct {
+	Mode   Mode                   `json:"mode"`
+	Events map[string]EventSchema `json:"events"`
+}
+
+// EventSchema defines allowed properties of a custom event.
+type EventSchema struct {
+	Properties map[string]PropertySchema `json:"properties"`
+}
+
+// PropertySchema defines a custom event property.
+type PropertySchema struct {
+	Type     PropertyType `json:"type"`
+	Required bool         `json:"required"`
+}
+
+// Validate validates schema of all domains.
+func (s Schema) Validate() error {
+	var errs []error
+	for domain, schema := range s.Domains {
+		if err := schema.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %q schema: %w", domain, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate validates domain schema.
+func (ds DomainSchema) Validate() error {
+	switch ds.Mode {
+	case RejectMode, TagMode, StripMode:
+	default:
+		return fmt.Errorf("invalid mode %q", ds.Mode)
+	}
+
+	for name, ev := range ds.Events {
+		for key, prop := range ev.Properties {
+			switch prop.Type {
+			case AnyType, StringType, NumberType, BoolType, ObjectType, ArrayType:
+			default:
+				return fmt.Errorf("invalid type %q of property %q of event %q", prop.Type, key, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (pt PropertyType) accepts(vt dataview.ValueType) bool {
+	return pt == AnyType || string(pt) == vt.String()
+}
@@ -0,0 +1,196 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/prismelabs/analytics/pkg/event"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrViolation is returned by Service.Validate when a custom event is rejected.
+var ErrViolation = errors.New("custom event violates schema")
+
+// Violation reasons.
+const (
+	unknownEvent    = "unknown_event"
+	unknownProperty = "unknown_property"
+	invalidType     = "invalid_type"
+	missingProperty = "missing_property"
+)
+
+// Service define a custom events schema registry.
+type Service interface {
+	// Validate validates custom event against schema of its domain. Events of
+	// domains without schema are always valid. Depending on domain's Mode, an
+	// error wrapping ErrViolation is returned, event is tagged as invalid or
+	// unknown properties are removed.
+	Validate(*event.Custom) error
+	// Schema returns schema of all domains.
+	Schema() Schema
+	// DomainSchema returns schema of given domain.
+	DomainSchema(domain string) (DomainSchema, bool)
+	// SetDomainSchema validates and replaces schema of given domain.
+	SetDomainSchema(domain string, schema DomainSchema) error
+	// DeleteDomainSchema deletes schema of given domain.
+	DeleteDomainSchema(domain string)
+}
+
+type service struct {
+	logger  log.Logger
+	metrics metrics
+	mu      sync.RWMutex
+	domains map[string]DomainSchema
+}
+
+// NewService returns a new schema registry Service. Schema is loaded from
+// configured file, if any.
+func NewService(cfg Config, logger log.Logger, promRegistry *prometheus.Registry) (Service, error) {
+	logger = logger.With("service", "eventschema")
+
+	srv := &service{
+		logger:  logger,
+		metrics: newMetrics(promRegistry),
+		domains: make(map[string]DomainSchema),
+	}
+
+	if cfg.File != "" {
+		data, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read custom events schema: %w", err)
+		}
+
+		var schema Schema
+		err = json.Unmarshal(data, &schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse custom events schema: %w", err)
+		}
+		err = schema.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		if schema.Domains != nil {
+			srv.domains = schema.Domains
+		}
+		logger.Info("custom events schema loaded", "file", cfg.File, "domains", len(srv.domains))
+	}
+
+	return srv, nil
+}
+
+// Validate implements Service.
+func (s *service) Validate(ev *event.Custom) error {
+	s.mu.RLock()
+	schema, ok := s.domains[ev.PageUri.Host()]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	violation := func(reason, format string, args ...any) error {
+		s.metrics.violations.With(prometheus.Labels{
+			"mode":   string(schema.Mode),
+			"reason": reason,
+		}).Inc()
+
+		if schema.Mode == RejectMode {
+			return fmt.Errorf("%w: "+format, append([]any{ErrViolation}, args...)...)
+		}
+		ev.Invalid = true
+		return nil
+	}
+
+	evSchema, ok := schema.Events[ev.Name]
+	if !ok {
+		return violation(unknownEvent, "unknown event %q", ev.Name)
+	}
+
+	// Check properties.
+	for i := 0; i < len(ev.Keys); {
+		key := ev.Keys[i]
+		prop, ok := evSchema.Properties[key]
+		if !ok {
+			if schema.Mode == StripMode {
+				s.metrics.violations.With(prometheus.Labels{
+					"mode":   string(schema.Mode),
+					"reason": unknownProperty,
+				}).Inc()
+				ev.Keys = append(ev.Keys[:i], ev.Keys[i+1:]...)
+				ev.Values = append(ev.Values[:i], ev.Values[i+1:]...)
+				if i < len(ev.Types) {
+					ev.Types = append(ev.Types[:i], ev.Types[i+1:]...)
+				}
+				continue
+			}
+			if err := violation(unknownProperty, "unknown property %q of event %q", key, ev.Name); err != nil {
+				return err
+			}
+		} else if i < len(ev.Types) && !prop.Type.accepts(ev.Types[i]) {
+			if err := violation(invalidType, "property %q of event %q must be of type %v", key, ev.Name, prop.Type); err != nil {
+				return err
+			}
+		}
+		i++
+	}
+
+	// Check required properties.
+	for key, prop := range evSchema.Properties {
+		if !prop.Required || slices.Contains(ev.Keys, key) {
+			continue
+		}
+		if err := violation(missingProperty, "required property %q of event %q is missing", key, ev.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Schema implements Service.
+func (s *service) Schema() Schema {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return Schema{Domains: maps.Clone(s.domains)}
+}
+
+// DomainSchema implements Service.
+func (s *service) DomainSchema(domain string) (DomainSchema, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema, ok := s.domains[domain]
+	return schema, ok
+}
+
+// SetDomainSchema implements Service.
+func (s *service) SetDomainSchema(domain string, schema DomainSchema) error {
+	err := schema.Validate()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.domains[domain] = schema
+	s.mu.Unlock()
+
+	s.logger.Info("custom events schema updated", "domain", domain)
+
+	return nil
+}
+
+// DeleteDomainSchema implements Service.
+func (s *service) DeleteDomainSchema(domain string) {
+	s.mu.Lock()
+	delete(s.domains, domain)
+	s.mu.Unlock()
+
+	s.logger.Info("custom events schema deleted", "domain", domain)
+}
@@ -0,0 +1,151 @@
+package eventschema
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/dataview"
+	"github.com/prismelabs/analytics/pkg/event"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/testutils"
+	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("eventschema_service_test", io.Discard, false)
+
+	schema := func(mode Mode) DomainSchema {
+		return DomainSchema{
+			Mode: mode,
+			Events: map[string]EventSchema{
+				"purchase": {
+					Properties: map[string]PropertySchema{
+						"plan":     {Type: StringType, Required: true},
+						"quantity": {Type: NumberType},
+					},
+				},
+			},
+		}
+	}
+
+	customEvent := func(name string, keys []string, values ...string) *event.Custom {
+		ev := &event.Custom{
+			PageUri: testutils.Must(uri.Parse)("https://example.com/checkout"),
+			Name:    name,
+			Keys:    keys,
+			Values:  values,
+		}
+		for _, v := range values {
+			ev.Types = append(ev.Types, dataview.ValueTypeOf([]byte(v)))
+		}
+		return ev
+	}
+
+	t.Run("NewService", func(t *testing.T) {
+		t.Run("NoFile", func(t *testing.T) {
+			service, err := NewService(Config{}, logger, prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.Empty(t, service.Schema().Domains)
+		})
+
+		t.Run("InvalidMode", func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), "schema.json")
+			err := os.WriteFile(fpath, []byte(`{"domains":{"example.com":{"mode":"drop"}}}`), 0o600)
+			require.NoError(t, err)
+
+			service, err := NewService(Config{File: fpath}, logger, prometheus.NewRegistry())
+			require.Error(t, err)
+			require.Nil(t, service)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), "schema.json")
+			err := os.WriteFile(fpath, []byte(`{"domains":{"example.com":{"mode":"reject","events":{"purchase":{"properties":{"plan":{"type":"string","required":true}}}}}}}`), 0o600)
+			require.NoError(t, err)
+
+			service, err := NewService(Config{File: fpath}, logger, prometheus.NewRegistry())
+			require.NoError(t, err)
+			domainSchema, ok := service.DomainSchema("example.com")
+			require.True(t, ok)
+			require.Equal(t, RejectMode, domainSchema.Mode)
+		})
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("NoSchema", func(t *testing.T) {
+			service, err := NewService(Config{}, logger, prometheus.NewRegistry())
+			require.NoError(t, err)
+
+			ev := customEvent("foo", []string{"bar"}, "1")
+			require.NoError(t, service.Validate(ev))
+			require.False(t, ev.Invalid)
+		})
+
+		t.Run("RejectMode", func(t *testing.T) {
+			promRegistry := prometheus.NewRegistry()
+			srv, err := NewService(Config{}, logger, promRegistry)
+			require.NoError(t, err)
+			require.NoError(t, srv.SetDomainSchema("example.com", schema(RejectMode)))
+
+			ev := customEvent("purchase", []string{"plan", "quantity"}, `"pro"`, "2")
+			require.NoError(t, srv.Validate(ev))
+			require.False(t, ev.Invalid)
+
+			ev = customEvent("purchse", []string{"plan"}, `"pro"`)
+			require.ErrorIs(t, srv.Validate(ev), ErrViolation)
+
+			ev = customEvent("purchase", []string{"plan", "quantity"}, `"pro"`, `"2"`)
+			require.ErrorIs(t, srv.Validate(ev), ErrViolation)
+
+			ev = customEvent("purchase", []string{"quantity"}, "2")
+			require.ErrorIs(t, srv.Validate(ev), ErrViolation)
+
+			require.Equal(t, 3, testutil.CollectAndCount(srv.(*service).metrics.violations))
+		})
+
+		t.Run("TagMode", func(t *testing.T) {
+			service, err := NewService(Config{}, logger, prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.NoError(t, service.SetDomainSchema("example.com", schema(TagMode)))
+
+			ev := customEvent("purchase", []string{"plan", "foo"}, `"pro"`, "1")
+			require.NoError(t, service.Validate(ev))
+			require.True(t, ev.Invalid)
+			require.Equal(t, []string{"plan", "foo"}, ev.Keys)
+		})
+
+		t.Run("StripMode", func(t *testing.T) {
+			service, err := NewService(Config{}, logger, prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.NoError(t, service.SetDomainSchema("example.com", schema(StripMode)))
+
+			ev := customEvent("purchase", []string{"foo", "plan", "bar"}, "1", `"pro"`, "true")
+			require.NoError(t, service.Validate(ev))
+			require.False(t, ev.Invalid)
+			require.Equal(t, []string{"plan"}, ev.Keys)
+			require.Equal(t, []string{`"pro"`}, ev.Values)
+			require.Equal(t, []dataview.ValueType{dataview.StringValue}, ev.Types)
+
+			// Missing required property.
+			ev = customEvent("purchase", []string{"foo"}, "1")
+			require.NoError(t, service.Validate(ev))
+			require.True(t, ev.Invalid)
+			require.Empty(t, ev.Keys)
+		})
+
+		t.Run("DeleteDomainSchema", func(t *testing.T) {
+			service, err := NewService(Config{}, logger, prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.NoError(t, service.SetDomainSchema("example.com", schema(RejectMode)))
+			service.DeleteDomainSchema("example.com")
+
+			ev := customEvent("foo", nil)
+			require.NoError(t, service.Validate(ev))
+		})
+	})
+}
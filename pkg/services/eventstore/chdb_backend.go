@@ -51,6 +51,10 @@ var (
 		customEventKind:            "events_custom",
 		fileDownloadEventKind:      "file_downloads",
 		outboundLinkClickEventKind: "outbound_link_clicks",
+		engagementEventKind:        "engagements",
+		webVitalEventKind:          "web_vitals",
+		errorEventKind:             "errors",
+		botPageviewEventKind:       "bot_pageviews",
 	}
 )
 
@@ -102,9 +106,19 @@ func (cb *chdbBackend) appendToBatch(ev any) error {
 				UtmCampaign:     e.Session.Utm.Campaign,
 				UtmTerm:         e.Session.Utm.Term,
 				UtmContent:      e.Session.Utm.Content,
-				Version:         e.Status,
-				ExitStatus:      e.Session.PageviewCount - 1, // Cancel previous version.
+				Version:         e.Session.PageviewCount - 1, // Cancel previous version.
+				ExitStatus:      e.Status,
 				Sign:            -1,
+				ExitKeys:        e.Keys,
+				ExitValues:      e.Values,
+				ExitSearchTerm:  e.SearchTerm,
+				Source:          e.Session.Source.Name,
+				Channel:         e.Session.Source.Channel,
+				BotScore:        e.Session.BotScore,
+				AppVersion:      e.Session.AppVersion,
+				OsVersion:       e.Session.OsVersion,
+				ExitTitle:       e.Title,
+				ExitEventId:     e.EventId,
 			})
 			if err != nil {
 				return err
@@ -131,19 +145,38 @@ func (cb *chdbBackend) appendToBatch(ev any) error {
 			Version:         e.Session.PageviewCount,
 			ExitStatus:      e.Status,
 			Sign:            1,
+			ExitKeys:        e.Keys,
+			ExitValues:      e.Values,
+			ExitSearchTerm:  e.SearchTerm,
+			Source:          e.Session.Source.Name,
+			Channel:         e.Session.Source.Channel,
+			BotScore:        e.Session.BotScore,
+			AppVersion:      e.Session.AppVersion,
+			OsVersion:       e.Session.OsVersion,
+			ExitTitle:       e.Title,
+			ExitEventId:     e.EventId,
 		})
 
 	case *event.Custom:
 		tab := cb.eventBatches[customEventKind]
+		typedValues := typedValuesOf(e)
 		return tab.append(customEvent{
-			Timestamp:   e.Timestamp.UTC().Format(time.DateTime),
-			Domain:      "",
-			Path:        e.Session.PageUri.Path(),
-			VisitorId:   e.Session.VisitorId,
-			SessionUuid: e.Session.SessionUuid,
-			Name:        e.Name,
-			Keys:        e.Keys,
-			Values:      e.Values,
+			Timestamp:    e.Timestamp.UTC().Format(time.DateTime),
+			Domain:       "",
+			Path:         e.Session.PageUri.Path(),
+			VisitorId:    e.Session.VisitorId,
+			SessionUuid:  e.Session.SessionUuid,
+			EventId:      e.EventId,
+			Name:         e.Name,
+			Keys:         e.Keys,
+			Values:       e.Values,
+			Revenue:      json.Number(e.Revenue.String()),
+			Currency:     e.Currency,
+			ValueTypes:   typedValues.types,
+			StringValues: typedValues.strings,
+			NumberValues: typedValues.numbers,
+			BoolValues:   typedValues.bools,
+			Invalid:      e.Invalid,
 		})
 
 	case *event.OutboundLinkClick:
@@ -154,6 +187,7 @@ func (cb *chdbBackend) appendToBatch(ev any) error {
 			Path:        e.Session.PageUri.Path(),
 			VisitorId:   e.Session.VisitorId,
 			SessionUuid: e.Session.SessionUuid,
+			EventId:     e.EventId,
 			Link:        e.Link.String(),
 		})
 
@@ -165,9 +199,62 @@ func (cb *chdbBackend) appendToBatch(ev any) error {
 			Path:        e.Session.PageUri.Path(),
 			VisitorId:   e.Session.VisitorId,
 			SessionUuid: e.Session.SessionUuid,
+			EventId:     e.EventId,
 			FileUrl:     e.FileUrl.String(),
 		})
 
+	case *event.Engagement:
+		tab := cb.eventBatches[engagementEventKind]
+		return tab.append(engagement{
+			Timestamp:   e.Timestamp.UTC().Format(time.DateTime),
+			Domain:      e.Session.PageUri.Host(),
+			Path:        e.PageUri.Path(),
+			VisitorId:   e.Session.VisitorId,
+			SessionUuid: e.Session.SessionUuid,
+			EventId:     e.EventId,
+			ActiveTime:  uint32(e.ActiveTime.Milliseconds()),
+			ScrollDepth: e.ScrollDepth,
+		})
+
+	case *event.WebVital:
+		tab := cb.eventBatches[webVitalEventKind]
+		return tab.append(webVital{
+			Timestamp:   e.Timestamp.UTC().Format(time.DateTime),
+			Domain:      e.Session.PageUri.Host(),
+			Path:        e.PageUri.Path(),
+			VisitorId:   e.Session.VisitorId,
+			SessionUuid: e.Session.SessionUuid,
+			EventId:     e.EventId,
+			Metric:      string(e.Metric),
+			Value:       e.Value,
+		})
+
+	case *event.Error:
+		tab := cb.eventBatches[errorEventKind]
+		return tab.append(errorEvent{
+			Timestamp:   e.Timestamp.UTC().Format(time.DateTime),
+			Domain:      e.Session.PageUri.Host(),
+			Path:        e.PageUri.Path(),
+			VisitorId:   e.Session.VisitorId,
+			SessionUuid: e.Session.SessionUuid,
+			EventId:     e.EventId,
+			Message:     e.Message,
+			Source:      e.Source,
+			Line:        e.Line,
+			Column:      e.Column,
+			Fingerprint: e.Fingerprint,
+		})
+
+	case *event.BotPageView:
+		tab := cb.eventBatches[botPageviewEventKind]
+		return tab.append(botPageview{
+			Timestamp: e.Timestamp.UTC().Format(time.DateTime),
+			Domain:    e.PageUri.Host(),
+			Path:      e.PageUri.Path(),
+			BotFamily: e.BotFamily,
+			Status:    e.Status,
+		})
+
 	default:
 		panic(fmt.Errorf("unknown event kind: %T", ev))
 	}
@@ -242,33 +329,97 @@ type pageview struct {
 	Version         uint16    `json:"version"`
 	ExitStatus      uint16    `json:"exit_status"`
 	Sign            int       `json:"sign"`
+	ExitKeys        []string  `json:"exit_keys"`
+	ExitValues      []string  `json:"exit_values"`
+	ExitSearchTerm  string    `json:"exit_search_term"`
+	Source          string    `json:"source"`
+	Channel         string    `json:"channel"`
+	BotScore        uint8     `json:"bot_score"`
+	AppVersion      string    `json:"app_version"`
+	OsVersion       string    `json:"os_version"`
+	ExitTitle       string    `json:"exit_title"`
+	ExitEventId     string    `json:"exit_event_id"`
 }
 
 type customEvent struct {
+	Timestamp    string      `json:"timestamp"`
+	Domain       string      `json:"domain"`
+	Path         string      `json:"path"`
+	VisitorId    string      `json:"visitor_id"`
+	SessionUuid  uuid.UUID   `json:"session_uuid"`
+	EventId      string      `json:"event_id"`
+	Name         string      `json:"name"`
+	Keys         []string    `json:"keys"`
+	Values       []string    `json:"values"`
+	Revenue      json.Number `json:"revenue"`
+	Currency     string      `json:"currency"`
+	ValueTypes   []string    `json:"value_types"`
+	StringValues []*string   `json:"string_values"`
+	NumberValues []*float64  `json:"number_values"`
+	BoolValues   []*bool     `json:"bool_values"`
+	Invalid      bool        `json:"invalid"`
+}
+
+type outboundLinkClick struct {
 	Timestamp   string    `json:"timestamp"`
 	Domain      string    `json:"domain"`
 	Path        string    `json:"path"`
 	VisitorId   string    `json:"visitor_id"`
 	SessionUuid uuid.UUID `json:"session_uuid"`
-	Name        string    `json:"name"`
-	Keys        []string  `json:"keys"`
-	Values      []string  `json:"values"`
+	EventId     string    `json:"event_id"`
+	Link        string    `json:"link"`
 }
 
-type outboundLinkClick struct {
+type fileDownload struct {
 	Timestamp   string    `json:"timestamp"`
 	Domain      string    `json:"domain"`
 	Path        string    `json:"path"`
 	VisitorId   string    `json:"visitor_id"`
 	SessionUuid uuid.UUID `json:"session_uuid"`
-	Link        string    `json:"link"`
+	EventId     string    `json:"event_id"`
+	FileUrl     string    `json:"url"`
 }
 
-type fileDownload struct {
+type engagement struct {
 	Timestamp   string    `json:"timestamp"`
 	Domain      string    `json:"domain"`
 	Path        string    `json:"path"`
 	VisitorId   string    `json:"visitor_id"`
 	SessionUuid uuid.UUID `json:"session_uuid"`
-	FileUrl     string    `json:"url"`
+	EventId     string    `json:"event_id"`
+	ActiveTime  uint32    `json:"active_time"`
+	ScrollDepth uint8     `json:"scroll_depth"`
+}
+
+type webVital struct {
+	Timestamp   string    `json:"timestamp"`
+	Domain      string    `json:"domain"`
+	Path        string    `json:"path"`
+	VisitorId   string    `json:"visitor_id"`
+	SessionUuid uuid.UUID `json:"session_uuid"`
+	EventId     string    `json:"event_id"`
+	Metric      string    `json:"metric"`
+	Value       float64   `json:"value"`
+}
+
+type errorEvent struct {
+	Timestamp   string    `json:"timestamp"`
+	Domain      string    `json:"domain"`
+	Path        string    `json:"path"`
+	VisitorId   string    `json:"visitor_id"`
+	SessionUuid uuid.UUID `json:"session_uuid"`
+	EventId     string    `json:"event_id"`
+	Message     string    `json:"message"`
+	Source      string    `json:"source"`
+	Line        uint32    `json:"line"`
+	Column      uint32    `json:"column"`
+	Fingerprint string    `json:"fingerprint"`
+}
+
+type botPageview struct {
+	Timestamp string `json:"timestamp"`
+	Domain    string `json:"domain"`
+	Path      string `json:"path"`
+	BotFamily string `json:"bot_family"`
+	Status    uint16 `json:"status"`
 }
@@ -41,6 +41,10 @@ func (cb *clickhouseBackend) prepareBatch() error {
 		customEventKind:            "INSERT INTO events_custom",
 		fileDownloadEventKind:      "INSERT INTO file_downloads",
 		outboundLinkClickEventKind: "INSERT INTO outbound_link_clicks",
+		engagementEventKind:        "INSERT INTO engagements",
+		webVitalEventKind:          "INSERT INTO web_vitals",
+		errorEventKind:             "INSERT INTO errors",
+		botPageviewEventKind:       "INSERT INTO bot_pageviews",
 	}
 
 	for i := range maxEventKind {
@@ -88,6 +92,16 @@ func (cb *clickhouseBackend) appendToBatch(ev any) (err error) {
 				e.Status,
 				e.Session.PageviewCount-1, // Cancel previous version.
 				-1,
+				e.Keys,
+				e.Values,
+				e.SearchTerm,
+				e.Session.Source.Name,
+				e.Session.Source.Channel,
+				e.Session.BotScore,
+				e.Session.AppVersion,
+				e.Session.OsVersion,
+				e.Title,
+				e.EventId,
 			)
 			if err != nil {
 				return err
@@ -114,18 +128,37 @@ func (cb *clickhouseBackend) appendToBatch(ev any) (err error) {
 			e.Status,
 			e.Session.PageviewCount,
 			1,
+			e.Keys,
+			e.Values,
+			e.SearchTerm,
+			e.Session.Source.Name,
+			e.Session.Source.Channel,
+			e.Session.BotScore,
+			e.Session.AppVersion,
+			e.Session.OsVersion,
+			e.Title,
+			e.EventId,
 		)
 	case *event.Custom:
 		batch := cb.eventBatches[customEventKind]
+		typedValues := typedValuesOf(e)
 		return batch.Append(
 			e.Timestamp.UTC(),
 			e.Session.PageUri.Host(),
 			e.PageUri.Path(),
 			e.Session.VisitorId,
 			e.Session.SessionUuid,
+			e.EventId,
 			e.Name,
 			e.Keys,
 			e.Values,
+			e.Revenue,
+			e.Currency,
+			typedValues.types,
+			typedValues.strings,
+			typedValues.numbers,
+			typedValues.bools,
+			e.Invalid,
 		)
 
 	case *event.OutboundLinkClick:
@@ -136,6 +169,7 @@ func (cb *clickhouseBackend) appendToBatch(ev any) (err error) {
 			e.PageUri.Path(),
 			e.Session.VisitorId,
 			e.Session.SessionUuid,
+			e.EventId,
 			e.Link,
 		)
 
@@ -147,9 +181,62 @@ func (cb *clickhouseBackend) appendToBatch(ev any) (err error) {
 			e.PageUri.Path(),
 			e.Session.VisitorId,
 			e.Session.SessionUuid,
+			e.EventId,
 			e.FileUrl,
 		)
 
+	case *event.Engagement:
+		batch := cb.eventBatches[engagementEventKind]
+		return batch.Append(
+			e.Timestamp.UTC(),
+			e.Session.PageUri.Host(),
+			e.PageUri.Path(),
+			e.Session.VisitorId,
+			e.Session.SessionUuid,
+			e.EventId,
+			uint32(e.ActiveTime.Milliseconds()),
+			e.ScrollDepth,
+		)
+
+	case *event.WebVital:
+		batch := cb.eventBatches[webVitalEventKind]
+		return batch.Append(
+			e.Timestamp.UTC(),
+			e.Session.PageUri.Host(),
+			e.PageUri.Path(),
+			e.Session.VisitorId,
+			e.Session.SessionUuid,
+			e.EventId,
+			string(e.Metric),
+			e.Value,
+		)
+
+	case *event.Error:
+		batch := cb.eventBatches[errorEventKind]
+		return batch.Append(
+			e.Timestamp.UTC(),
+			e.Session.PageUri.Host(),
+			e.PageUri.Path(),
+			e.Session.VisitorId,
+			e.Session.SessionUuid,
+			e.EventId,
+			e.Message,
+			e.Source,
+			e.Line,
+			e.Column,
+			e.Fingerprint,
+		)
+
+	case *event.BotPageView:
+		batch := cb.eventBatches[botPageviewEventKind]
+		return batch.Append(
+			e.Timestamp.UTC(),
+			e.PageUri.Host(),
+			e.PageUri.Path(),
+			e.BotFamily,
+			e.Status,
+		)
+
 	default:
 		panic(fmt.Errorf("unknown event kind: %T", ev))
 	}
@@ -0,0 +1,55 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/prismelabs/analytics/pkg/dataview"
+	"github.com/prismelabs/analytics/pkg/event"
+)
+
+// customTypedValues holds typed views of custom event properties values.
+// Slices are aligned with event values and contains nil elements when value
+// has another type.
+type customTypedValues struct {
+	types   []string
+	strings []*string
+	numbers []*float64
+	bools   []*bool
+}
+
+func typedValuesOf(e *event.Custom) customTypedValues {
+	tv := customTypedValues{
+		types:   make([]string, len(e.Values)),
+		strings: make([]*string, len(e.Values)),
+		numbers: make([]*float64, len(e.Values)),
+		bools:   make([]*bool, len(e.Values)),
+	}
+
+	for i, value := range e.Values {
+		var vtype dataview.ValueType
+		if i < len(e.Types) {
+			vtype = e.Types[i]
+		} else {
+			vtype = dataview.ValueTypeOf([]byte(value))
+		}
+		tv.types[i] = vtype.String()
+
+		switch vtype {
+		case dataview.StringValue:
+			var str string
+			if json.Unmarshal([]byte(value), &str) == nil {
+				tv.strings[i] = &str
+			}
+		case dataview.NumberValue:
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				tv.numbers[i] = &f
+			}
+		case dataview.BoolValue:
+			b := value == "true"
+			tv.bools[i] = &b
+		}
+	}
+
+	return tv
+}
@@ -7,5 +7,9 @@ const (
 	customEventKind
 	fileDownloadEventKind
 	outboundLinkClickEventKind
+	engagementEventKind
+	webVitalEventKind
+	errorEventKind
+	botPageviewEventKind
 	maxEventKind
 )
@@ -25,6 +25,10 @@ type Service interface {
 	StoreCustom(context.Context, *event.Custom) error
 	StoreOutboundLinkClick(context.Context, *event.OutboundLinkClick) error
 	StoreFileDownload(context.Context, *event.FileDownload) error
+	StoreEngagement(context.Context, *event.Engagement) error
+	StoreWebVital(context.Context, *event.WebVital) error
+	StoreError(context.Context, *event.Error) error
+	StoreBotPageView(context.Context, *event.BotPageView) error
 }
 
 var backendsFactory = map[string]func(eventdb.Service, teardown.Service) backend{}
@@ -110,6 +114,30 @@ func (s *service) StoreFileDownload(_ context.Context, ev *event.FileDownload) e
 	return nil
 }
 
+// StoreEngagement implements Service.
+func (s *service) StoreEngagement(_ context.Context, ev *event.Engagement) error {
+	s.eventRingBuf.Push(ev)
+	return nil
+}
+
+// StoreWebVital implements Service.
+func (s *service) StoreWebVital(_ context.Context, ev *event.WebVital) error {
+	s.eventRingBuf.Push(ev)
+	return nil
+}
+
+// StoreError implements Service.
+func (s *service) StoreError(_ context.Context, ev *event.Error) error {
+	s.eventRingBuf.Push(ev)
+	return nil
+}
+
+// StoreBotPageView implements Service.
+func (s *service) StoreBotPageView(_ context.Context, ev *event.BotPageView) error {
+	s.eventRingBuf.Push(ev)
+	return nil
+}
+
 // StoreOutboundLinkClick implements Service.
 func (s *service) StoreOutboundLinkClick(_ context.Context, ev *event.OutboundLinkClick) error {
 	s.eventRingBuf.Push(ev)
@@ -0,0 +1,26 @@
+package eventtime
+
+import (
+	"errors"
+	"time"
+
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	MaxAge time.Duration
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.DurationVar(&c.MaxAge, "eventtime.max.age", 24*time.Hour, "maximum age `duration` of events with a client timestamp, older events are rejected")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	if c.MaxAge <= 0 {
+		return errors.New("event time maximum age must be greater than 0")
+	}
+	return nil
+}
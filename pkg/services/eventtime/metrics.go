@@ -0,0 +1,29 @@
+package eventtime
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	clockSkew prometheus.Histogram
+	rejected  *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		clockSkew: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "eventtime_clock_skew_seconds",
+			Help:    "Absolute skew between client and server clocks of events with a client timestamp",
+			Buckets: []float64{0.1, 1, 10, 60, 600, 3600, 86400},
+		}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "eventtime_rejected_total",
+			Help: "Number of events rejected because of their client timestamp",
+		}, []string{"reason"}),
+	}
+
+	promRegistry.MustRegister(
+		m.clockSkew,
+		m.rejected,
+	)
+
+	return m
+}
@@ -0,0 +1,108 @@
+package eventtime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// Client timestamps headers. Both contain a unix timestamp in milliseconds of
+// client clock.
+const (
+	// Date at which event occurred.
+	TimestampHeader = "X-Prisme-Timestamp"
+	// Date at which request was sent.
+	ClientTimeHeader = "X-Prisme-Client-Time"
+)
+
+var (
+	ErrMissingClientTime = errors.New("missing " + ClientTimeHeader + " header")
+	ErrFutureTimestamp   = errors.New("event timestamp is after client time")
+	ErrTooOld            = errors.New("event timestamp is too old")
+)
+
+// Service define a service correcting client supplied event timestamps for
+// client clock skew.
+//
+// Clients send date at which event occurred along their current time. Age of
+// event is computed using client clock only and subtracted from server time,
+// so client clock skew doesn't affect corrected timestamp.
+type Service interface {
+	// Timestamp returns corrected event timestamp of request. False is
+	// returned if request has no client timestamp. An error is returned if
+	// timestamp headers are invalid or if event is outside accepted window.
+	Timestamp(*fasthttp.RequestHeader) (time.Time, bool, error)
+}
+
+type service struct {
+	logger  log.Logger
+	metrics metrics
+	maxAge  time.Duration
+	now     func() time.Time
+}
+
+// NewService returns a new event time Service.
+func NewService(cfg Config, logger log.Logger, promRegistry *prometheus.Registry) Service {
+	logger = logger.With("service", "eventtime")
+	logger.Info("event time configured", "max_age", cfg.MaxAge)
+
+	return &service{
+		logger:  logger,
+		metrics: newMetrics(promRegistry),
+		maxAge:  cfg.MaxAge,
+		now:     time.Now,
+	}
+}
+
+// Timestamp implements Service.
+func (s *service) Timestamp(headers *fasthttp.RequestHeader) (time.Time, bool, error) {
+	rawTimestamp := headers.Peek(TimestampHeader)
+	if len(rawTimestamp) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	timestamp, err := parseUnixMilli(TimestampHeader, rawTimestamp)
+	if err != nil {
+		return s.reject("invalid", err)
+	}
+
+	rawClientTime := headers.Peek(ClientTimeHeader)
+	if len(rawClientTime) == 0 {
+		return s.reject("invalid", ErrMissingClientTime)
+	}
+	clientTime, err := parseUnixMilli(ClientTimeHeader, rawClientTime)
+	if err != nil {
+		return s.reject("invalid", err)
+	}
+
+	age := clientTime.Sub(timestamp)
+	if age < 0 {
+		return s.reject("future", ErrFutureTimestamp)
+	}
+	if age > s.maxAge {
+		return s.reject("too_old", ErrTooOld)
+	}
+
+	now := s.now()
+	s.metrics.clockSkew.Observe(now.Sub(clientTime).Abs().Seconds())
+
+	return now.Add(-age).UTC(), true, nil
+}
+
+func (s *service) reject(reason string, err error) (time.Time, bool, error) {
+	s.metrics.rejected.With(prometheus.Labels{"reason": reason}).Inc()
+	return time.Time{}, true, err
+}
+
+func parseUnixMilli(header string, value []byte) (time.Time, error) {
+	ms, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil || ms < 0 {
+		return time.Time{}, fmt.Errorf("invalid %v header: expected unix timestamp in milliseconds", header)
+	}
+	return time.UnixMilli(ms), nil
+}
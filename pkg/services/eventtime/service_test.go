@@ -0,0 +1,86 @@
+package eventtime
+
+import (
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("eventtime_service_test", io.Discard, false)
+
+	now := time.Date(2025, 11, 2, 12, 0, 0, 0, time.UTC)
+	newService := func(t *testing.T, cfg Config) *service {
+		require.NoError(t, cfg.Validate())
+		srv := NewService(cfg, logger, prometheus.NewRegistry())
+		srv.(*service).now = func() time.Time { return now }
+		return srv.(*service)
+	}
+
+	headers := func(timestamp, clientTime *time.Time) *fasthttp.RequestHeader {
+		h := &fasthttp.RequestHeader{}
+		if timestamp != nil {
+			h.Set(TimestampHeader, strconv.FormatInt(timestamp.UnixMilli(), 10))
+		}
+		if clientTime != nil {
+			h.Set(ClientTimeHeader, strconv.FormatInt(clientTime.UnixMilli(), 10))
+		}
+		return h
+	}
+
+	t.Run("NoClientTimestamp", func(t *testing.T) {
+		srv := newService(t, Config{MaxAge: time.Hour})
+
+		_, ok, err := srv.Timestamp(headers(nil, nil))
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("SkewCorrected", func(t *testing.T) {
+		srv := newService(t, Config{MaxAge: time.Hour})
+
+		// Client clock is 10 minutes ahead and event occurred 5 minutes ago.
+		clientTime := now.Add(10 * time.Minute)
+		timestamp := clientTime.Add(-5 * time.Minute)
+
+		ts, ok, err := srv.Timestamp(headers(&timestamp, &clientTime))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, now.Add(-5*time.Minute), ts)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		srv := newService(t, Config{MaxAge: time.Hour})
+
+		clientTime := now.Add(-3 * time.Minute)
+		old := clientTime.Add(-2 * time.Hour)
+		future := clientTime.Add(time.Second)
+
+		_, ok, err := srv.Timestamp(headers(&old, &clientTime))
+		require.True(t, ok)
+		require.ErrorIs(t, err, ErrTooOld)
+
+		_, _, err = srv.Timestamp(headers(&future, &clientTime))
+		require.ErrorIs(t, err, ErrFutureTimestamp)
+
+		_, _, err = srv.Timestamp(headers(&old, nil))
+		require.ErrorIs(t, err, ErrMissingClientTime)
+
+		h := headers(nil, &clientTime)
+		h.Set(TimestampHeader, "yesterday")
+		_, _, err = srv.Timestamp(h)
+		require.Error(t, err)
+
+		rejected := srv.metrics.rejected
+		require.Equal(t, 1.0, testutil.ToFloat64(rejected.WithLabelValues("too_old")))
+		require.Equal(t, 1.0, testutil.ToFloat64(rejected.WithLabelValues("future")))
+		require.Equal(t, 2.0, testutil.ToFloat64(rejected.WithLabelValues("invalid")))
+	})
+}
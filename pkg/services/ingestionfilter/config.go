@@ -0,0 +1,30 @@
+package ingestionfilter
+
+import (
+	"github.com/negrel/configue"
+	"github.com/prismelabs/analytics/pkg/options"
+)
+
+// Config holds service configuration.
+type Config struct {
+	ReferrerSpam bool
+	Referrers    []string
+	IPs          []string
+	UserAgents   []string
+	Paths        []string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.BoolVar(&c.ReferrerSpam, "ingestionfilter.referrer.spam", true, "block events with a referrer listed in embedded referrer spam blocklist")
+	f.StringSliceVar(&c.Referrers, "ingestionfilter.block.referrers", nil, "comma separated `list` of blocked referrer domains, subdomains are blocked too")
+	f.StringSliceVar(&c.IPs, "ingestionfilter.block.ips", nil, "comma separated `list` of blocked IP addresses and CIDR (e.g. 10.0.0.0/8)")
+	f.Var(&options.Lines{Lines: &c.UserAgents}, "ingestionfilter.block.user.agents", "newline separated `list` of blocked user agents regular expressions, option can be repeated")
+	f.StringSliceVar(&c.Paths, "ingestionfilter.block.paths", nil, "comma separated `list` of blocked pages paths, a trailing * matches any suffix (e.g. /admin/*)")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	_, err := parseRules(*c)
+	return err
+}
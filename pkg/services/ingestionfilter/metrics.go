@@ -0,0 +1,22 @@
+package ingestionfilter
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	blocked *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		blocked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ingestionfilter_blocked_total",
+			Help: "Number of events blocked by ingestion filter per rule",
+		}, []string{"rule"}),
+	}
+
+	promRegistry.MustRegister(
+		m.blocked,
+	)
+
+	return m
+}
@@ -0,0 +1,144 @@
+package ingestionfilter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// Kinds of rules.
+const (
+	referrerSpamRule = "referrer_spam"
+	referrerRule     = "referrer"
+	ipRule           = "ip"
+	userAgentRule    = "user_agent"
+	pathRule         = "path"
+)
+
+type userAgentPattern struct {
+	source string
+	regex  *regexp.Regexp
+}
+
+// rules holds parsed blocking rules.
+type rules struct {
+	spam       map[string]struct{}
+	referrers  map[string]struct{}
+	prefixes   []netip.Prefix
+	userAgents []userAgentPattern
+	paths      []string
+}
+
+// parseRules parses user defined rules of given config. Referrer spam
+// blocklist isn't loaded.
+func parseRules(cfg Config) (rules, error) {
+	r := rules{referrers: make(map[string]struct{})}
+
+	for _, domain := range cfg.Referrers {
+		if domain = normalizeDomain(domain); domain != "" {
+			r.referrers[domain] = struct{}{}
+		}
+	}
+
+	for _, ip := range cfg.IPs {
+		ip = strings.TrimSpace(ip)
+		if ip == "" {
+			continue
+		}
+
+		var prefix netip.Prefix
+		if strings.Contains(ip, "/") {
+			var err error
+			prefix, err = netip.ParsePrefix(ip)
+			if err != nil {
+				return rules{}, fmt.Errorf("invalid blocked CIDR %q: %w", ip, err)
+			}
+			prefix = prefix.Masked()
+		} else {
+			addr, err := netip.ParseAddr(ip)
+			if err != nil {
+				return rules{}, fmt.Errorf("invalid blocked IP address %q: %w", ip, err)
+			}
+			addr = addr.Unmap()
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		r.prefixes = append(r.prefixes, prefix)
+	}
+
+	for _, ua := range cfg.UserAgents {
+		if ua = strings.TrimSpace(ua); ua == "" {
+			continue
+		}
+
+		regex, err := regexp.Compile(ua)
+		if err != nil {
+			return rules{}, fmt.Errorf("invalid blocked user agent regex %q: %w", ua, err)
+		}
+		r.userAgents = append(r.userAgents, userAgentPattern{ua, regex})
+	}
+
+	for _, path := range cfg.Paths {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			return rules{}, fmt.Errorf("invalid blocked path %q: path must start with /", path)
+		}
+		r.paths = append(r.paths, path)
+	}
+
+	return r, nil
+}
+
+// parseSpamList parses a referrer spam blocklist containing one domain per
+// line.
+func parseSpamList(data []byte) map[string]struct{} {
+	spam := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		domain := normalizeDomain(scanner.Text())
+		if domain != "" && !strings.HasPrefix(domain, "#") {
+			spam[domain] = struct{}{}
+		}
+	}
+
+	return spam
+}
+
+// matchDomain returns given domain or its parent domain that is contained in
+// domains set.
+func matchDomain(domains map[string]struct{}, domain string) (string, bool) {
+	for domain != "" {
+		if _, ok := domains[domain]; ok {
+			return domain, true
+		}
+
+		// Try parent domain, top level domains are never looked up.
+		_, parent, found := strings.Cut(domain, ".")
+		if !found || !strings.Contains(parent, ".") {
+			break
+		}
+		domain = parent
+	}
+
+	return "", false
+}
+
+// matchPath reports whether path matches pattern. A trailing * in pattern
+// matches any suffix.
+func matchPath(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+
+	return pattern == path
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	return strings.TrimPrefix(domain, "www.")
+}
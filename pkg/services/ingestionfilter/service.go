@@ -0,0 +1,113 @@
+package ingestionfilter
+
+import (
+	"net/netip"
+
+	"github.com/prismelabs/analytics/pkg/embedded"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Request holds data of an ingestion request checked against blocking rules.
+// Empty fields are ignored.
+type Request struct {
+	IpAddr         string
+	UserAgent      string
+	PagePath       string
+	ReferrerDomain string
+}
+
+// Service define an ingestion filter service blocking events from referrer
+// spammers and user defined sources before they're processed.
+type Service interface {
+	// Filter returns rule blocking given request, if any. Blocked requests are
+	// counted per rule.
+	Filter(Request) (rule string, blocked bool)
+}
+
+type service struct {
+	logger  log.Logger
+	metrics metrics
+	rules   rules
+}
+
+// NewService returns a new ingestion filter Service.
+func NewService(
+	cfg Config,
+	logger log.Logger,
+	promRegistry *prometheus.Registry,
+) (Service, error) {
+	logger = logger.With("service", "ingestionfilter")
+
+	r, err := parseRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ReferrerSpam {
+		r.spam = parseSpamList(embedded.ReferrerSpammers)
+	}
+
+	logger.Info("ingestion filter configured",
+		"referrer_spam_domains", len(r.spam),
+		"blocked_referrers", len(r.referrers),
+		"blocked_ips", len(r.prefixes),
+		"blocked_user_agents", len(r.userAgents),
+		"blocked_paths", len(r.paths),
+	)
+
+	return &service{logger, newMetrics(promRegistry), r}, nil
+}
+
+// Filter implements Service.
+func (s *service) Filter(req Request) (string, bool) {
+	rule, blocked := s.match(req)
+	if blocked {
+		s.metrics.blocked.With(prometheus.Labels{"rule": rule}).Inc()
+		s.logger.Debug("ingestion request blocked", "rule", rule, "request", req)
+	}
+
+	return rule, blocked
+}
+
+func (s *service) match(req Request) (string, bool) {
+	if req.ReferrerDomain != "" {
+		domain := normalizeDomain(req.ReferrerDomain)
+		if _, ok := matchDomain(s.rules.spam, domain); ok {
+			return referrerSpamRule, true
+		}
+		if match, ok := matchDomain(s.rules.referrers, domain); ok {
+			return referrerRule + ":" + match, true
+		}
+	}
+
+	if req.IpAddr != "" && len(s.rules.prefixes) > 0 {
+		addr, err := netip.ParseAddr(req.IpAddr)
+		if err == nil {
+			addr = addr.Unmap()
+			for _, prefix := range s.rules.prefixes {
+				if prefix.Contains(addr) {
+					return ipRule + ":" + prefix.String(), true
+				}
+			}
+		}
+	}
+
+	if req.UserAgent != "" {
+		for _, ua := range s.rules.userAgents {
+			if ua.regex.MatchString(req.UserAgent) {
+				return userAgentRule + ":" + ua.source, true
+			}
+		}
+	}
+
+	if req.PagePath != "" {
+		for _, path := range s.rules.paths {
+			if matchPath(path, req.PagePath) {
+				return pathRule + ":" + path, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,160 @@
+package ingestionfilter
+
+import (
+	"io"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/options"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("ingestionfilter_service_test", io.Discard, false)
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		for _, cfg := range []Config{
+			{IPs: []string{"10.0.0.0/33"}},
+			{IPs: []string{"not an ip"}},
+			{UserAgents: []string{"("}},
+			{Paths: []string{"admin"}},
+		} {
+			require.Error(t, cfg.Validate())
+			_, err := NewService(cfg, logger, prometheus.NewRegistry())
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("UserAgentsOption", func(t *testing.T) {
+		var cfg Config
+		opt := options.Lines{Lines: &cfg.UserAgents}
+		require.NoError(t, opt.Set("BadBot/[0-9]{1,3}\n\n(?i)curl/"))
+		require.NoError(t, opt.Set("scrapy"))
+
+		require.Equal(t, []string{"BadBot/[0-9]{1,3}", "(?i)curl/", "scrapy"}, cfg.UserAgents)
+		require.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		srv, err := NewService(Config{
+			ReferrerSpam: true,
+			Referrers:    []string{"www.example.com"},
+			IPs:          []string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"},
+			UserAgents:   []string{"(?i)curl/"},
+			Paths:        []string{"/admin/*", "/preview"},
+		}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		type testCase struct {
+			name    string
+			request Request
+			rule    string
+		}
+
+		testCases := []testCase{
+			{
+				name:    "Empty",
+				request: Request{},
+			},
+			{
+				name: "NotBlocked",
+				request: Request{
+					IpAddr:         "8.8.8.8",
+					UserAgent:      "Mozilla/5.0",
+					PagePath:       "/administrator",
+					ReferrerDomain: "www.google.com",
+				},
+			},
+			{
+				name:    "ReferrerSpam",
+				request: Request{ReferrerDomain: "semalt.com"},
+				rule:    "referrer_spam",
+			},
+			{
+				name:    "ReferrerSpamSubdomain",
+				request: Request{ReferrerDomain: "www.seo.semalt.com"},
+				rule:    "referrer_spam",
+			},
+			{
+				name:    "Referrer",
+				request: Request{ReferrerDomain: "blog.example.com"},
+				rule:    "referrer:example.com",
+			},
+			{
+				name:    "CIDR",
+				request: Request{IpAddr: "10.1.2.3"},
+				rule:    "ip:10.0.0.0/8",
+			},
+			{
+				name:    "IP",
+				request: Request{IpAddr: "192.168.1.1"},
+				rule:    "ip:192.168.1.1/32",
+			},
+			{
+				name:    "IPv4MappedIPv6",
+				request: Request{IpAddr: "::ffff:10.0.0.1"},
+				rule:    "ip:10.0.0.0/8",
+			},
+			{
+				name:    "IPv6",
+				request: Request{IpAddr: "2001:db8::1"},
+				rule:    "ip:2001:db8::/32",
+			},
+			{
+				name:    "UserAgent",
+				request: Request{UserAgent: "Curl/8.0.1"},
+				rule:    "user_agent:(?i)curl/",
+			},
+			{
+				name:    "PathPrefix",
+				request: Request{PagePath: "/admin/users"},
+				rule:    "path:/admin/*",
+			},
+			{
+				name:    "Path",
+				request: Request{PagePath: "/preview"},
+				rule:    "path:/preview",
+			},
+			{
+				name:    "PathIsExactWithoutWildcard",
+				request: Request{PagePath: "/preview/foo"},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				rule, blocked := srv.Filter(tc.request)
+				require.Equal(t, tc.rule, rule)
+				require.Equal(t, tc.rule != "", blocked)
+			})
+		}
+	})
+
+	t.Run("ReferrerSpamDisabled", func(t *testing.T) {
+		srv, err := NewService(Config{}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		_, blocked := srv.Filter(Request{ReferrerDomain: "semalt.com"})
+		require.False(t, blocked)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		srv, err := NewService(Config{
+			ReferrerSpam: true,
+			Paths:        []string{"/admin/*"},
+		}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		srv.Filter(Request{ReferrerDomain: "semalt.com"})
+		srv.Filter(Request{ReferrerDomain: "darodar.com"})
+		srv.Filter(Request{PagePath: "/admin/"})
+		srv.Filter(Request{PagePath: "/"})
+
+		blocked := srv.(*service).metrics.blocked
+		require.Equal(t, float64(2), testutil.ToFloat64(blocked.WithLabelValues("referrer_spam")))
+		require.Equal(t, float64(1), testutil.ToFloat64(blocked.WithLabelValues("path:/admin/*")))
+		require.Equal(t, 2, testutil.CollectAndCount(blocked))
+	})
+}
@@ -7,6 +7,10 @@ type CountryCode struct {
 	value string
 }
 
+// UnknownCountryCode is the country code of IP addresses with no known
+// country.
+var UnknownCountryCode = CountryCode{"XX"}
+
 // String implements fmt.Stringer.
 func (cc CountryCode) String() string {
 	return cc.value
@@ -0,0 +1,20 @@
+package pathrules
+
+import (
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	File string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringVar(&c.File, "pathrules.file", "", "`filepath` to JSON path rewrite rules, paths aren't normalized if empty")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	return nil
+}
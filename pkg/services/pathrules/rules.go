@@ -0,0 +1,159 @@
+package pathrules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// Placeholders of collapsed path segments.
+const (
+	UuidPlaceholder   = ":uuid"
+	NumberPlaceholder = ":id"
+)
+
+var (
+	uuidRegex   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	numberRegex = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Rules defines path rewrite rules of multiple domains.
+type Rules struct {
+	// Default rules apply to domains without rules.
+	Default *DomainRules           `json:"default"`
+	Domains map[string]DomainRules `json:"domains"`
+}
+
+// DomainRules defines path rewrite rules of a domain. Rules are applied in
+// fields order.
+type DomainRules struct {
+	// Promote hash route (e.g. #/users/42 or #!/users/42) of page URIs into
+	// their path. Applied by Service.Normalize only as paths alone have no
+	// fragment.
+	HashRouting bool `json:"hash_routing"`
+	// Convert path to lower case.
+	Lowercase bool `json:"lowercase"`
+	// Remove trailing slash of paths other than "/".
+	StripTrailingSlash bool `json:"strip_trailing_slash"`
+	// Replace UUID segments with UuidPlaceholder.
+	CollapseUuids bool `json:"collapse_uuids"`
+	// Replace number segments with NumberPlaceholder.
+	CollapseNumbers bool `json:"collapse_numbers"`
+	// First rewrite matching path is applied.
+	Rewrites []Rewrite `json:"rewrites"`
+}
+
+// Rewrite defines a regular expression matching whole path and a template
+// of the new path. Template may reference capture groups of pattern
+// (e.g. $1 or ${name}).
+type Rewrite struct {
+	Pattern  string `json:"pattern"`
+	Template string `json:"template"`
+	regexp   *regexp.Regexp
+}
+
+// Compile validates and compiles rules of all domains.
+func (r *Rules) Compile() error {
+	if r.Default != nil {
+		err := r.Default.Compile()
+		if err != nil {
+			return fmt.Errorf("invalid default path rules: %w", err)
+		}
+	}
+	for domain, rules := range r.Domains {
+		err := rules.Compile()
+		if err != nil {
+			return fmt.Errorf("invalid path rules of domain %q: %w", domain, err)
+		}
+		r.Domains[domain] = rules
+	}
+
+	return nil
+}
+
+// Compile validates and compiles rules.
+func (dr *DomainRules) Compile() error {
+	for i, rewrite := range dr.Rewrites {
+		re, err := regexp.Compile("^(?:" + rewrite.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid rewrite pattern %q: %w", rewrite.Pattern, err)
+		}
+		dr.Rewrites[i].regexp = re
+	}
+
+	return nil
+}
+
+// Apply applies rules to given path and returns the result. Rules must be
+// compiled.
+func (dr *DomainRules) Apply(path string) string {
+	if dr.Lowercase {
+		path = strings.ToLower(path)
+	}
+
+	if dr.StripTrailingSlash {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+
+	if dr.CollapseUuids || dr.CollapseNumbers {
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if dr.CollapseUuids && uuidRegex.MatchString(segment) {
+				segments[i] = UuidPlaceholder
+			} else if dr.CollapseNumbers && numberRegex.MatchString(segment) {
+				segments[i] = NumberPlaceholder
+			}
+		}
+		path = strings.Join(segments, "/")
+	}
+
+	for _, rewrite := range dr.Rewrites {
+		match := rewrite.regexp.FindStringSubmatchIndex(path)
+		if match == nil {
+			continue
+		}
+
+		path = string(rewrite.regexp.ExpandString(nil, rewrite.Template, path, match))
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		break
+	}
+
+	return path
+}
+
+// PromoteHashRoute returns given URI with its hash route appended to its path
+// and query string of route merged with URI one: https://example.com/app/#/users/42?tab=1
+// becomes https://example.com/app/users/42?tab=1. URI is returned unchanged
+// if its fragment isn't a route, that is it doesn't start with "/" or "!/".
+func PromoteHashRoute(u uri.Uri) uri.Uri {
+	route := strings.TrimPrefix(u.Hash(), "!")
+	if !strings.HasPrefix(route, "/") {
+		return u
+	}
+
+	routePath, routeQuery, _ := strings.Cut(route, "?")
+
+	str := u.Scheme() + "://" + u.Host() + strings.TrimRight(u.Path(), "/") + routePath
+	query := u.QueryString()
+	if query != "" && routeQuery != "" {
+		query += "&"
+	}
+	query += routeQuery
+	if query != "" {
+		str += "?" + query
+	}
+
+	result, err := uri.Parse(str)
+	if err != nil {
+		return u
+	}
+
+	return result
+}
@@ -0,0 +1,113 @@
+package pathrules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/uri"
+)
+
+// Service define a path normalization service. Events handlers normalize
+// page URIs before they reach session store and event store so sessions and
+// reports only contain normalized paths.
+type Service interface {
+	// NormalizePath returns path normalized using rules of given domain.
+	NormalizePath(domain, path string) string
+	// Normalize returns given URI with a normalized path. Hash route of URI is
+	// promoted into its path first if domain has hash routing enabled.
+	Normalize(uri.Uri) uri.Uri
+}
+
+type service struct {
+	rules Rules
+}
+
+// NewService returns a new path normalization Service. Rules are loaded from
+// configured file, if any.
+func NewService(cfg Config, logger log.Logger) (Service, error) {
+	logger = logger.With("service", "pathrules")
+
+	rules, err := LoadRules(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.File != "" {
+		logger.Info("path rules loaded", "file", cfg.File, "domains", len(rules.Domains))
+	}
+
+	return NewServiceFromRules(rules), nil
+}
+
+// NewServiceFromRules returns a new path normalization Service using the
+// given compiled rules.
+func NewServiceFromRules(rules Rules) Service {
+	return &service{rules: rules}
+}
+
+// LoadRules reads and compiles rules stored in given JSON file. An empty
+// rules set is returned if file path is empty.
+func LoadRules(file string) (Rules, error) {
+	if file == "" {
+		return Rules{}, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Rules{}, fmt.Errorf("failed to read path rules: %w", err)
+	}
+
+	var rules Rules
+	err = json.Unmarshal(data, &rules)
+	if err != nil {
+		return Rules{}, fmt.Errorf("failed to parse path rules: %w", err)
+	}
+
+	err = rules.Compile()
+	if err != nil {
+		return Rules{}, err
+	}
+
+	return rules, nil
+}
+
+// NormalizePath implements Service.
+func (s *service) NormalizePath(domain, path string) string {
+	rules := s.domainRules(domain)
+	if rules == nil {
+		return path
+	}
+
+	return rules.Apply(path)
+}
+
+// Normalize implements Service.
+func (s *service) Normalize(u uri.Uri) uri.Uri {
+	rules := s.domainRules(u.Host())
+	if rules == nil {
+		return u
+	}
+
+	if rules.HashRouting {
+		u = PromoteHashRoute(u)
+	}
+
+	path := rules.Apply(u.Path())
+	if path == u.Path() {
+		return u
+	}
+
+	return u.WithPath(path)
+}
+
+// domainRules returns rules of given domain, default rules if domain has none
+// or nil if there is no default rules.
+func (s *service) domainRules(domain string) *DomainRules {
+	rules, ok := s.rules.Domains[domain]
+	if ok {
+		return &rules
+	}
+
+	return s.rules.Default
+}
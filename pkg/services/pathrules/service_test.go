@@ -0,0 +1,106 @@
+package pathrules
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/testutils"
+	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("pathrules_service_test", io.Discard, false)
+
+	t.Run("NoFile", func(t *testing.T) {
+		srv, err := NewService(Config{}, logger)
+		require.NoError(t, err)
+		require.Equal(t, "/Foo/42/", srv.NormalizePath("example.com", "/Foo/42/"))
+	})
+
+	t.Run("InvalidPattern", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "rules.json")
+		err := os.WriteFile(file, []byte(`{"domains": {"example.com": {"rewrites": [{"pattern": "(", "template": "/"}]}}}`), 0o600)
+		require.NoError(t, err)
+
+		_, err = NewService(Config{File: file}, logger)
+		require.Error(t, err)
+	})
+
+	t.Run("NormalizePath", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "rules.json")
+		err := os.WriteFile(file, []byte(`{
+			"default": { "strip_trailing_slash": true },
+			"domains": {
+				"example.com": {
+					"lowercase": true,
+					"strip_trailing_slash": true,
+					"collapse_uuids": true,
+					"collapse_numbers": true,
+					"rewrites": [
+						{ "pattern": "/users/[^/]+/settings", "template": "/users/:name/settings" },
+						{ "pattern": "/blog/(?P<slug>[^/]+)/.*", "template": "/blog/${slug}" }
+					]
+				}
+			}
+		}`), 0o600)
+		require.NoError(t, err)
+
+		srv, err := NewService(Config{File: file}, logger)
+		require.NoError(t, err)
+
+		type testCase struct {
+			domain, path, expected string
+		}
+		for _, tcase := range []testCase{
+			{"example.com", "/", "/"},
+			{"example.com", "//", "/"},
+			{"example.com", "/Foo/", "/foo"},
+			{"example.com", "/orders/8F3A6C1E-1C2D-4E5F-9A0B-1234567890AB/items/12", "/orders/:uuid/items/:id"},
+			{"example.com", "/orders/12a", "/orders/12a"},
+			{"example.com", "/users/john/settings", "/users/:name/settings"},
+			{"example.com", "/users/john/settings/profile", "/users/john/settings/profile"},
+			{"example.com", "/blog/hello-world/comments/3", "/blog/hello-world"},
+			{"example.org", "/Foo/42/", "/Foo/42"},
+		} {
+			require.Equal(t, tcase.expected, srv.NormalizePath(tcase.domain, tcase.path), tcase.path)
+		}
+
+		u := testutils.Must(uri.Parse)("https://example.com/Orders/42?q=foo")
+		normalized := srv.Normalize(u)
+		require.Equal(t, "https://example.com/orders/:id?q=foo", normalized.String())
+	})
+
+	t.Run("HashRouting", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "rules.json")
+		err := os.WriteFile(file, []byte(`{
+			"domains": {
+				"example.com": { "hash_routing": true, "collapse_numbers": true }
+			}
+		}`), 0o600)
+		require.NoError(t, err)
+
+		srv, err := NewService(Config{File: file}, logger)
+		require.NoError(t, err)
+
+		type testCase struct {
+			uri, expected string
+		}
+		for _, tcase := range []testCase{
+			{"https://example.com/#/users/42", "https://example.com/users/:id"},
+			{"https://example.com/#!/users", "https://example.com/users"},
+			{"https://example.com/app/#/users?tab=1", "https://example.com/app/users?tab=1"},
+			{"https://example.com/?utm_source=foo#/search?q=bar", "https://example.com/search?utm_source=foo&q=bar"},
+			{"https://example.com/#section", "https://example.com/#section"},
+			{"https://example.com/", "https://example.com/"},
+			{"https://example.org/#/users/42", "https://example.org/#/users/42"},
+		} {
+			u := testutils.Must(uri.Parse)(tcase.uri)
+			normalized := srv.Normalize(u)
+			require.Equal(t, tcase.expected, normalized.String(), tcase.uri)
+		}
+	})
+}
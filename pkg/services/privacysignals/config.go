@@ -0,0 +1,21 @@
+package privacysignals
+
+import (
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	Policies []string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringSliceVar(&c.Policies, "privacysignals.policies", nil, "comma separated `list` of [DOMAIN=]POLICY applied to events with Sec-GPC or DNT signal: ignore, drop or anonymize, policy without domain apply to all domains (e.g. anonymize,www.example.com=drop)")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	_, _, err := parsePolicies(c.Policies)
+	return err
+}
@@ -0,0 +1,22 @@
+package privacysignals
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	applied *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		applied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "privacysignals_applied_total",
+			Help: "Number of events with a privacy signal dropped or anonymized",
+		}, []string{"policy"}),
+	}
+
+	promRegistry.MustRegister(
+		m.applied,
+	)
+
+	return m
+}
@@ -0,0 +1,121 @@
+package privacysignals
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// Policy defines how events carrying a privacy signal are handled.
+type Policy string
+
+// Supported policies.
+const (
+	// Privacy signals are ignored and events are processed as usual.
+	IgnorePolicy Policy = "ignore"
+	// Events are dropped.
+	DropPolicy Policy = "drop"
+	// Pageviews are recorded as single pageview sessions with a random visitor
+	// ID and no client details nor country. Other events are dropped.
+	AnonymizePolicy Policy = "anonymize"
+)
+
+// Service define a privacy signals service handling Global Privacy Control
+// (Sec-GPC) and Do Not Track (DNT) signals.
+type Service interface {
+	// Policy returns policy to apply to request of given domain. IgnorePolicy
+	// is returned if request carries no privacy signal.
+	Policy(domain string, headers *fasthttp.RequestHeader) Policy
+	// ReportApplied reports that a policy was applied to an event. Events are
+	// counted per policy only as domain comes from client.
+	ReportApplied(policy Policy)
+}
+
+type service struct {
+	metrics        metrics
+	policy         Policy
+	domainPolicies map[string]Policy
+}
+
+// NewService returns a new privacy signals Service.
+func NewService(cfg Config, logger log.Logger, promRegistry *prometheus.Registry) (Service, error) {
+	logger = logger.With("service", "privacysignals")
+
+	policy, domainPolicies, err := parsePolicies(cfg.Policies)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("privacy signals policies loaded", "policy", policy, "domains", len(domainPolicies))
+
+	return &service{
+		metrics:        newMetrics(promRegistry),
+		policy:         policy,
+		domainPolicies: domainPolicies,
+	}, nil
+}
+
+// Policy implements Service.
+func (s *service) Policy(domain string, headers *fasthttp.RequestHeader) Policy {
+	policy := s.policy
+	if domainPolicy, ok := s.domainPolicies[domain]; ok {
+		policy = domainPolicy
+	}
+	if policy == IgnorePolicy || !HasSignal(headers) {
+		return IgnorePolicy
+	}
+
+	return policy
+}
+
+// ReportApplied implements Service.
+func (s *service) ReportApplied(policy Policy) {
+	s.metrics.applied.With(prometheus.Labels{"policy": string(policy)}).Inc()
+}
+
+// HasSignal reports whether given request headers contains a Global Privacy
+// Control or Do Not Track signal.
+func HasSignal(headers *fasthttp.RequestHeader) bool {
+	return string(headers.Peek("Sec-GPC")) == "1" || string(headers.Peek("DNT")) == "1"
+}
+
+// parsePolicies parses list of [DOMAIN=]POLICY. Domain specific policies
+// replace policy without domain.
+func parsePolicies(policies []string) (Policy, map[string]Policy, error) {
+	policy := IgnorePolicy
+	domainPolicies := make(map[string]Policy)
+
+	for _, p := range policies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		domain, rawPolicy, hasDomain := strings.Cut(p, "=")
+		if !hasDomain {
+			rawPolicy = domain
+		}
+
+		switch pol := Policy(strings.TrimSpace(rawPolicy)); pol {
+		case IgnorePolicy, DropPolicy, AnonymizePolicy:
+			if !hasDomain {
+				policy = pol
+				continue
+			}
+
+			domain = strings.TrimSpace(domain)
+			if domain == "" {
+				return "", nil, fmt.Errorf("invalid privacy signals policy %q: empty domain", p)
+			}
+			domainPolicies[domain] = pol
+
+		default:
+			return "", nil, fmt.Errorf("invalid privacy signals policy %q: policy must be ignore, drop or anonymize", p)
+		}
+	}
+
+	return policy, domainPolicies, nil
+}
@@ -0,0 +1,115 @@
+package privacysignals
+
+import (
+	"io"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("privacysignals_service_test", io.Discard, false)
+
+	headers := func(kv ...string) *fasthttp.RequestHeader {
+		var h fasthttp.RequestHeader
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return &h
+	}
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		for _, policies := range [][]string{
+			{"foo"},
+			{"example.com=foo"},
+			{"=drop"},
+		} {
+			cfg := Config{Policies: policies}
+			require.Error(t, cfg.Validate())
+			_, err := NewService(cfg, logger, prometheus.NewRegistry())
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("Policy", func(t *testing.T) {
+		srv, err := NewService(Config{
+			Policies: []string{"anonymize", "drop.example.com=drop", "ignore.example.com=ignore"},
+		}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		type testCase struct {
+			name     string
+			domain   string
+			headers  *fasthttp.RequestHeader
+			expected Policy
+		}
+
+		testCases := []testCase{
+			{
+				name:     "NoSignal",
+				domain:   "example.com",
+				headers:  headers(),
+				expected: IgnorePolicy,
+			},
+			{
+				name:     "GPC",
+				domain:   "example.com",
+				headers:  headers("Sec-GPC", "1"),
+				expected: AnonymizePolicy,
+			},
+			{
+				name:     "DNT",
+				domain:   "example.com",
+				headers:  headers("DNT", "1"),
+				expected: AnonymizePolicy,
+			},
+			{
+				name:     "DNTDisabled",
+				domain:   "example.com",
+				headers:  headers("DNT", "0"),
+				expected: IgnorePolicy,
+			},
+			{
+				name:     "DomainPolicy",
+				domain:   "drop.example.com",
+				headers:  headers("Sec-GPC", "1"),
+				expected: DropPolicy,
+			},
+			{
+				name:     "DomainIgnorePolicy",
+				domain:   "ignore.example.com",
+				headers:  headers("Sec-GPC", "1", "DNT", "1"),
+				expected: IgnorePolicy,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				require.Equal(t, tc.expected, srv.Policy(tc.domain, tc.headers))
+			})
+		}
+	})
+
+	t.Run("DefaultIgnore", func(t *testing.T) {
+		srv, err := NewService(Config{}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+		require.Equal(t, IgnorePolicy, srv.Policy("example.com", headers("Sec-GPC", "1")))
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		srv, err := NewService(Config{}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		srv.ReportApplied(DropPolicy)
+		srv.ReportApplied(DropPolicy)
+		srv.ReportApplied(AnonymizePolicy)
+
+		applied := srv.(*service).metrics.applied
+		require.Equal(t, float64(2), testutil.ToFloat64(applied.WithLabelValues("drop")))
+		require.Equal(t, float64(1), testutil.ToFloat64(applied.WithLabelValues("anonymize")))
+	})
+}
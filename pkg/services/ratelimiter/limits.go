@@ -0,0 +1,77 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// limitKey identifies a limit, empty fields match any domain or endpoint.
+type limitKey struct {
+	domain   string
+	endpoint string
+}
+
+// parseLimits parses a list of [DOMAIN=][ENDPOINT:]LIMIT.
+func parseLimits(limits []string) (map[limitKey]uint64, error) {
+	result := make(map[limitKey]uint64)
+
+	for _, l := range limits {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+
+		var key limitKey
+		domain, value, hasDomain := strings.Cut(l, "=")
+		if hasDomain {
+			key.domain = strings.TrimSpace(domain)
+			if key.domain == "" {
+				return nil, fmt.Errorf("invalid rate limit %q: empty domain", l)
+			}
+		} else {
+			value = domain
+		}
+
+		endpoint, value, hasEndpoint := strings.Cut(value, ":")
+		if hasEndpoint {
+			key.endpoint = strings.TrimSpace(endpoint)
+			if key.endpoint == "" {
+				return nil, fmt.Errorf("invalid rate limit %q: empty endpoint", l)
+			}
+			if !slices.Contains(Endpoints, key.endpoint) {
+				return nil, fmt.Errorf("invalid rate limit %q: unknown endpoint, expected one of %v", l, strings.Join(Endpoints, ", "))
+			}
+		} else {
+			value = endpoint
+		}
+
+		max, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit %q: %w", l, err)
+		}
+
+		result[key] = max
+	}
+
+	return result, nil
+}
+
+// lookup returns most specific limit of given domain and endpoint. Domain and
+// endpoint limit takes precedence over domain limit which takes precedence over
+// endpoint limit.
+func lookup(limits map[limitKey]uint64, domain, endpoint string) (limitKey, uint64, bool) {
+	for _, key := range [...]limitKey{
+		{domain, endpoint},
+		{domain, ""},
+		{"", endpoint},
+		{"", ""},
+	} {
+		if max, ok := limits[key]; ok {
+			return key, max, true
+		}
+	}
+
+	return limitKey{}, 0, false
+}
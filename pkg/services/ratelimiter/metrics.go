@@ -0,0 +1,22 @@
+package ratelimiter
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	throttled *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		throttled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ratelimiter_throttled_total",
+			Help: "Number of events requests throttled by rate limiter",
+		}, []string{"domain", "endpoint"}),
+	}
+
+	promRegistry.MustRegister(
+		m.throttled,
+	)
+
+	return m
+}
@@ -0,0 +1,155 @@
+package ratelimiter
+
+import (
+	"slices"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Algorithm define a rate limiting algorithm.
+type Algorithm string
+
+// Supported algorithms.
+const (
+	// At most LIMIT requests are accepted per minute window.
+	FixedWindowAlgorithm Algorithm = "fixed-window"
+	// Bucket holds up to LIMIT tokens and is refilled at LIMIT tokens per
+	// minute, each request consumes a token.
+	TokenBucketAlgorithm Algorithm = "token-bucket"
+)
+
+// Key define what requests are rate limited by.
+type Key string
+
+// Supported keys.
+const (
+	// Client IP address.
+	IpKey Key = "ip"
+	// Device id derived from IP address, user agent and domain.
+	DeviceKey Key = "device"
+	// Domain of tracked page.
+	OriginKey Key = "origin"
+)
+
+// Rate limits period.
+const period = time.Minute
+
+// Endpoints lists events endpoints that can be rate limited.
+var Endpoints = []string{
+	"pageviews",
+	"custom",
+	"outbound-links",
+	"file-downloads",
+	"engagement",
+	"web-vitals",
+	"errors",
+}
+
+// Label of metrics for requests without a domain specific limit or of an
+// unknown endpoint.
+const otherLabel = "other"
+
+// Request holds rate limited request data.
+type Request struct {
+	Domain   string
+	Endpoint string
+	// Key value of request, see Service.Key.
+	Key string
+}
+
+// Service define a rate limiter service for events endpoints.
+//
+// Limiters state is kept in a Storage (see NewStorage) so it can be shared by
+// multiple replicas using a shared storage.
+type Service interface {
+	// Key returns key requests are rate limited by.
+	Key() Key
+	// Allow returns true if request is allowed and false if it must be
+	// throttled.
+	Allow(Request) bool
+}
+
+type service struct {
+	logger    log.Logger
+	metrics   metrics
+	limits    map[limitKey]uint64
+	algorithm Algorithm
+	key       Key
+	storage   Storage
+	now       func() time.Time
+}
+
+// NewService returns a new rate limiter Service using given storage.
+func NewService(
+	cfg Config,
+	storage Storage,
+	logger log.Logger,
+	promRegistry *prometheus.Registry,
+) (Service, error) {
+	logger = logger.With("service", "ratelimiter")
+
+	limits, err := parseLimits(cfg.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(
+		"rate limiter configured",
+		"algorithm", cfg.Algorithm,
+		"key", cfg.Key,
+		"limits", len(limits),
+	)
+
+	return &service{
+		logger:    logger,
+		metrics:   newMetrics(promRegistry),
+		limits:    limits,
+		algorithm: Algorithm(cfg.Algorithm),
+		key:       Key(cfg.Key),
+		storage:   storage,
+		now:       time.Now,
+	}, nil
+}
+
+// Key implements Service.
+func (s *service) Key() Key {
+	return s.key
+}
+
+// Allow implements Service.
+func (s *service) Allow(req Request) bool {
+	limit, max, ok := lookup(s.limits, req.Domain, req.Endpoint)
+	if !ok || max == 0 {
+		return true
+	}
+
+	// Requests share limiter state of the limit they match.
+	stateKey := "ratelimiter:" + limit.domain + ":" + limit.endpoint + ":" + req.Key
+
+	allowed, err := s.storage.Take(stateKey, s.algorithm, max, s.now())
+	if err != nil {
+		// Events are never rejected because of storage failures.
+		s.logger.Err("failed to update rate limiter state", err, "key", stateKey)
+		return true
+	}
+
+	if !allowed {
+		// Labels are bounded by configuration as request domain and endpoint
+		// come from client.
+		domain, endpoint := limit.domain, req.Endpoint
+		if domain == "" {
+			domain = otherLabel
+		}
+		if !slices.Contains(Endpoints, endpoint) {
+			endpoint = otherLabel
+		}
+		s.metrics.throttled.With(prometheus.Labels{
+			"domain":   domain,
+			"endpoint": endpoint,
+		}).Inc()
+	}
+
+	return allowed
+}
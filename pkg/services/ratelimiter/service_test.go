@@ -0,0 +1,153 @@
+package ratelimiter
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("ratelimiter_service_test", io.Discard, false)
+
+	newService := func(t *testing.T, cfg Config) (*service, *time.Time) {
+		cfg.Storage = string(MemoryStorage)
+		require.NoError(t, cfg.Validate())
+		srv, err := NewService(cfg, newMemoryStorage(), logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		now := time.Now()
+		srv.(*service).now = func() time.Time { return now }
+		return srv.(*service), &now
+	}
+
+	allowed := func(srv Service, req Request, n int) int {
+		count := 0
+		for range n {
+			if srv.Allow(req) {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("FixedWindow", func(t *testing.T) {
+		srv, now := newService(t, Config{
+			Limits:    []string{"5"},
+			Algorithm: string(FixedWindowAlgorithm),
+			Key:       string(IpKey),
+		})
+		req := Request{Domain: "example.com", Endpoint: "pageviews", Key: "192.0.2.1"}
+
+		require.Equal(t, 5, allowed(srv, req, 10))
+		// Limit is shared by all endpoints.
+		require.False(t, srv.Allow(Request{Domain: "example.com", Endpoint: "custom", Key: "192.0.2.1"}))
+		// Other keys aren't affected.
+		require.True(t, srv.Allow(Request{Domain: "example.com", Endpoint: "pageviews", Key: "192.0.2.2"}))
+
+		*now = now.Add(30 * time.Second)
+		require.False(t, srv.Allow(req))
+
+		*now = now.Add(30 * time.Second)
+		require.Equal(t, 5, allowed(srv, req, 10))
+
+		// Unknown endpoint.
+		require.False(t, srv.Allow(Request{Domain: "example.com", Endpoint: "foo", Key: "192.0.2.1"}))
+
+		// Labels don't contain client supplied domain and endpoint.
+		throttled := srv.metrics.throttled
+		require.Equal(t, 3, testutil.CollectAndCount(throttled))
+		require.Equal(t, 11.0, testutil.ToFloat64(throttled.WithLabelValues("other", "pageviews")))
+		require.Equal(t, 1.0, testutil.ToFloat64(throttled.WithLabelValues("other", "custom")))
+		require.Equal(t, 1.0, testutil.ToFloat64(throttled.WithLabelValues("other", "other")))
+	})
+
+	t.Run("TokenBucket", func(t *testing.T) {
+		srv, now := newService(t, Config{
+			Limits:    []string{"60"},
+			Algorithm: string(TokenBucketAlgorithm),
+			Key:       string(IpKey),
+		})
+		req := Request{Domain: "example.com", Endpoint: "pageviews", Key: "192.0.2.1"}
+
+		require.Equal(t, 60, allowed(srv, req, 100))
+
+		// One token per second.
+		*now = now.Add(10 * time.Second)
+		require.Equal(t, 10, allowed(srv, req, 100))
+
+		// Bucket can't hold more than limit.
+		*now = now.Add(time.Hour)
+		require.Equal(t, 60, allowed(srv, req, 100))
+	})
+
+	t.Run("DomainAndEndpointLimits", func(t *testing.T) {
+		srv, _ := newService(t, Config{
+			Limits: []string{
+				"2",
+				"pageviews:3",
+				"example.com=4",
+				"example.com=custom:0",
+			},
+			Algorithm: string(FixedWindowAlgorithm),
+			Key:       string(OriginKey),
+		})
+
+		require.Equal(t, 3, allowed(srv, Request{Domain: "foo.com", Endpoint: "pageviews", Key: "foo.com"}, 10))
+		require.Equal(t, 2, allowed(srv, Request{Domain: "foo.com", Endpoint: "errors", Key: "foo.com"}, 10))
+		require.Equal(t, 4, allowed(srv, Request{Domain: "example.com", Endpoint: "pageviews", Key: "example.com"}, 10))
+		require.Equal(t, 6.0, testutil.ToFloat64(srv.metrics.throttled.WithLabelValues("example.com", "pageviews")))
+		// Unlimited.
+		require.Equal(t, 10, allowed(srv, Request{Domain: "example.com", Endpoint: "custom", Key: "example.com"}, 10))
+	})
+
+	t.Run("ConcurrentRequests", func(t *testing.T) {
+		srv, _ := newService(t, Config{
+			Limits:    []string{"50"},
+			Algorithm: string(FixedWindowAlgorithm),
+			Key:       string(IpKey),
+		})
+		req := Request{Domain: "example.com", Endpoint: "pageviews", Key: "192.0.2.1"}
+
+		var (
+			wg    sync.WaitGroup
+			count atomic.Int64
+		)
+		for range 100 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if srv.Allow(req) {
+					count.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.EqualValues(t, 50, count.Load())
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		for _, cfg := range []Config{
+			{Limits: []string{"foo"}, Algorithm: string(FixedWindowAlgorithm), Key: string(IpKey)},
+			{Limits: []string{"=10"}, Algorithm: string(FixedWindowAlgorithm), Key: string(IpKey)},
+			{Limits: []string{"example.com=:10"}, Algorithm: string(FixedWindowAlgorithm), Key: string(IpKey)},
+			{Limits: []string{"10"}, Algorithm: "foo", Key: string(IpKey)},
+			{Limits: []string{"10"}, Algorithm: string(TokenBucketAlgorithm), Key: "foo"},
+			{Limits: []string{"foo:10"}, Algorithm: string(FixedWindowAlgorithm), Key: string(IpKey)},
+			{Limits: []string{"10"}, Algorithm: string(FixedWindowAlgorithm), Key: string(IpKey), Storage: "foo"},
+			{Limits: []string{"10"}, Algorithm: string(FixedWindowAlgorithm), Key: string(IpKey), Storage: string(RedisStorage)},
+		} {
+			if cfg.Storage == "" {
+				cfg.Storage = string(MemoryStorage)
+			}
+			require.Error(t, cfg.Validate(), cfg)
+		}
+	})
+}
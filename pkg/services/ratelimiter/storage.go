@@ -0,0 +1,206 @@
+package ratelimiter
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/gofiber/storage/memory"
+	"github.com/redis/go-redis/v9"
+)
+
+// StorageKind define a kind of storage of rate limiters state.
+type StorageKind string
+
+// Supported storages.
+const (
+	// State is kept in process memory, each replica enforces its own limits.
+	MemoryStorage StorageKind = "memory"
+	// State is kept in a redis server shared by replicas.
+	RedisStorage StorageKind = "redis"
+)
+
+// Number of locks guarding memory storage states, states are assigned to a
+// lock using their key hash.
+const locksCount = 256
+
+// Storage define a storage of rate limiters state. Limiters state is read and
+// updated atomically so limits hold across replicas sharing a storage.
+type Storage interface {
+	// Take atomically consumes a request from limiter state of given key and
+	// returns true if request is allowed.
+	Take(key string, algorithm Algorithm, limit uint64, now time.Time) (bool, error)
+}
+
+// NewStorage returns storage of rate limiters state configured by cfg.
+func NewStorage(cfg Config) (Storage, error) {
+	switch StorageKind(cfg.Storage) {
+	case RedisStorage:
+		opts, err := redis.ParseURL(cfg.RedisUrl.ExposeSecret())
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limiter redis url: %w", err)
+		}
+
+		client := redis.NewClient(opts)
+		err = client.Ping(context.Background()).Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to rate limiter redis storage: %w", err)
+		}
+
+		return &redisStorage{client: client}, nil
+
+	default:
+		return newMemoryStorage(), nil
+	}
+}
+
+type memoryStorage struct {
+	storage *memory.Storage
+	// Locks guarding read and write of states.
+	locks [locksCount]sync.Mutex
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		storage: memory.New(memory.Config{
+			GCInterval: 10 * time.Second,
+		}),
+	}
+}
+
+// Take implements Storage.
+func (ms *memoryStorage) Take(key string, algorithm Algorithm, limit uint64, now time.Time) (bool, error) {
+	mu := &ms.locks[xxhash.Sum64String(key)%locksCount]
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Memory storage never fails.
+	raw, _ := ms.storage.Get(key)
+	st, found := decodeState(raw)
+
+	var allowed bool
+	var exp time.Duration
+	switch algorithm {
+	case TokenBucketAlgorithm:
+		if !found {
+			st = state{value: float64(limit), time: now}
+		}
+		// Refill bucket.
+		elapsed := now.Sub(st.time)
+		st.value = min(float64(limit), st.value+elapsed.Seconds()*float64(limit)/period.Seconds())
+		st.time = now
+
+		allowed = st.value >= 1
+		if allowed {
+			st.value--
+		}
+		// Bucket is full once expired.
+		exp = time.Duration((float64(limit) - st.value) / float64(limit) * float64(period))
+
+	default:
+		if !found || now.Sub(st.time) >= period {
+			st = state{value: 0, time: now}
+		}
+
+		allowed = st.value < float64(limit)
+		if allowed {
+			st.value++
+		}
+		exp = st.time.Add(period).Sub(now)
+	}
+
+	_ = ms.storage.Set(key, st.encode(), max(exp, time.Second))
+
+	return allowed, nil
+}
+
+// state define limiter state of memory storage. value is the number of
+// requests of current window for fixed window algorithm and number of tokens
+// for token bucket algorithm.
+type state struct {
+	value float64
+	time  time.Time
+}
+
+func (s state) encode() []byte {
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(s.value))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(s.time.UnixNano()))
+	return buf
+}
+
+func decodeState(buf []byte) (state, bool) {
+	if len(buf) != 16 {
+		return state{}, false
+	}
+
+	return state{
+		value: math.Float64frombits(binary.LittleEndian.Uint64(buf)),
+		time:  time.Unix(0, int64(binary.LittleEndian.Uint64(buf[8:]))),
+	}, true
+}
+
+// Redis scripts are executed atomically.
+var (
+	// KEYS[1]: state key, ARGV[1]: limit, ARGV[2]: period in milliseconds.
+	redisFixedWindowScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if count <= tonumber(ARGV[1]) then
+	return 1
+end
+return 0
+`)
+	// KEYS[1]: state key, ARGV[1]: limit, ARGV[2]: period in milliseconds,
+	// ARGV[3]: current time in milliseconds.
+	redisTokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local st = redis.call("HMGET", KEYS[1], "tokens", "time")
+local tokens = tonumber(st[1]) or limit
+local time = tonumber(st[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - time) * limit / period)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "time", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.max(1000, math.ceil((limit - tokens) / limit * period)))
+return allowed
+`)
+)
+
+type redisStorage struct {
+	client *redis.Client
+}
+
+// Take implements Storage.
+func (rs *redisStorage) Take(key string, algorithm Algorithm, limit uint64, now time.Time) (bool, error) {
+	ctx := context.Background()
+
+	var (
+		allowed int
+		err     error
+	)
+	switch algorithm {
+	case TokenBucketAlgorithm:
+		allowed, err = redisTokenBucketScript.Run(ctx, rs.client, []string{key},
+			limit, period.Milliseconds(), now.UnixMilli()).Int()
+	default:
+		allowed, err = redisFixedWindowScript.Run(ctx, rs.client, []string{key},
+			limit, period.Milliseconds()).Int()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return allowed == 1, nil
+}
@@ -0,0 +1,111 @@
+package referrerparser
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// categoriesPriority defines which category wins when a source name or domain
+// appears in multiple categories. Unlisted categories have lowest priority.
+var categoriesPriority = []string{SearchChannel, SocialChannel, EmailChannel, PaidChannel}
+
+// database maps referrer domains and lower cased source names to sources.
+type database struct {
+	domains map[string]Source
+	names   map[string]Source
+}
+
+// parseDatabase parses a referrers database in Snowplow referer-parser JSON
+// format. Referrers under "unknown" category belongs to referral channel and
+// domains with a path are ignored.
+func parseDatabase(data []byte) (database, error) {
+	var raw map[string]map[string]struct {
+		Domains []string `json:"domains"`
+	}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return database{}, fmt.Errorf("failed to parse referrers database: %w", err)
+	}
+
+	db := database{
+		domains: make(map[string]Source),
+		names:   make(map[string]Source),
+	}
+	// Iterate in a deterministic order so duplicates are always resolved the
+	// same way.
+	categories := slices.SortedFunc(maps.Keys(raw), func(a, b string) int {
+		return cmp.Or(categoryRank(a)-categoryRank(b), strings.Compare(a, b))
+	})
+
+	for _, category := range categories {
+		channel := category
+		if channel == "unknown" {
+			channel = ReferralChannel
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(raw[category])) {
+			referrer := raw[category][name]
+			source := Source{Name: name, Channel: channel}
+			addIfMissing(db.names, strings.ToLower(name), source)
+
+			for _, domain := range referrer.Domains {
+				if strings.Contains(domain, "/") {
+					continue
+				}
+				addIfMissing(db.domains, normalizeDomain(domain), source)
+			}
+		}
+	}
+
+	return db, nil
+}
+
+// lookupDomain returns source of given domain or of its closest parent domain.
+func (db *database) lookupDomain(domain string) (Source, bool) {
+	domain = normalizeDomain(domain)
+	for domain != "" {
+		if source, ok := db.domains[domain]; ok {
+			return source, true
+		}
+
+		// Try parent domain, top level domains are never looked up.
+		_, parent, found := strings.Cut(domain, ".")
+		if !found || !strings.Contains(parent, ".") {
+			break
+		}
+		domain = parent
+	}
+
+	return Source{}, false
+}
+
+// lookupName returns source with given name (case insensitive) or domain.
+func (db *database) lookupName(name string) (Source, bool) {
+	if source, ok := db.names[strings.ToLower(name)]; ok {
+		return source, true
+	}
+
+	return db.lookupDomain(name)
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	return strings.TrimPrefix(domain, "www.")
+}
+
+func categoryRank(category string) int {
+	if i := slices.Index(categoriesPriority, category); i >= 0 {
+		return i
+	}
+	return len(categoriesPriority)
+}
+
+func addIfMissing(m map[string]Source, key string, source Source) {
+	if _, ok := m[key]; !ok {
+		m[key] = source
+	}
+}
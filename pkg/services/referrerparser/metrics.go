@@ -0,0 +1,22 @@
+package referrerparser
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	parsed *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		parsed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "referrerparser_parse_total",
+			Help: "Number of sessions sources parsed",
+		}, []string{"channel"}),
+	}
+
+	promRegistry.MustRegister(
+		m.parsed,
+	)
+
+	return m
+}
@@ -0,0 +1,122 @@
+package referrerparser
+
+import (
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/embedded"
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// utmMediumChannels maps lower cased UTM medium to channels.
+var utmMediumChannels = map[string]string{
+	"cpc":            PaidChannel,
+	"ppc":            PaidChannel,
+	"cpm":            PaidChannel,
+	"cpv":            PaidChannel,
+	"cpa":            PaidChannel,
+	"paid":           PaidChannel,
+	"display":        PaidChannel,
+	"banner":         PaidChannel,
+	"retargeting":    PaidChannel,
+	"remarketing":    PaidChannel,
+	"email":          EmailChannel,
+	"e-mail":         EmailChannel,
+	"e_mail":         EmailChannel,
+	"newsletter":     EmailChannel,
+	"social":         SocialChannel,
+	"social-media":   SocialChannel,
+	"social_media":   SocialChannel,
+	"social-network": SocialChannel,
+	"social_network": SocialChannel,
+	"sm":             SocialChannel,
+	"organic":        SearchChannel,
+	"referral":       ReferralChannel,
+}
+
+// Service define a referrer parser service classifying sessions traffic
+// source and channel.
+type Service interface {
+	// ParseSource returns source of a session using its external referrer
+	// domain (empty for direct traffic), UTM source and UTM medium. UTM medium
+	// takes precedence over referrer domain to determine channel.
+	ParseSource(referrerDomain, utmSource, utmMedium string) Source
+}
+
+type service struct {
+	logger  log.Logger
+	metrics metrics
+	db      database
+}
+
+// NewService returns a new referrer parser Service using embedded referrers
+// database.
+func NewService(
+	logger log.Logger,
+	promRegistry *prometheus.Registry,
+) Service {
+	logger = logger.With(
+		"service", "referrerparser",
+	)
+
+	db, err := parseDatabase(embedded.Referrers)
+	if err != nil {
+		logger.Fatal("failed to load referrers database", err)
+	}
+
+	return &service{logger, newMetrics(promRegistry), db}
+}
+
+// ParseSource implements Service.
+func (s *service) ParseSource(referrerDomain, utmSource, utmMedium string) Source {
+	referrerDomain = normalizeDomain(referrerDomain)
+	utmSource = strings.TrimSpace(utmSource)
+
+	source, known := s.db.lookupDomain(referrerDomain)
+	if !known && utmSource != "" {
+		source, known = s.db.lookupName(utmSource)
+	}
+
+	if !known {
+		switch {
+		case utmSource != "":
+			source = Source{Name: utmSource, Channel: ReferralChannel}
+		case referrerDomain != "":
+			source = Source{Name: referrerDomain, Channel: ReferralChannel}
+		default:
+			source = Source{Name: DirectChannel, Channel: DirectChannel}
+		}
+	}
+
+	if channel, ok := mediumChannel(utmMedium); ok {
+		source.Channel = channel
+	}
+
+	s.metrics.parsed.With(prometheus.Labels{
+		"channel": source.Channel,
+	}).Inc()
+
+	s.logger.Debug(
+		"source parsed",
+		"referrer_domain", referrerDomain,
+		"utm_source", utmSource,
+		"utm_medium", utmMedium,
+		"source", source,
+	)
+
+	return source
+}
+
+func mediumChannel(medium string) (string, bool) {
+	medium = strings.ToLower(strings.TrimSpace(medium))
+	if channel, ok := utmMediumChannels[medium]; ok {
+		return channel, true
+	}
+
+	// paid-search, paid_social, paidsocial...
+	if strings.HasPrefix(medium, "paid") {
+		return PaidChannel, true
+	}
+
+	return "", false
+}
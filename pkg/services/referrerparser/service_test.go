@@ -0,0 +1,131 @@
+package referrerparser
+
+import (
+	"io"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("referrerparser_service_test", io.Discard, false)
+
+	t.Run("ParseSource", func(t *testing.T) {
+		srv := NewService(logger, prometheus.NewRegistry())
+
+		type testCase struct {
+			name           string
+			referrerDomain string
+			utmSource      string
+			utmMedium      string
+			expected       Source
+		}
+
+		testCases := []testCase{
+			{
+				name:     "Direct",
+				expected: Source{Name: "direct", Channel: DirectChannel},
+			},
+			{
+				name:           "SearchEngine",
+				referrerDomain: "www.google.com",
+				expected:       Source{Name: "Google", Channel: SearchChannel},
+			},
+			{
+				name:           "SearchEngineCountryDomain",
+				referrerDomain: "www.google.co.uk",
+				expected:       Source{Name: "Google", Channel: SearchChannel},
+			},
+			{
+				name:           "SubdomainOfKnownDomain",
+				referrerDomain: "lm.facebook.com",
+				expected:       Source{Name: "Facebook", Channel: SocialChannel},
+			},
+			{
+				name:           "MostSpecificDomainWins",
+				referrerDomain: "mail.google.com",
+				expected:       Source{Name: "Gmail", Channel: EmailChannel},
+			},
+			{
+				name:           "UnknownReferrer",
+				referrerDomain: "www.example.com",
+				expected:       Source{Name: "example.com", Channel: ReferralChannel},
+			},
+			{
+				name:      "UtmSourceName",
+				utmSource: "newsletter-weekly",
+				utmMedium: "email",
+				expected:  Source{Name: "newsletter-weekly", Channel: EmailChannel},
+			},
+			{
+				name:      "KnownUtmSource",
+				utmSource: "facebook",
+				expected:  Source{Name: "Facebook", Channel: SocialChannel},
+			},
+			{
+				name:           "PaidMediumOverrideReferrerChannel",
+				referrerDomain: "google.fr",
+				utmSource:      "google",
+				utmMedium:      "cpc",
+				expected:       Source{Name: "Google", Channel: PaidChannel},
+			},
+			{
+				name:           "PaidMediumPrefix",
+				referrerDomain: "t.co",
+				utmMedium:      "Paid_Social",
+				expected:       Source{Name: "Twitter", Channel: PaidChannel},
+			},
+			{
+				name:           "UnknownMedium",
+				referrerDomain: "duckduckgo.com",
+				utmMedium:      "foo",
+				expected:       Source{Name: "DuckDuckGo", Channel: SearchChannel},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				source := srv.ParseSource(tc.referrerDomain, tc.utmSource, tc.utmMedium)
+				require.Equal(t, tc.expected, source)
+			})
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		promRegistry := prometheus.NewRegistry()
+		srv := NewService(logger, promRegistry)
+
+		srv.ParseSource("", "", "")
+		srv.ParseSource("bing.com", "", "")
+		srv.ParseSource("search.brave.com", "", "")
+
+		parsed := srv.(*service).metrics.parsed
+		require.Equal(t, float64(1), testutil.ToFloat64(parsed.WithLabelValues(DirectChannel)))
+		require.Equal(t, float64(2), testutil.ToFloat64(parsed.WithLabelValues(SearchChannel)))
+	})
+}
+
+func TestParseDatabase(t *testing.T) {
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := parseDatabase([]byte("[]"))
+		require.Error(t, err)
+	})
+
+	t.Run("DuplicatesAndPaths", func(t *testing.T) {
+		db, err := parseDatabase([]byte(`{
+			"unknown": {"Foo": {"domains": ["foo.com"]}},
+			"search": {"Foo": {"domains": ["www.foo.com", "bar.com/search"]}}
+		}`))
+		require.NoError(t, err)
+
+		source, ok := db.lookupDomain("foo.com")
+		require.True(t, ok)
+		require.Equal(t, Source{Name: "Foo", Channel: SearchChannel}, source)
+
+		_, ok = db.lookupDomain("bar.com")
+		require.False(t, ok)
+	})
+}
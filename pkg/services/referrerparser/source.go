@@ -0,0 +1,19 @@
+package referrerparser
+
+// Acquisition channels of sessions.
+const (
+	DirectChannel   = "direct"
+	SearchChannel   = "search"
+	SocialChannel   = "social"
+	EmailChannel    = "email"
+	PaidChannel     = "paid"
+	ReferralChannel = "referral"
+)
+
+// Source define normalized traffic source of a session.
+type Source struct {
+	// Name of the source (e.g. "Google"), referrer domain or UTM source if
+	// source is unknown and "direct" for direct traffic.
+	Name    string `json:"name"`
+	Channel string `json:"channel"`
+}
@@ -21,9 +21,11 @@ type Service interface {
 	// session isn't stored.
 	InsertSession(deviceId uint64, session event.Session) bool
 	// AddPageview adds a pageview to a session with the given device id
-	// latest path (referrer). Session is returned along a true flag if it was
-	// found.
-	AddPageview(deviceId uint64, referrer event.ReferrerUri, uri uri.Uri) (event.Session, bool)
+	// latest path (referrer). Session is returned along pageview timestamp and
+	// a true flag if it was found. Returned timestamp is never before latest
+	// pageview of session so late pageviews with a client timestamp don't
+	// move session exit backward.
+	AddPageview(deviceId uint64, referrer event.ReferrerUri, uri uri.Uri, timestamp time.Time) (event.Session, time.Time, bool)
 	// IdentifySession updates stored session visitor id. Updated session and
 	// boolean found flag are returned.
 	IdentifySession(deviceId uint64, pageUri uri.Uri, visitorId string) (event.Session, bool)
@@ -37,10 +39,11 @@ type Service interface {
 // sessionEntry holds session and associated metadata of an entry in session
 // storage.
 type sessionEntry struct {
-	Session   event.Session
-	latestUri uri.Uri
-	wait      chan struct{}
-	expiry    uint32
+	Session         event.Session
+	latestUri       uri.Uri
+	latestTimestamp time.Time
+	wait            chan struct{}
+	expiry          uint32
 }
 
 func (e *sessionEntry) hasWaiter() bool {
@@ -226,10 +229,11 @@ func (s *service) InsertSession(deviceId uint64, session event.Session) bool {
 
 	s.mu.Lock()
 	newEntry := sessionEntry{
-		Session:   session,
-		latestUri: session.PageUri,
-		wait:      nil,
-		expiry:    s.newExpiry(),
+		Session:         session,
+		latestUri:       session.PageUri,
+		latestTimestamp: session.SessionTime().UTC(),
+		wait:            nil,
+		expiry:          s.newExpiry(),
 	}
 
 	deviceData, deviceFound := s.devices[deviceId]
@@ -277,22 +281,26 @@ func (s *service) InsertSession(deviceId uint64, session event.Session) bool {
 }
 
 // AddPageview implements Service.
-func (s *service) AddPageview(deviceId uint64, referrer event.ReferrerUri, uri uri.Uri) (event.Session, bool) {
+func (s *service) AddPageview(deviceId uint64, referrer event.ReferrerUri, uri uri.Uri, timestamp time.Time) (event.Session, time.Time, bool) {
 	s.mu.Lock()
 	entry := s.getValidSessionEntry(deviceId, referrer.Path())
 	if entry == nil {
 		s.mu.Unlock()
-		return event.Session{}, false
+		return event.Session{}, time.Time{}, false
 	}
 
 	entry.latestUri = uri
 	entry.Session.PageviewCount++
+	if timestamp.Before(entry.latestTimestamp) {
+		timestamp = entry.latestTimestamp
+	}
+	entry.latestTimestamp = timestamp
 
 	// Copy before releasing lock.
 	sess := entry.Session
 	s.mu.Unlock()
 
-	return sess, true
+	return sess, timestamp, true
 }
 
 // IdentifySession implements Service.
@@ -154,7 +154,7 @@ func TestService(t *testing.T) {
 			referrer := mustParseReferrerUri([]byte(mustParseUri("https://example.com/bar").String()))
 			pageUri = mustParseUri("https://example.com/foo")
 
-			sessionV2, ok := service.AddPageview(deviceId, referrer, pageUri)
+			sessionV2, _, ok := service.AddPageview(deviceId, referrer, pageUri, time.Now())
 			require.False(t, ok)
 			require.NotEqual(t, sessionV1.VisitorId, sessionV2.VisitorId)
 
@@ -188,7 +188,7 @@ func TestService(t *testing.T) {
 			referrer := mustParseReferrerUri([]byte(mustParseUri("https://example.com/").String()))
 			pageUri = mustParseUri("https://example.com/foo")
 
-			sessionV2, ok := service.AddPageview(deviceId, referrer, pageUri)
+			sessionV2, _, ok := service.AddPageview(deviceId, referrer, pageUri, time.Now())
 			require.True(t, ok)
 			require.Equal(t, sessionV1.VisitorId, sessionV2.VisitorId)
 			require.Equal(t, sessionV1.PageviewCount+1, sessionV2.PageviewCount)
@@ -204,6 +204,46 @@ func TestService(t *testing.T) {
 			require.Equal(t, float64(0),
 				testutils.HistogramSumValue(t, promRegistry, "sessionstore_sessions_pageviews", nil))
 		})
+
+		t.Run("LateTimestamp", func(t *testing.T) {
+			promRegistry := prometheus.NewRegistry()
+			service := NewService(logger, cfg, promRegistry)
+
+			deviceId := rand.Uint64()
+			sessionUuid, err := uuid.NewV7()
+			require.NoError(t, err)
+			session := event.Session{
+				PageUri:       mustParseUri("https://example.com"),
+				SessionUuid:   sessionUuid,
+				PageviewCount: 1,
+			}
+
+			ok := service.InsertSession(deviceId, session)
+			require.True(t, ok)
+
+			// Pageview timestamp before session creation.
+			referrer := mustParseReferrerUri([]byte("https://example.com/"))
+			pageUri := mustParseUri("https://example.com/foo")
+			_, timestamp, ok := service.AddPageview(deviceId, referrer, pageUri, session.SessionTime().Add(-time.Hour))
+			require.True(t, ok)
+			require.Equal(t, session.SessionTime().UTC(), timestamp)
+
+			// Pageview timestamp after previous one.
+			later := session.SessionTime().Add(time.Minute)
+			referrer = mustParseReferrerUri([]byte(pageUri.String()))
+			pageUri = mustParseUri("https://example.com/bar")
+			_, timestamp, ok = service.AddPageview(deviceId, referrer, pageUri, later)
+			require.True(t, ok)
+			require.Equal(t, later, timestamp)
+
+			// Late pageview doesn't move session exit backward.
+			referrer = mustParseReferrerUri([]byte(pageUri.String()))
+			pageUri = mustParseUri("https://example.com/baz")
+			sessionV4, timestamp, ok := service.AddPageview(deviceId, referrer, pageUri, later.Add(-30*time.Second))
+			require.True(t, ok)
+			require.Equal(t, later, timestamp)
+			require.Equal(t, uint16(4), sessionV4.PageviewCount)
+		})
 	})
 
 	t.Run("IdentifySession", func(t *testing.T) {
@@ -531,7 +571,7 @@ func TestService(t *testing.T) {
 								referrerUri := mustParseReferrerUri([]byte(session.PageUri.String()))
 								go func() {
 									time.Sleep(cfg.sessionInactiveTtl / 2)
-									session, ok := service.AddPageview(deviceId, referrerUri, newPageUri)
+									session, _, ok := service.AddPageview(deviceId, referrerUri, newPageUri, time.Now())
 									require.True(t, ok)
 									activeSessions.Store(session.SessionUuid, session)
 								}()
@@ -605,7 +645,7 @@ func TestService(t *testing.T) {
 								referrerUri := mustParseReferrerUri([]byte(session.PageUri.String()))
 								go func() {
 									time.Sleep(cfg.sessionInactiveTtl / 2)
-									_, ok := service.AddPageview(deviceId, referrerUri, newPageUri)
+									_, _, ok := service.AddPageview(deviceId, referrerUri, newPageUri, time.Now())
 									require.True(t, ok)
 								}()
 							}
@@ -693,7 +733,7 @@ func TestService(t *testing.T) {
 							referrerUri := mustParseReferrerUri([]byte(session.PageUri.String()))
 							go func() {
 								time.Sleep(cfg.sessionInactiveTtl / 2)
-								_, ok := service.AddPageview(deviceId, referrerUri, newPageUri)
+								_, _, ok := service.AddPageview(deviceId, referrerUri, newPageUri, time.Now())
 								require.True(t, ok)
 							}()
 						}
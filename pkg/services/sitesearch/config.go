@@ -0,0 +1,21 @@
+package sitesearch
+
+import (
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	Params []string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringSliceVar(&c.Params, "sitesearch.params", nil, "comma separated `list` of [DOMAIN=]PARAM site search query parameters, parameters without domain apply to all domains (e.g. q,www.example.com=query)")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	_, _, err := parseParams(c.Params)
+	return err
+}
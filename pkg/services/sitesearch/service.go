@@ -0,0 +1,116 @@
+package sitesearch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/valyala/fasthttp"
+)
+
+// MaxTermLength defines maximum length of search terms, longer terms are
+// truncated.
+const MaxTermLength = 256
+
+// Service define a site search service extracting search terms from pages
+// URI.
+type Service interface {
+	// Extract returns search term of given page URI and the URI without site
+	// search query parameters. An empty term is returned if URI has no site
+	// search query parameter.
+	Extract(uri.Uri) (uri.Uri, string)
+}
+
+type service struct {
+	params       []string
+	domainParams map[string][]string
+}
+
+// NewService returns a new site search Service.
+func NewService(cfg Config, logger log.Logger) (Service, error) {
+	logger = logger.With("service", "sitesearch")
+
+	params, domainParams, err := parseParams(cfg.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("site search parameters loaded", "params", params, "domains", len(domainParams))
+
+	return &service{
+		params:       params,
+		domainParams: domainParams,
+	}, nil
+}
+
+// Extract implements Service.
+func (s *service) Extract(u uri.Uri) (uri.Uri, string) {
+	query := u.QueryString()
+	if query == "" {
+		return u, ""
+	}
+
+	params := s.params
+	if domainParams, ok := s.domainParams[u.Host()]; ok {
+		params = domainParams
+	}
+	if len(params) == 0 {
+		return u, ""
+	}
+
+	var args fasthttp.Args
+	args.Parse(query)
+
+	term := ""
+	found := false
+	for _, param := range params {
+		value := args.Peek(param)
+		if value == nil {
+			continue
+		}
+		found = true
+		args.Del(param)
+		if term == "" {
+			term = strings.TrimSpace(string(value))
+		}
+	}
+	if !found {
+		return u, ""
+	}
+
+	if len(term) > MaxTermLength {
+		term = strings.ToValidUTF8(term[:MaxTermLength], "")
+	}
+
+	return u.WithQuery(string(args.QueryString())), term
+}
+
+// parseParams parses list of [DOMAIN=]PARAM site search parameters. Domain
+// specific parameters replace parameters without domain.
+func parseParams(list []string) ([]string, map[string][]string, error) {
+	var params []string
+	domainParams := make(map[string][]string)
+
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		domain, param, hasDomain := strings.Cut(entry, "=")
+		if !hasDomain {
+			params = append(params, entry)
+			continue
+		}
+
+		domain = strings.TrimSpace(domain)
+		param = strings.TrimSpace(param)
+		if domain == "" || param == "" {
+			return nil, nil, fmt.Errorf("invalid site search parameter %q", entry)
+		}
+		domainParams[domain] = append(domainParams[domain], param)
+	}
+
+	return params, domainParams, nil
+}
@@ -0,0 +1,50 @@
+package sitesearch
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/testutils"
+	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("sitesearch_service_test", io.Discard, false)
+
+	t.Run("InvalidParams", func(t *testing.T) {
+		_, err := NewService(Config{Params: []string{"=q"}}, logger)
+		require.Error(t, err)
+
+		cfg := Config{Params: []string{"example.com="}}
+		require.Error(t, cfg.Validate())
+	})
+
+	t.Run("Extract", func(t *testing.T) {
+		srv, err := NewService(Config{Params: []string{"q", "s", "example.com=query"}}, logger)
+		require.NoError(t, err)
+
+		type testCase struct {
+			uri, expectedUri, expectedTerm string
+		}
+		for _, tcase := range []testCase{
+			{"https://example.org/search", "https://example.org/search", ""},
+			{"https://example.org/search?page=2", "https://example.org/search?page=2", ""},
+			{"https://example.org/search?q=+red+shoes&page=2", "https://example.org/search?page=2", "red shoes"},
+			{"https://example.org/search?s=hat&q=shoes", "https://example.org/search", "shoes"},
+			{"https://example.org/search?q=", "https://example.org/search", ""},
+			// Domain specific parameters replace default ones.
+			{"https://example.com/search?q=shoes", "https://example.com/search?q=shoes", ""},
+			{"https://example.com/search?query=shoes#results", "https://example.com/search#results", "shoes"},
+		} {
+			u, term := srv.Extract(testutils.Must(uri.Parse)(tcase.uri))
+			require.Equal(t, tcase.expectedUri, u.String(), tcase.uri)
+			require.Equal(t, tcase.expectedTerm, term, tcase.uri)
+		}
+
+		_, term := srv.Extract(testutils.Must(uri.Parse)("https://example.org/search?q=" + strings.Repeat("a", 2*MaxTermLength)))
+		require.Len(t, term, MaxTermLength)
+	})
+}
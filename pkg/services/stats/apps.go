@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"context"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// TopAppVersions implements Service. Web sessions have no app version and are
+// ignored.
+func (s *service) TopAppVersions(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	return s.topAppSessionDimension(ctx, "app_version", filters, limit)
+}
+
+// TopOsVersions implements Service. Web sessions have no operating system
+// version and are ignored.
+func (s *service) TopOsVersions(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	return s.topAppSessionDimension(ctx, "os_version", filters, limit)
+}
+
+func (s *service) topAppSessionDimension(
+	ctx context.Context,
+	col string,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Strs(
+		"WITH dimensions AS (",
+		"  SELECT argMax("+col+", pageviews) AS dimension",
+		"  FROM sessions",
+		"  WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+		"  GROUP BY session_uuid",
+		")",
+		"SELECT dimension, COUNT(*) AS sessions",
+		"FROM dimensions",
+		"WHERE dimension != ''",
+		"GROUP BY dimension",
+		"ORDER BY sessions DESC, dimension ASC",
+	).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
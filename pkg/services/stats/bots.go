@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// BotPageViews implements Service. Bot pageviews are only recorded if bot
+// traffic recording is enabled and are never part of sessions statistics.
+func (s *service) BotPageViews(
+	ctx context.Context,
+	filters Filters,
+) (DataFrame[time.Time, uint64], error) {
+	var b sql.Builder
+
+	b.Str("SELECT toStartOfInterval(toDateTime(timestamp),").
+		Call(interval, filters.TimeRange).Str(") AS time,").
+		Strs("COUNT(*)",
+			"FROM bot_pageviews",
+			"WHERE").Call(botPageviewsFilter, filters).
+		Strs("GROUP BY time",
+			"ORDER BY time")
+
+	return doQuery[time.Time](s.db, ctx, &b)
+}
+
+// TopBots implements Service.
+func (s *service) TopBots(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Strs("SELECT bot_family, COUNT(*) AS pageviews",
+		"FROM bot_pageviews",
+		"WHERE").Call(botPageviewsFilter, filters).
+		Strs("GROUP BY bot_family",
+			"ORDER BY pageviews DESC, bot_family ASC",
+		).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
+
+// TopCrawledPages implements Service.
+func (s *service) TopCrawledPages(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Strs("SELECT path, COUNT(*) AS pageviews",
+		"FROM bot_pageviews",
+		"WHERE").Call(botPageviewsFilter, filters).
+		Strs("GROUP BY path",
+			"ORDER BY pageviews DESC, path ASC",
+		).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
+
+// botPageviewsFilter filters bot_pageviews rows. Only time range, domain, path
+// and bot filters apply as bot pageviews have no session.
+func botPageviewsFilter(builder *sql.Builder, args ...any) {
+	filters := args[0].(Filters)
+
+	builder.Str("1 = 1")
+	if (filters.TimeRange != TimeRange{}) {
+		builder.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	if len(filters.Domain) > 0 {
+		builder.Str("AND").Call(stringListFilter, "domain", filters.Domain)
+	}
+	if len(filters.Path) > 0 {
+		builder.Str("AND").Call(stringListFilter, "path", filters.Path)
+	}
+	if len(filters.Bots) > 0 {
+		builder.Str("AND").Call(stringListFilter, "bot_family", filters.Bots)
+	}
+}
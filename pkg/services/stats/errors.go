@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// ErrorReport defines occurrences of a single front end error.
+type ErrorReport struct {
+	Fingerprint string
+	Message     string
+	Source      string
+	Line        uint32
+	Occurrences uint64
+	// Number of sessions affected by the error.
+	Sessions uint64
+	LastSeen time.Time
+}
+
+// TopErrors implements Service.
+func (s *service) TopErrors(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) ([]ErrorReport, error) {
+	var b sql.Builder
+
+	b.Strs("SELECT fingerprint,",
+		"any(message),",
+		"any(source),",
+		"any(line),",
+		"toUInt64(COUNT(*)) AS occurrences,",
+		"toUInt64(COUNT(DISTINCT(session_uuid))),",
+		"max(timestamp)",
+		"FROM errors",
+		"WHERE session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("GROUP BY fingerprint",
+		"ORDER BY occurrences DESC",
+	).Fmt("LIMIT %v", limit)
+
+	query, args := finishQuery(&b)
+
+	result, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %v failed: %w", query, err)
+	}
+
+	report := []ErrorReport{}
+	for result.Next() {
+		var e ErrorReport
+		err := result.Scan(
+			&e.Fingerprint,
+			&e.Message,
+			&e.Source,
+			&e.Line,
+			&e.Occurrences,
+			&e.Sessions,
+			&e.LastSeen,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		report = append(report, e)
+	}
+
+	return report, nil
+}
+
+// ErrorRate implements Service. Error rate is the ratio of sessions with at
+// least one error.
+func (s *service) ErrorRate(
+	ctx context.Context,
+	filters Filters,
+) (DataFrame[time.Time, float64], error) {
+	var b sql.Builder
+
+	b.Strs("WITH errors_sessions AS (",
+		"  SELECT DISTINCT session_uuid",
+		"  FROM errors",
+		"  WHERE session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Str(")").
+		Str("SELECT toStartOfInterval(toDateTime(session_timestamp),").
+		Call(interval, filters.TimeRange).Str(") AS time,").
+		Strs("toFloat64(uniqExactIf(",
+			"  session_uuid,",
+			"  session_uuid IN (SELECT session_uuid FROM errors_sessions)",
+			") / uniqExact(session_uuid))",
+			"FROM sessions",
+			"WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+		"GROUP BY time",
+		"ORDER BY time")
+
+	return doTypedQuery[time.Time, float64](s.db, ctx, &b)
+}
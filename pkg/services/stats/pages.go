@@ -0,0 +1,190 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// PagesReportSort defines a column used to sort pages report.
+type PagesReportSort string
+
+// Supported PagesReportSort.
+const (
+	SortByPath       PagesReportSort = "path"
+	SortByPageViews  PagesReportSort = "pageviews"
+	SortByVisitors   PagesReportSort = "visitors"
+	SortByEntries    PagesReportSort = "entries"
+	SortByExits      PagesReportSort = "exits"
+	SortByExitRate   PagesReportSort = "exit_rate"
+	SortByBounceRate PagesReportSort = "bounce_rate"
+	SortByTimeOnPage PagesReportSort = "time_on_page"
+	SortByActiveTime PagesReportSort = "active_time"
+	SortByScroll     PagesReportSort = "scroll_depth"
+)
+
+const defaultPagesOrder = SortByPageViews
+
+// ParsePagesReportSort parses and validates a PagesReportSort. An empty string
+// is parsed as SortByPageViews.
+func ParsePagesReportSort(str string) (PagesReportSort, error) {
+	switch sort := PagesReportSort(str); sort {
+	case "":
+		return defaultPagesOrder, nil
+	case SortByPath, SortByPageViews, SortByVisitors, SortByEntries,
+		SortByExits, SortByExitRate, SortByBounceRate, SortByTimeOnPage,
+		SortByActiveTime, SortByScroll:
+		return sort, nil
+	default:
+		return "", fmt.Errorf("invalid pages report sort column: %q", str)
+	}
+}
+
+// PagesReportOptions defines sorting and pagination options of
+// Service.PagesReport.
+type PagesReportOptions struct {
+	SortBy PagesReportSort
+	Asc    bool
+	Limit  uint64
+	Offset uint64
+}
+
+// PageReport defines engagement metrics of a single page.
+type PageReport struct {
+	Path      string
+	PageViews uint64
+	Visitors  uint64
+	// Number of sessions that started on this page.
+	Entries uint64
+	// Number of sessions that ended on this page.
+	Exits uint64
+	// Exits divided by PageViews.
+	ExitRate float64
+	// Single page sessions divided by Entries.
+	BounceRate float64
+	// Average time in seconds between a view of this page and the next
+	// pageview of the same session. Last pageview of sessions are ignored.
+	AvgTimeOnPage float64
+	// Average active time in seconds per session on this page as reported by
+	// engagement events.
+	AvgActiveTime float64
+	// Average maximum scroll depth in percent per session on this page as
+	// reported by engagement events.
+	AvgScrollDepth float64
+}
+
+// PagesReport implements Service.
+func (s *service) PagesReport(
+	ctx context.Context,
+	filters Filters,
+	opts PagesReportOptions,
+) ([]PageReport, error) {
+	if opts.SortBy == "" {
+		opts.SortBy = defaultPagesOrder
+	}
+	order := "DESC"
+	if opts.Asc {
+		order = "ASC"
+	}
+
+	var b sql.Builder
+
+	b.Strs("WITH views AS (",
+		"  SELECT path, visitor_id, toInt64(timestamp) AS ts,",
+		"  leadInFrame(toNullable(toInt64(timestamp))) OVER (",
+		"    PARTITION BY session_uuid ORDER BY timestamp ASC",
+		"    ROWS BETWEEN CURRENT ROW AND 1 FOLLOWING",
+		"  ) AS next_ts",
+		"  FROM pageviews",
+		"  WHERE session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("),",
+		"sessions_summary AS (",
+		"  SELECT session_uuid, argMax(entry_path, version) AS entry_path,",
+		"  argMax(exit_path, version) AS exit_path,",
+		"  max(version) AS pageview_count",
+		"  FROM sessions",
+		"  WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+		"  GROUP BY session_uuid",
+		"),",
+		"entries AS (",
+		"  SELECT entry_path AS path, COUNT(*) AS entries,",
+		"  countIf(pageview_count = 1) AS bounces",
+		"  FROM sessions_summary",
+		"  GROUP BY path",
+		"),",
+		"exits AS (",
+		"  SELECT exit_path AS path, COUNT(*) AS exits",
+		"  FROM sessions_summary",
+		"  GROUP BY path",
+		"),",
+		"engagement AS (",
+		"  SELECT path, avg(active_time) / 1000 AS active_time,",
+		"  avg(scroll_depth) AS scroll_depth",
+		"  FROM (",
+		"    SELECT path, sum(active_time) AS active_time,",
+		"    max(scroll_depth) AS scroll_depth",
+		"    FROM engagements",
+		"    WHERE session_uuid IN (SELECT session_uuid FROM sessions_summary)",
+		"    GROUP BY session_uuid, path",
+		"  )",
+		"  GROUP BY path",
+		"),",
+		"pages AS (",
+		"  SELECT path, COUNT(*) AS pageviews,",
+		"  COUNT(DISTINCT(visitor_id)) AS visitors,",
+		"  ifNull(avg(next_ts - ts), 0) AS time_on_page",
+		"  FROM views",
+		"  GROUP BY path",
+		")",
+		"SELECT pages.path AS path,",
+		"toUInt64(pages.pageviews) AS pageviews,",
+		"toUInt64(pages.visitors) AS visitors,",
+		"toUInt64(entries.entries) AS entries,",
+		"toUInt64(exits.exits) AS exits,",
+		"toFloat64(exits.exits / pages.pageviews) AS exit_rate,",
+		"toFloat64(if(entries.entries = 0, 0, entries.bounces / entries.entries)) AS bounce_rate,",
+		"toFloat64(pages.time_on_page) AS time_on_page,",
+		"toFloat64(engagement.active_time) AS active_time,",
+		"toFloat64(engagement.scroll_depth) AS scroll_depth",
+		"FROM pages",
+		"LEFT JOIN entries ON entries.path = pages.path",
+		"LEFT JOIN exits ON exits.path = pages.path",
+		"LEFT JOIN engagement ON engagement.path = pages.path",
+	).Fmt("ORDER BY %v %v, path ASC", opts.SortBy, order).
+		Fmt("LIMIT %v OFFSET %v", opts.Limit, opts.Offset)
+
+	query, args := finishQuery(&b)
+
+	result, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %v failed: %w", query, err)
+	}
+
+	report := []PageReport{}
+	for result.Next() {
+		var page PageReport
+		err := result.Scan(
+			&page.Path,
+			&page.PageViews,
+			&page.Visitors,
+			&page.Entries,
+			&page.Exits,
+			&page.ExitRate,
+			&page.BounceRate,
+			&page.AvgTimeOnPage,
+			&page.AvgActiveTime,
+			&page.AvgScrollDepth,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		report = append(report, page)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// TopPageviewPropertyValues implements Service.
+func (s *service) TopPageviewPropertyValues(
+	ctx context.Context,
+	filters Filters,
+	key string,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	// String values are decoded, other values are returned JSON encoded.
+	b.Strs("SELECT if(startsWith(raw_value, '\"'),",
+		"  JSONExtractString(concat('[', raw_value, ']'), 1), raw_value) AS value,",
+		"COUNT(*) AS pageviews",
+		"FROM (").
+		Str("  SELECT values[indexOf(keys, ?)] AS raw_value", key).
+		Str("  FROM pageviews").
+		Str("  WHERE has(keys, ?)", key).
+		Str("  AND session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs(")",
+		"GROUP BY value",
+		"ORDER BY pageviews DESC, value ASC",
+	).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
+
+// pageviewPropertyFilter is the pageviews equivalent of propertyFilter.
+// Pageviews properties are stored as raw JSON values so typed values are
+// derived in query.
+func pageviewPropertyFilter(builder *sql.Builder, args ...any) {
+	filter := args[0].(PropertyFilter)
+
+	builder.Str("session_uuid IN (SELECT session_uuid FROM pageviews WHERE has(keys, ?)", filter.Key)
+
+	// Values are passed as strings and converted in query.
+	switch v := filter.Value.(type) {
+	case string:
+		builder.Str("AND startsWith(values[indexOf(keys, ?)], '\"')", filter.Key).
+			Str("AND JSONExtractString(concat('[', values[indexOf(keys, ?)], ']'), 1)", filter.Key).
+			Str(propertyOperator(filter.Operator)+" ?", v)
+	case float64:
+		builder.Str("AND toFloat64OrNull(values[indexOf(keys, ?)])", filter.Key).
+			Str(propertyOperator(filter.Operator)+" toFloat64(?)", strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		builder.Str("AND values[indexOf(keys, ?)] IN ('true', 'false')", filter.Key).
+			Str("AND (values[indexOf(keys, ?)] = 'true')", filter.Key).
+			Str(propertyOperator(filter.Operator)+" (? = 'true')", strconv.FormatBool(v))
+	default:
+		panic(fmt.Errorf("unsupported property value type: %T", v))
+	}
+
+	builder.Str(")")
+}
@@ -0,0 +1,181 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// PropertyOperator defines a comparison operator used in PropertyFilter.
+type PropertyOperator string
+
+// Supported PropertyOperator.
+const (
+	PropertyEq  PropertyOperator = "eq"
+	PropertyNeq PropertyOperator = "neq"
+	PropertyGt  PropertyOperator = "gt"
+	PropertyGte PropertyOperator = "gte"
+	PropertyLt  PropertyOperator = "lt"
+	PropertyLte PropertyOperator = "lte"
+)
+
+// PropertyFilter defines a comparison between a custom event property and a
+// value. Sessions match the filter if they contain at least one custom event
+// whose property has the same type as Value and satisfies the comparison.
+type PropertyFilter struct {
+	Key      string
+	Operator PropertyOperator
+	// Value is either a string, a float64 or a bool.
+	Value any
+}
+
+// ParsePropertyFilter parses a PropertyFilter of the form
+// "<key>:<operator>:<json value>" (e.g. `price:gt:10` or `plan:eq:"pro"`).
+// Ordering operators are only supported for numbers.
+func ParsePropertyFilter(str string) (PropertyFilter, error) {
+	parts := strings.SplitN(str, ":", 3)
+	if len(parts) != 3 || parts[0] == "" {
+		return PropertyFilter{}, fmt.Errorf("invalid property filter %q: expected <key>:<operator>:<value>", str)
+	}
+
+	filter := PropertyFilter{
+		Key:      parts[0],
+		Operator: PropertyOperator(parts[1]),
+	}
+
+	err := json.Unmarshal([]byte(parts[2]), &filter.Value)
+	if err != nil {
+		return PropertyFilter{}, fmt.Errorf("invalid property filter value %q: %w", parts[2], err)
+	}
+
+	switch filter.Value.(type) {
+	case string, float64, bool:
+	default:
+		return PropertyFilter{}, fmt.Errorf("invalid property filter value %q: only strings, numbers and booleans are supported", parts[2])
+	}
+
+	switch filter.Operator {
+	case PropertyEq, PropertyNeq:
+	case PropertyGt, PropertyGte, PropertyLt, PropertyLte:
+		if _, isNumber := filter.Value.(float64); !isNumber {
+			return PropertyFilter{}, fmt.Errorf("invalid property filter %q: %v operator requires a number", str, filter.Operator)
+		}
+	default:
+		return PropertyFilter{}, fmt.Errorf("invalid property filter operator %q", parts[1])
+	}
+
+	return filter, nil
+}
+
+// PropertyAggregates holds aggregates of a numeric custom event property.
+type PropertyAggregates struct {
+	Count uint64
+	Sum   float64
+	Avg   float64
+	P50   float64
+	P75   float64
+	P90   float64
+}
+
+// CustomProperty implements Service.
+func (s *service) CustomProperty(
+	ctx context.Context,
+	filters Filters,
+	eventName string,
+	key string,
+) (DataFrame[time.Time, PropertyAggregates], error) {
+	var b sql.Builder
+
+	b.Str("SELECT toStartOfInterval(toDateTime(timestamp),").
+		Call(interval, filters.TimeRange).Str(") AS time,").
+		Strs("toUInt64(COUNT(value)),",
+			"sum(value),",
+			"avg(value),",
+			"arrayElement(quantiles(0.5, 0.75, 0.9)(value) AS q, 1), q[2], q[3]",
+			"FROM (").
+		Str("  SELECT timestamp, number_values[indexOf(keys, ?)] AS value", key).
+		Str("  FROM events_custom").
+		Str("  WHERE name = ?", eventName).
+		Str("  AND session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs(")",
+		"WHERE value IS NOT NULL",
+		"GROUP BY time",
+		"ORDER BY time")
+
+	query, args := finishQuery(&b)
+
+	result, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return DataFrame[time.Time, PropertyAggregates]{}, fmt.Errorf("query %v failed: %w", query, err)
+	}
+
+	df := DataFrame[time.Time, PropertyAggregates]{
+		Keys:   []time.Time{},
+		Values: []PropertyAggregates{},
+	}
+	for result.Next() {
+		var t time.Time
+		var v PropertyAggregates
+		err := result.Scan(&t, &v.Count, &v.Sum, &v.Avg, &v.P50, &v.P75, &v.P90)
+		if err != nil {
+			return DataFrame[time.Time, PropertyAggregates]{}, err
+		}
+
+		df.Keys = append(df.Keys, t)
+		df.Values = append(df.Values, v)
+	}
+
+	return df, nil
+}
+
+func propertyFilter(builder *sql.Builder, args ...any) {
+	filter := args[0].(PropertyFilter)
+	operator := propertyOperator(filter.Operator)
+
+	builder.Str("session_uuid IN (SELECT session_uuid FROM events_custom WHERE has(keys, ?)", filter.Key)
+
+	// Values are passed as strings and converted in query.
+	switch v := filter.Value.(type) {
+	case string:
+		builder.Str("AND string_values[indexOf(keys, ?)]", filter.Key).
+			Str(operator+" ?", v)
+	case float64:
+		builder.Str("AND number_values[indexOf(keys, ?)]", filter.Key).
+			Str(operator+" toFloat64(?)", strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		builder.Str("AND bool_values[indexOf(keys, ?)]", filter.Key).
+			Str(operator+" (? = 'true')", strconv.FormatBool(v))
+	default:
+		panic(fmt.Errorf("unsupported property value type: %T", v))
+	}
+
+	builder.Str(")")
+}
+
+// propertyOperator returns SQL comparison operator of given PropertyOperator.
+func propertyOperator(op PropertyOperator) string {
+	switch op {
+	case PropertyEq:
+		return "="
+	case PropertyNeq:
+		return "!="
+	case PropertyGt:
+		return ">"
+	case PropertyGte:
+		return ">="
+	case PropertyLt:
+		return "<"
+	case PropertyLte:
+		return "<="
+	default:
+		panic(fmt.Errorf("unknown property operator: %q", op))
+	}
+}
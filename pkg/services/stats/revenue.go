@@ -0,0 +1,177 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+	"github.com/shopspring/decimal"
+)
+
+// RevenueDimension defines a dimension used to break down revenue.
+type RevenueDimension string
+
+// Supported RevenueDimension.
+const (
+	// Session normalized traffic source (see TopSources).
+	RevenueBySource   RevenueDimension = "source"
+	RevenueByCampaign RevenueDimension = "utm_campaign"
+	RevenueByCountry  RevenueDimension = "country_code"
+	RevenueByPage     RevenueDimension = "path"
+)
+
+// Revenue holds revenue of custom events converted to reporting currency.
+type Revenue struct {
+	Total decimal.Decimal
+	// Number of custom events with a revenue.
+	Orders uint64
+	// Total divided by Orders.
+	AverageOrderValue decimal.Decimal
+}
+
+// Revenue implements Service.
+func (s *service) Revenue(
+	ctx context.Context,
+	filters Filters,
+) (DataFrame[time.Time, Revenue], error) {
+	var b sql.Builder
+
+	b.Str("SELECT toStartOfInterval(toDateTime(timestamp),").
+		Call(interval, filters.TimeRange).Str(") AS time,").
+		Str("toString(sum(").Call(convertedRevenue, s.currency.Rates()).Str(")),").
+		Strs("toUInt64(COUNT(*))",
+			"FROM events_custom",
+			"WHERE").Call(supportedCurrency, s.currency.Rates()).
+		Str("AND session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("GROUP BY time",
+		"ORDER BY time")
+
+	return doRevenueQuery[time.Time](s, ctx, &b)
+}
+
+// RevenueBreakdown implements Service.
+func (s *service) RevenueBreakdown(
+	ctx context.Context,
+	filters Filters,
+	dimension RevenueDimension,
+	limit uint64,
+) (DataFrame[string, Revenue], error) {
+	var b sql.Builder
+
+	var column string
+	switch dimension {
+	case RevenueByPage:
+	case RevenueBySource:
+		column = "argMax(source, pageviews)"
+	case RevenueByCampaign, RevenueByCountry:
+		column = "any(" + string(dimension) + ")"
+	default:
+		return DataFrame[string, Revenue]{}, fmt.Errorf("invalid revenue dimension: %q", dimension)
+	}
+
+	if dimension == RevenueByPage {
+		b.Str("SELECT path AS key,")
+	} else {
+		b.Str("SELECT revenue_sessions.key AS key,")
+	}
+	b.Str("toString(sum(").Call(convertedRevenue, s.currency.Rates()).Str(") AS total),").
+		Strs("toUInt64(COUNT(*))",
+			"FROM events_custom")
+	if dimension != RevenueByPage {
+		b.Strs("LEFT JOIN (",
+			"  SELECT session_uuid, "+column+" AS key",
+			"  FROM sessions",
+			"  WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+			"  GROUP BY session_uuid",
+			") AS revenue_sessions",
+			"ON revenue_sessions.session_uuid = events_custom.session_uuid")
+	}
+
+	b.Str("WHERE").Call(supportedCurrency, s.currency.Rates()).
+		Str("AND events_custom.session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("GROUP BY key",
+		"ORDER BY total DESC, key").
+		Fmt("LIMIT %v", limit)
+
+	return doRevenueQuery[string](s, ctx, &b)
+}
+
+// convertedRevenue adds an expression converting revenue column to reporting
+// currency using given conversion rates.
+func convertedRevenue(builder *sql.Builder, args ...any) {
+	rates := args[0].(map[string]decimal.Decimal)
+
+	builder.Str("toDecimal128(revenue, 4) * multiIf(")
+	for _, code := range slices.Sorted(maps.Keys(rates)) {
+		builder.Str("currency = ?, toDecimal128(?, 8),", code, rates[code].StringFixed(8))
+	}
+	builder.Str("toDecimal128(0, 8))")
+}
+
+// supportedCurrency adds a condition filtering out rows without revenue or
+// with a currency missing from given conversion rates.
+func supportedCurrency(builder *sql.Builder, args ...any) {
+	rates := args[0].(map[string]decimal.Decimal)
+
+	builder.Call(stringListFilter, "currency", slices.Sorted(maps.Keys(rates)))
+}
+
+// doRevenueQuery executes a query returning key, revenue sum in reporting
+// currency and number of orders rows.
+func doRevenueQuery[K comparable](
+	s *service,
+	ctx context.Context,
+	builder *sql.Builder,
+) (DataFrame[K, Revenue], error) {
+	df := DataFrame[K, Revenue]{
+		Keys:   []K{},
+		Values: []Revenue{},
+	}
+
+	query, args := finishQuery(builder)
+
+	result, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return DataFrame[K, Revenue]{}, fmt.Errorf("query %v failed: %w", query, err)
+	}
+
+	for result.Next() {
+		var (
+			k      K
+			sum    string
+			orders uint64
+		)
+		err := result.Scan(&k, &sum, &orders)
+		if err != nil {
+			return DataFrame[K, Revenue]{}, err
+		}
+
+		total, err := decimal.NewFromString(sum)
+		if err != nil {
+			return DataFrame[K, Revenue]{}, fmt.Errorf("invalid revenue sum %q: %w", sum, err)
+		}
+
+		revenue := Revenue{
+			Total:  total.Round(4),
+			Orders: orders,
+		}
+		if orders > 0 {
+			revenue.AverageOrderValue = total.
+				Div(decimal.NewFromUint64(orders)).Round(4)
+		}
+
+		df.Keys = append(df.Keys, k)
+		df.Values = append(df.Values, revenue)
+	}
+
+	return df, nil
+}
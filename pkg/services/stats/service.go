@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/prismelabs/analytics/pkg/event"
+	"github.com/prismelabs/analytics/pkg/services/currency"
 	"github.com/prismelabs/analytics/pkg/services/eventdb"
 	"github.com/prismelabs/analytics/pkg/services/teardown"
 	"github.com/prismelabs/analytics/pkg/sql"
@@ -15,6 +18,8 @@ import (
 // Service define a statistics service.
 type Service interface {
 	Visitors(context.Context, Filters) (DataFrame[time.Time, uint64], error)
+	NewVisitors(context.Context, Filters) (DataFrame[time.Time, uint64], error)
+	ReturningVisitors(context.Context, Filters) (DataFrame[time.Time, uint64], error)
 	Sessions(context.Context, Filters) (DataFrame[time.Time, uint64], error)
 	SessionsDuration(context.Context, Filters) (DataFrame[time.Time, uint64], error)
 	PageViews(context.Context, Filters) (DataFrame[time.Time, uint64], error)
@@ -24,14 +29,47 @@ type Service interface {
 	TopEntryPages(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopExitPages(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopReferrers(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	TopSources(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	TopChannels(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopUtmSources(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopUtmMediums(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopUtmCampaigns(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopCountries(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopBrowsers(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 	TopOperatingSystems(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	NewVsReturning(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	PagesReport(context.Context, Filters, PagesReportOptions) ([]PageReport, error)
+	WebVitals(context.Context, Filters, event.WebVitalMetric) (DataFrame[time.Time, Percentiles], error)
+	WebVitalsBreakdown(context.Context, Filters, event.WebVitalMetric, WebVitalsDimension, uint64) (DataFrame[string, Percentiles], error)
+	TopErrors(context.Context, Filters, uint64) ([]ErrorReport, error)
+	ErrorRate(context.Context, Filters) (DataFrame[time.Time, float64], error)
+	Revenue(context.Context, Filters) (DataFrame[time.Time, Revenue], error)
+	RevenueBreakdown(context.Context, Filters, RevenueDimension, uint64) (DataFrame[string, Revenue], error)
+	CustomProperty(ctx context.Context, filters Filters, eventName, key string) (DataFrame[time.Time, PropertyAggregates], error)
+	TopPageviewPropertyValues(ctx context.Context, filters Filters, key string, limit uint64) (DataFrame[string, uint64], error)
+	TopSearchTerms(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	SearchesPerSession(context.Context, Filters) (DataFrame[time.Time, float64], error)
+	TopSearchExits(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	BotPageViews(context.Context, Filters) (DataFrame[time.Time, uint64], error)
+	TopBots(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	TopCrawledPages(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	TopAppVersions(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
+	TopOsVersions(context.Context, Filters, uint64) (DataFrame[string, uint64], error)
 }
 
+// Visitor types supported by Filters.VisitorType and returned by
+// Service.NewVsReturning.
+//
+// A visitor is new if its first session for a domain falls inside filters time
+// range and returning otherwise. Anonymous visitor IDs are derived from a daily
+// salt so an anonymous visitor coming back another day is seen as a new
+// visitor. Only visitors identified using X-Prisme-Visitor-Id header are
+// reliably tracked across days.
+const (
+	NewVisitorType       = "new"
+	ReturningVisitorType = "returning"
+)
+
 // DataFrame defines a columnar view over timestamped data.
 type DataFrame[K, V any] struct {
 	Keys   []K
@@ -52,6 +90,8 @@ type Filters struct {
 	EntryPath       []string
 	ExitPath        []string
 	Referrers       []string
+	Sources         []string
+	Channels        []string
 	OperatingSystem []string
 	BrowserFamily   []string
 	Country         []string
@@ -60,19 +100,32 @@ type Filters struct {
 	UtmCampaign     []string
 	UtmTerm         []string
 	UtmContent      []string
+	VisitorType     []string
+	// AppVersion and OsVersion filter sessions of native apps. Apps ids are
+	// domains.
+	AppVersion []string
+	OsVersion  []string
+	// Properties filters sessions by their custom events properties.
+	Properties []PropertyFilter
+	// PageviewProperties filters sessions by their pageviews properties.
+	PageviewProperties []PropertyFilter
+	// Bots filters bot pageviews by bot family. It doesn't apply to sessions.
+	Bots []string
 }
 
 type service struct {
 	db        eventdb.Service
+	currency  currency.Service
 	tmpTables sync.Map
 }
 
 // NewService returns a new Service.
 func NewService(
 	db eventdb.Service,
+	currency currency.Service,
 	teardown teardown.Service,
 ) Service {
-	srv := &service{db: db, tmpTables: sync.Map{}}
+	srv := &service{db: db, currency: currency, tmpTables: sync.Map{}}
 	teardown.RegisterProcedure(func() error {
 		var errs []error
 
@@ -177,17 +230,31 @@ func (s *service) SessionsDuration(
 ) (DataFrame[time.Time, uint64], error) {
 	var b sql.Builder
 
+	// Session duration is the time between first and last pageview or the
+	// total active time reported by engagement events if greater. Without
+	// the latter, single page sessions always last 0 seconds.
 	b.Strs("WITH sessions_duration AS (",
-		"  SELECT toStartOfInterval(toDateTime(session_timestamp),").
+		"  SELECT session_uuid, toStartOfInterval(toDateTime(session_timestamp),").
 		Call(interval, filters.TimeRange).Str(") AS time,").
 		Strs("argMax(session_timestamp, pageviews) as session_timestamp,",
 			"argMax(exit_timestamp, pageviews) AS exit_timestamp",
 			"FROM sessions",
 			"WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
 		"  GROUP BY session_uuid",
+		"),",
+		"sessions_engagement AS (",
+		"  SELECT session_uuid, intDiv(sum(active_time), 1000) AS active_time",
+		"  FROM engagements",
+		"  WHERE session_uuid IN (SELECT session_uuid FROM sessions_duration)",
+		"  GROUP BY session_uuid",
 		")").
-		Strs("SELECT time, toUInt64(avg(exit_timestamp - session_timestamp))",
+		Strs("SELECT time, toUInt64(avg(greatest(",
+			"  toUInt64(exit_timestamp - session_timestamp),",
+			"  toUInt64(sessions_engagement.active_time)",
+			")))",
 			"FROM sessions_duration",
+			"LEFT JOIN sessions_engagement",
+			"ON sessions_engagement.session_uuid = sessions_duration.session_uuid",
 			"GROUP BY time",
 			"ORDER BY time")
 
@@ -212,6 +279,32 @@ func (s *service) Visitors(
 	return doQuery[time.Time](s.db, ctx, &b)
 }
 
+// NewVisitors implements Service.
+func (s *service) NewVisitors(
+	ctx context.Context,
+	filters Filters,
+) (DataFrame[time.Time, uint64], error) {
+	filters, ok := withVisitorType(filters, NewVisitorType)
+	if !ok {
+		return DataFrame[time.Time, uint64]{Keys: []time.Time{}, Values: []uint64{}}, nil
+	}
+
+	return s.Visitors(ctx, filters)
+}
+
+// ReturningVisitors implements Service.
+func (s *service) ReturningVisitors(
+	ctx context.Context,
+	filters Filters,
+) (DataFrame[time.Time, uint64], error) {
+	filters, ok := withVisitorType(filters, ReturningVisitorType)
+	if !ok {
+		return DataFrame[time.Time, uint64]{Keys: []time.Time{}, Values: []uint64{}}, nil
+	}
+
+	return s.Visitors(ctx, filters)
+}
+
 // TopPages implements Service.
 func (s *service) TopPages(
 	ctx context.Context,
@@ -306,6 +399,54 @@ func (s *service) TopReferrers(
 	return doQuery[string](s.db, ctx, &b)
 }
 
+// TopSources implements Service.
+func (s *service) TopSources(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Strs(
+		"WITH sources AS (",
+		"  SELECT argMax(source, pageviews) AS source",
+		"  FROM sessions",
+		"  WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+		"  GROUP BY session_uuid",
+		")",
+		"SELECT source, COUNT(*) AS sessions",
+		"FROM sources",
+		"GROUP BY source",
+		"ORDER BY sessions DESC",
+	).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
+
+// TopChannels implements Service.
+func (s *service) TopChannels(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Strs(
+		"WITH channels AS (",
+		"  SELECT argMax(channel, pageviews) AS channel",
+		"  FROM sessions",
+		"  WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+		"  GROUP BY session_uuid",
+		")",
+		"SELECT channel, COUNT(*) AS sessions",
+		"FROM channels",
+		"GROUP BY channel",
+		"ORDER BY sessions DESC",
+	).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
+
 // TopUtmSources implements Service.
 func (s *service) TopUtmSources(
 	ctx context.Context,
@@ -457,6 +598,27 @@ func (s *service) TopOperatingSystems(
 	return doQuery[string](s.db, ctx, &b)
 }
 
+// NewVsReturning implements Service.
+func (s *service) NewVsReturning(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Str("SELECT if((domain, visitor_id) IN (").Call(returningVisitors, filters).
+		Str("), ?, ?) AS visitor_type,", ReturningVisitorType, NewVisitorType).
+		Strs(
+			"COUNT(DISTINCT(visitor_id)) AS visitors",
+			"FROM sessions",
+			"WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+		"GROUP BY visitor_type",
+		"ORDER BY visitors DESC",
+	).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
+
 func interval(builder *sql.Builder, args ...any) {
 	timeRange := args[0].(TimeRange)
 
@@ -474,24 +636,32 @@ func doQuery[K any](
 	ctx context.Context,
 	builder *sql.Builder,
 ) (DataFrame[K, uint64], error) {
-	df := DataFrame[K, uint64]{
+	return doTypedQuery[K, uint64](db, ctx, builder)
+}
+
+func doTypedQuery[K, V any](
+	db eventdb.Service,
+	ctx context.Context,
+	builder *sql.Builder,
+) (DataFrame[K, V], error) {
+	df := DataFrame[K, V]{
 		Keys:   []K{},
-		Values: []uint64{},
+		Values: []V{},
 	}
 
-	query, args := builder.Finish()
+	query, args := finishQuery(builder)
 
 	result, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return DataFrame[K, uint64]{}, fmt.Errorf("query %v failed: %w", query, err)
+		return DataFrame[K, V]{}, fmt.Errorf("query %v failed: %w", query, err)
 	}
 
 	for result.Next() {
 		var k K
-		var v uint64
+		var v V
 		err := result.Scan(&k, &v)
 		if err != nil {
-			return DataFrame[K, uint64]{}, err
+			return DataFrame[K, V]{}, err
 		}
 
 		df.Keys = append(df.Keys, k)
@@ -501,6 +671,14 @@ func doQuery[K any](
 	return df, nil
 }
 
+// finishQuery finishes given top level query. Queries are executed with FINAL
+// applied to every table so events sharing the same event id and cancelled
+// sessions rows are merged at query time instead of being counted until
+// background merges.
+func finishQuery(builder *sql.Builder) (string, []any) {
+	return builder.Str("SETTINGS final = 1").Finish()
+}
+
 func sessionQuery(builder *sql.Builder, args ...any) {
 	var (
 		sub     sql.Builder
@@ -523,6 +701,12 @@ func sessionQuery(builder *sql.Builder, args ...any) {
 	if len(filters.Referrers) > 0 {
 		sub.Str("AND").Call(stringListFilter, "referrer_domain", filters.Referrers)
 	}
+	if len(filters.Sources) > 0 {
+		sub.Str("AND").Call(stringListFilter, "source", filters.Sources)
+	}
+	if len(filters.Channels) > 0 {
+		sub.Str("AND").Call(stringListFilter, "channel", filters.Channels)
+	}
 	if len(filters.OperatingSystem) > 0 {
 		sub.Str("AND").Call(stringListFilter, "operating_system", filters.OperatingSystem)
 	}
@@ -547,6 +731,21 @@ func sessionQuery(builder *sql.Builder, args ...any) {
 	if len(filters.UtmContent) > 0 {
 		sub.Str("AND").Call(stringListFilter, "utm_content", filters.UtmContent)
 	}
+	if len(filters.VisitorType) > 0 {
+		sub.Str("AND").Call(visitorTypeFilter, filters)
+	}
+	if len(filters.AppVersion) > 0 {
+		sub.Str("AND").Call(stringListFilter, "app_version", filters.AppVersion)
+	}
+	if len(filters.OsVersion) > 0 {
+		sub.Str("AND").Call(stringListFilter, "os_version", filters.OsVersion)
+	}
+	for _, property := range filters.Properties {
+		sub.Str("AND").Call(propertyFilter, property)
+	}
+	for _, property := range filters.PageviewProperties {
+		sub.Str("AND").Call(pageviewPropertyFilter, property)
+	}
 
 	if len(filters.Path) > 0 {
 		query, args := sub.Finish()
@@ -605,3 +804,52 @@ func stringListFilter(builder *sql.Builder, args ...any) {
 	}
 	builder.Str(")")
 }
+
+// visitorTypeFilter filters sessions of new or returning visitors. A visitor is
+// new if it has no session for the domain before filters time range.
+func visitorTypeFilter(builder *sql.Builder, args ...any) {
+	filters := args[0].(Filters)
+
+	isNew := slices.Contains(filters.VisitorType, NewVisitorType)
+	isReturning := slices.Contains(filters.VisitorType, ReturningVisitorType)
+	if isNew == isReturning {
+		builder.Str("1 = 1")
+		return
+	}
+
+	builder.Str("(domain, visitor_id)")
+	if isNew {
+		builder.Str("NOT")
+	}
+	builder.Str("IN (").Call(returningVisitors, filters).Str(")")
+}
+
+// returningVisitors adds a query selecting domain and visitor id of visitors
+// with a session before filters time range. Query is restricted to filtered
+// domains so it doesn't scan sessions of other domains. Without time range,
+// all visitors are new.
+func returningVisitors(builder *sql.Builder, args ...any) {
+	filters := args[0].(Filters)
+
+	builder.Str("SELECT domain, visitor_id FROM sessions WHERE")
+	if (filters.TimeRange == TimeRange{}) {
+		builder.Str("1 = 0")
+		return
+	}
+
+	builder.Str("session_timestamp < toDateTime(?)", filters.TimeRange.Start.Format(time.DateTime))
+	if len(filters.Domain) > 0 {
+		builder.Str("AND").Call(stringListFilter, "domain", filters.Domain)
+	}
+}
+
+// withVisitorType restricts filters to the given visitor type. Returned boolean
+// is false if filters already excludes visitor type.
+func withVisitorType(filters Filters, visitorType string) (Filters, bool) {
+	if len(filters.VisitorType) > 0 && !slices.Contains(filters.VisitorType, visitorType) {
+		return filters, false
+	}
+
+	filters.VisitorType = []string{visitorType}
+	return filters, true
+}
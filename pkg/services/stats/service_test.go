@@ -5,15 +5,23 @@ package stats
 import (
 	"context"
 	"io"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/prismelabs/analytics/pkg/dataview"
+	"github.com/prismelabs/analytics/pkg/event"
 	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/services/currency"
 	"github.com/prismelabs/analytics/pkg/services/eventdb"
 	"github.com/prismelabs/analytics/pkg/services/eventstore"
+	"github.com/prismelabs/analytics/pkg/services/referrerparser"
 	"github.com/prismelabs/analytics/pkg/services/teardown"
+	"github.com/prismelabs/analytics/pkg/testutils"
 	"github.com/prismelabs/analytics/pkg/testutils/faker"
+	"github.com/prismelabs/analytics/pkg/uri"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,8 +59,13 @@ func TestIntegNoRaceDetectorService(t *testing.T) {
 					promRegistry,
 					teardown,
 				)
-				stats = NewService(db, teardown)
 				require.NoError(t, err)
+				currencies, err := currency.NewService(currency.Config{
+					Reporting: "USD",
+					Rates:     []string{"EUR=2"},
+				}, log.New("stats-test", io.Discard, false))
+				require.NoError(t, err)
+				stats = NewService(db, currencies, teardown)
 				test(t)
 				require.NoError(t, teardown.Teardown())
 			})
@@ -127,6 +140,575 @@ func TestIntegNoRaceDetectorService(t *testing.T) {
 			require.EqualValues(t, 1, sum(df.Values))
 		})
 	})
+
+	t.Run("NewVsReturning", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			now := time.Now()
+			filters := Filters{
+				TimeRange: TimeRange{
+					Start: now.Add(-time.Hour),
+					Dur:   2 * time.Hour,
+				},
+			}
+
+			df, err := stats.NewVsReturning(ctx, filters, 10)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			// Returning visitor with a session two days ago and one now.
+			returning := faker.Session()
+			returning.SessionUuid = faker.UuidV7(now.Add(-48 * time.Hour))
+			returning.PageviewCount++
+			pv := faker.PageView(returning)
+			require.NoError(t, store.StorePageView(ctx, &pv))
+
+			returning.SessionUuid = faker.UuidV7(now)
+			pv = faker.PageView(returning)
+			require.NoError(t, store.StorePageView(ctx, &pv))
+
+			// New visitor.
+			{
+				session := faker.Session()
+				session.SessionUuid = faker.UuidV7(now)
+				session.PageviewCount++
+				pv := faker.PageView(session)
+				require.NoError(t, store.StorePageView(ctx, &pv))
+			}
+
+			time.Sleep(time.Second)
+
+			df, err = stats.NewVsReturning(ctx, filters, 10)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{NewVisitorType, ReturningVisitorType}, df.Keys)
+			require.EqualValues(t, []uint64{1, 1}, df.Values)
+
+			ts, err := stats.NewVisitors(ctx, filters)
+			require.NoError(t, err)
+			require.EqualValues(t, 1, sum(ts.Values))
+
+			ts, err = stats.ReturningVisitors(ctx, filters)
+			require.NoError(t, err)
+			require.EqualValues(t, 1, sum(ts.Values))
+
+			filters.VisitorType = []string{ReturningVisitorType}
+			ts, err = stats.NewVisitors(ctx, filters)
+			require.NoError(t, err)
+			require.Len(t, ts.Keys, 0)
+
+			ts, err = stats.Sessions(ctx, filters)
+			require.NoError(t, err)
+			require.EqualValues(t, 1, sum(ts.Values))
+		})
+	})
+
+	t.Run("PagesReport", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			report, err := stats.PagesReport(ctx, Filters{}, PagesReportOptions{Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, report, 0)
+
+			now := time.Now()
+			entry := testutils.Must(uri.Parse)("https://example.com/entry")
+			exit := testutils.Must(uri.Parse)("https://example.com/exit")
+
+			// Session 1: /entry -> /exit, one minute on /entry.
+			session := faker.Session()
+			session.PageUri = entry
+			session.SessionUuid = faker.UuidV7(now)
+			session.PageviewCount++
+			pv := faker.PageView(session)
+			pv.PageUri = entry
+			require.NoError(t, store.StorePageView(ctx, &pv))
+			session.PageviewCount++
+			pv = faker.PageView(session)
+			pv.PageUri = exit
+			require.NoError(t, store.StorePageView(ctx, &pv))
+
+			// Session 2: bounce on /entry with 90 seconds of engagement.
+			session = faker.Session()
+			session.PageUri = entry
+			session.SessionUuid = faker.UuidV7(now)
+			session.PageviewCount++
+			pv = faker.PageView(session)
+			pv.PageUri = entry
+			pv.Timestamp = session.SessionTime()
+			require.NoError(t, store.StorePageView(ctx, &pv))
+			engagement := faker.Engagement(session)
+			engagement.PageUri = entry
+			engagement.ActiveTime = 90 * time.Second
+			engagement.ScrollDepth = 80
+			require.NoError(t, store.StoreEngagement(ctx, &engagement))
+
+			time.Sleep(time.Second)
+
+			report, err = stats.PagesReport(ctx, Filters{}, PagesReportOptions{
+				SortBy: SortByPath,
+				Asc:    true,
+				Limit:  10,
+			})
+			require.NoError(t, err)
+			require.Equal(t, []PageReport{
+				{
+					Path:           "/entry",
+					PageViews:      2,
+					Visitors:       2,
+					Entries:        2,
+					Exits:          1,
+					ExitRate:       0.5,
+					BounceRate:     0.5,
+					AvgTimeOnPage:  60,
+					AvgActiveTime:  90,
+					AvgScrollDepth: 80,
+				},
+				{
+					Path:          "/exit",
+					PageViews:     1,
+					Visitors:      1,
+					Entries:       0,
+					Exits:         1,
+					ExitRate:      1,
+					BounceRate:    0,
+					AvgTimeOnPage: 0,
+				},
+			}, report)
+
+			// Pagination.
+			report, err = stats.PagesReport(ctx, Filters{}, PagesReportOptions{
+				SortBy: SortByPageViews,
+				Limit:  1,
+				Offset: 1,
+			})
+			require.NoError(t, err)
+			require.Len(t, report, 1)
+			require.Equal(t, "/exit", report[0].Path)
+
+			// Session 1 lasts 120 seconds (2 pageviews) and session 2 lasts 90
+			// seconds (engagement).
+			df, err := stats.SessionsDuration(ctx, Filters{})
+			require.NoError(t, err)
+			require.EqualValues(t, 105, sum(df.Values))
+		})
+	})
+
+	t.Run("WebVitals", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.WebVitals(ctx, Filters{}, event.LcpWebVital)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			now := time.Now()
+			session := faker.Session()
+			session.SessionUuid = faker.UuidV7(now)
+			session.PageviewCount++
+			pv := faker.PageView(session)
+			require.NoError(t, store.StorePageView(ctx, &pv))
+
+			for _, value := range []float64{100, 200, 300, 400} {
+				ev := faker.WebVital(session)
+				ev.PageUri = testutils.Must(uri.Parse)("https://example.com/vitals")
+				ev.Metric = event.LcpWebVital
+				ev.Value = value
+				require.NoError(t, store.StoreWebVital(ctx, &ev))
+			}
+			ev := faker.WebVital(session)
+			ev.Metric = event.ClsWebVital
+			ev.Value = 0.1
+			require.NoError(t, store.StoreWebVital(ctx, &ev))
+
+			time.Sleep(time.Second)
+
+			df, err = stats.WebVitals(ctx, Filters{}, event.LcpWebVital)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 1)
+			require.EqualValues(t, 4, df.Values[0].Count)
+			require.InDelta(t, 325, df.Values[0].P75, 50)
+			require.InDelta(t, 370, df.Values[0].P90, 50)
+
+			pages, err := stats.WebVitalsBreakdown(ctx, Filters{}, event.LcpWebVital, WebVitalsByPage, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"/vitals"}, pages.Keys)
+
+			browsers, err := stats.WebVitalsBreakdown(ctx, Filters{}, event.ClsWebVital, WebVitalsByBrowser, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{session.Client.BrowserFamily}, browsers.Keys)
+			require.EqualValues(t, 1, browsers.Values[0].Count)
+
+			countries, err := stats.WebVitalsBreakdown(ctx, Filters{}, event.LcpWebVital, WebVitalsByCountry, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{session.CountryCode.String()}, countries.Keys)
+		})
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			report, err := stats.TopErrors(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Len(t, report, 0)
+
+			now := time.Now()
+			var sessions [2]event.Session
+			for i := range sessions {
+				sessions[i] = faker.Session()
+				sessions[i].SessionUuid = faker.UuidV7(now)
+				sessions[i].PageviewCount++
+				pv := faker.PageView(sessions[i])
+				require.NoError(t, store.StorePageView(ctx, &pv))
+			}
+
+			// Same error twice in first session.
+			ev := faker.Error(sessions[0])
+			for range 2 {
+				require.NoError(t, store.StoreError(ctx, &ev))
+			}
+
+			time.Sleep(time.Second)
+
+			report, err = stats.TopErrors(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Len(t, report, 1)
+			require.Equal(t, ev.Fingerprint, report[0].Fingerprint)
+			require.Equal(t, ev.Message, report[0].Message)
+			require.EqualValues(t, 2, report[0].Occurrences)
+			require.EqualValues(t, 1, report[0].Sessions)
+
+			df, err := stats.ErrorRate(ctx, Filters{})
+			require.NoError(t, err)
+			require.Len(t, df.Values, 1)
+			require.InDelta(t, 0.5, df.Values[0], 0.001)
+		})
+	})
+
+	t.Run("Revenue", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.Revenue(ctx, Filters{})
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			now := time.Now()
+			session := faker.Session()
+			session.SessionUuid = faker.UuidV7(now)
+			session.Source = referrerparser.Source{Name: "Google", Channel: referrerparser.SearchChannel}
+			session.PageviewCount++
+			pv := faker.PageView(session)
+			require.NoError(t, store.StorePageView(ctx, &pv))
+
+			// Custom event without revenue.
+			ev := faker.CustomEvent(session)
+			require.NoError(t, store.StoreCustom(ctx, &ev))
+
+			// Purchases.
+			for _, revenue := range [][2]string{{"10.50", "USD"}, {"20", "EUR"}} {
+				ev := faker.CustomEvent(session)
+				ev.PageUri = testutils.Must(uri.Parse)("https://example.com/checkout")
+				ev.Name = "purchase"
+				ev.Revenue = decimal.RequireFromString(revenue[0])
+				ev.Currency = revenue[1]
+				require.NoError(t, store.StoreCustom(ctx, &ev))
+			}
+
+			time.Sleep(time.Second)
+
+			df, err = stats.Revenue(ctx, Filters{})
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 1)
+			require.Equal(t, "50.5", df.Values[0].Total.String())
+			require.EqualValues(t, 2, df.Values[0].Orders)
+			require.Equal(t, "25.25", df.Values[0].AverageOrderValue.String())
+
+			pages, err := stats.RevenueBreakdown(ctx, Filters{}, RevenueByPage, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"/checkout"}, pages.Keys)
+			require.Equal(t, "50.5", pages.Values[0].Total.String())
+
+			countries, err := stats.RevenueBreakdown(ctx, Filters{}, RevenueByCountry, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{session.CountryCode.String()}, countries.Keys)
+
+			campaigns, err := stats.RevenueBreakdown(ctx, Filters{}, RevenueByCampaign, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{session.Utm.Campaign}, campaigns.Keys)
+
+			sources, err := stats.RevenueBreakdown(ctx, Filters{}, RevenueBySource, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"Google"}, sources.Keys)
+			require.EqualValues(t, 2, sources.Values[0].Orders)
+
+			// Limit is applied.
+			pages, err = stats.RevenueBreakdown(ctx, Filters{}, RevenueByPage, 0)
+			require.NoError(t, err)
+			require.Len(t, pages.Keys, 0)
+		})
+	})
+
+	t.Run("CustomProperty", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.CustomProperty(ctx, Filters{}, "purchase", "quantity")
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			now := time.Now()
+			var sessions [2]event.Session
+			for i := range sessions {
+				sessions[i] = faker.Session()
+				sessions[i].SessionUuid = faker.UuidV7(now)
+				sessions[i].PageviewCount++
+				pv := faker.PageView(sessions[i])
+				require.NoError(t, store.StorePageView(ctx, &pv))
+			}
+
+			for i, quantity := range []string{"1", "2", "6", `"3"`} {
+				ev := faker.CustomEvent(sessions[i%2])
+				ev.Name = "purchase"
+				ev.Keys = []string{"quantity", "plan"}
+				ev.Values = []string{quantity, `"pro"`}
+				ev.Types = []dataview.ValueType{
+					dataview.ValueTypeOf([]byte(quantity)),
+					dataview.StringValue,
+				}
+				if i%2 == 1 {
+					ev.Values[1] = `"free"`
+				}
+				require.NoError(t, store.StoreCustom(ctx, &ev))
+			}
+
+			time.Sleep(time.Second)
+
+			// String value "3" is ignored.
+			df, err = stats.CustomProperty(ctx, Filters{}, "purchase", "quantity")
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 1)
+			require.EqualValues(t, 3, df.Values[0].Count)
+			require.Equal(t, 9.0, df.Values[0].Sum)
+			require.Equal(t, 3.0, df.Values[0].Avg)
+
+			filter, err := ParsePropertyFilter(`plan:eq:"pro"`)
+			require.NoError(t, err)
+			df, err = stats.CustomProperty(ctx, Filters{Properties: []PropertyFilter{filter}}, "purchase", "quantity")
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 1)
+			require.EqualValues(t, 2, df.Values[0].Count)
+			require.Equal(t, 7.0, df.Values[0].Sum)
+
+			filter, err = ParsePropertyFilter("quantity:gt:5")
+			require.NoError(t, err)
+			sessionsDf, err := stats.Sessions(ctx, Filters{Properties: []PropertyFilter{filter}})
+			require.NoError(t, err)
+			require.EqualValues(t, 1, sum(sessionsDf.Values))
+
+			_, err = ParsePropertyFilter(`plan:gt:"pro"`)
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("PageviewProperties", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.TopPageviewPropertyValues(ctx, Filters{}, "author", 10)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			now := time.Now()
+			for i, author := range []string{`"alice"`, `"bob"`, `"alice"`} {
+				session := faker.Session()
+				session.SessionUuid = faker.UuidV7(now)
+				session.PageviewCount++
+				pv := faker.PageView(session)
+				pv.Keys = []string{"author", "logged_in"}
+				pv.Values = []string{author, strconv.FormatBool(i == 0)}
+				require.NoError(t, store.StorePageView(ctx, &pv))
+			}
+
+			time.Sleep(time.Second)
+
+			df, err = stats.TopPageviewPropertyValues(ctx, Filters{}, "author", 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"alice", "bob"}, df.Keys)
+			require.Equal(t, []uint64{2, 1}, df.Values)
+
+			filter, err := ParsePropertyFilter("logged_in:eq:true")
+			require.NoError(t, err)
+			filters := Filters{PageviewProperties: []PropertyFilter{filter}}
+			df, err = stats.TopPageviewPropertyValues(ctx, filters, "author", 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"alice"}, df.Keys)
+			require.Equal(t, []uint64{1}, df.Values)
+
+			filter, err = ParsePropertyFilter(`author:neq:"alice"`)
+			require.NoError(t, err)
+			sessionsDf, err := stats.Sessions(ctx, Filters{PageviewProperties: []PropertyFilter{filter}})
+			require.NoError(t, err)
+			require.EqualValues(t, 1, sum(sessionsDf.Values))
+		})
+	})
+
+	t.Run("SourcesAndChannels", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.TopChannels(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			now := time.Now()
+			sources := []referrerparser.Source{
+				{Name: "Google", Channel: referrerparser.SearchChannel},
+				{Name: "Google", Channel: referrerparser.PaidChannel},
+				{Name: "Facebook", Channel: referrerparser.SocialChannel},
+				{Name: "Google", Channel: referrerparser.SearchChannel},
+			}
+			for _, source := range sources {
+				session := faker.Session()
+				session.SessionUuid = faker.UuidV7(now)
+				session.Source = source
+				session.PageviewCount++
+				pv := faker.PageView(session)
+				require.NoError(t, store.StorePageView(ctx, &pv))
+			}
+
+			time.Sleep(time.Second)
+
+			df, err = stats.TopSources(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"Google", "Facebook"}, df.Keys)
+			require.Equal(t, []uint64{3, 1}, df.Values)
+
+			df, err = stats.TopChannels(ctx, Filters{Sources: []string{"Google"}}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"search", "paid"}, df.Keys)
+			require.Equal(t, []uint64{2, 1}, df.Values)
+
+			df, err = stats.TopSources(ctx, Filters{Channels: []string{"social"}}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"Facebook"}, df.Keys)
+			require.Equal(t, []uint64{1}, df.Values)
+		})
+	})
+
+	t.Run("SiteSearch", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.TopSearchTerms(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			// First session searches "shoes" then "Hats" and leaves, second
+			// session searches "shoes" and views a product.
+			now := time.Now()
+			for _, terms := range [][]string{{"shoes", "Hats"}, {"shoes", ""}} {
+				session := faker.Session()
+				session.SessionUuid = faker.UuidV7(now)
+				for _, term := range terms {
+					session.PageviewCount++
+					pv := faker.PageView(session)
+					pv.SearchTerm = term
+					require.NoError(t, store.StorePageView(ctx, &pv))
+				}
+			}
+
+			time.Sleep(time.Second)
+
+			df, err = stats.TopSearchTerms(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"shoes", "hats"}, df.Keys)
+			require.Equal(t, []uint64{2, 1}, df.Values)
+
+			perSession, err := stats.SearchesPerSession(ctx, Filters{})
+			require.NoError(t, err)
+			require.Len(t, perSession.Values, 1)
+			require.Equal(t, 1.5, perSession.Values[0])
+
+			df, err = stats.TopSearchExits(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"hats"}, df.Keys)
+			require.Equal(t, []uint64{1}, df.Values)
+		})
+	})
+
+	t.Run("Bots", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.TopBots(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			// A human session and bot pageviews.
+			session := faker.Session()
+			pv := faker.PageView(session)
+			require.NoError(t, store.StorePageView(ctx, &pv))
+
+			now := time.Now().UTC()
+			for _, bot := range []struct{ family, path string }{
+				{"Googlebot", "/docs"},
+				{"Googlebot", "/docs/install"},
+				{"GPTBot", "/docs"},
+			} {
+				require.NoError(t, store.StoreBotPageView(ctx, &event.BotPageView{
+					Timestamp: now,
+					PageUri:   testutils.Must(uri.Parse)("https://mywebsite.localhost" + bot.path),
+					BotFamily: bot.family,
+					Status:    200,
+				}))
+			}
+
+			time.Sleep(time.Second)
+
+			df, err = stats.TopBots(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"Googlebot", "GPTBot"}, df.Keys)
+			require.Equal(t, []uint64{2, 1}, df.Values)
+
+			df, err = stats.TopCrawledPages(ctx, Filters{Bots: []string{"Googlebot"}}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"/docs", "/docs/install"}, df.Keys)
+			require.Equal(t, []uint64{1, 1}, df.Values)
+
+			timeDf, err := stats.BotPageViews(ctx, Filters{})
+			require.NoError(t, err)
+			require.Equal(t, uint64(3), sum(timeDf.Values))
+
+			// Bot pageviews aren't part of human metrics.
+			timeDf, err = stats.PageViews(ctx, Filters{})
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), sum(timeDf.Values))
+		})
+	})
+
+	t.Run("Apps", func(t *testing.T) {
+		forEachEventStoreBackend(t, func(t *testing.T) {
+			df, err := stats.TopAppVersions(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Len(t, df.Keys, 0)
+
+			// A web session and app sessions.
+			session := faker.Session()
+			session.PageviewCount = 1
+			pv := faker.PageView(session)
+			require.NoError(t, store.StorePageView(ctx, &pv))
+
+			for _, versions := range [][2]string{{"1.2.0", "17.4"}, {"1.2.0", "18.0"}, {"1.1.0", "18.0"}} {
+				session := faker.Session()
+				session.PageUri = testutils.Must(uri.Parse)("app://com.example.ios/Home")
+				session.AppVersion = versions[0]
+				session.OsVersion = versions[1]
+				session.PageviewCount = 1
+				pv := faker.PageView(session)
+				pv.PageUri = session.PageUri
+				require.NoError(t, store.StorePageView(ctx, &pv))
+			}
+
+			time.Sleep(time.Second)
+
+			df, err = stats.TopAppVersions(ctx, Filters{}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"1.2.0", "1.1.0"}, df.Keys)
+			require.Equal(t, []uint64{2, 1}, df.Values)
+
+			df, err = stats.TopOsVersions(ctx, Filters{AppVersion: []string{"1.2.0"}}, 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"17.4", "18.0"}, df.Keys)
+			require.Equal(t, []uint64{1, 1}, df.Values)
+
+			// Apps are domains.
+			timeDf, err := stats.Sessions(ctx, Filters{Domain: []string{"com.example.ios"}})
+			require.NoError(t, err)
+			require.Equal(t, uint64(3), sum(timeDf.Values))
+		})
+	})
 }
 
 func sum(s []uint64) uint64 {
@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// TopSearchTerms implements Service. Search terms are case insensitive.
+func (s *service) TopSearchTerms(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Strs("SELECT lower(search_term) AS term, COUNT(*) AS searches",
+		"FROM pageviews",
+		"WHERE search_term != ''",
+		"AND session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("GROUP BY term",
+		"ORDER BY searches DESC, term ASC",
+	).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
+
+// SearchesPerSession implements Service. It returns average number of
+// searches of sessions with at least one search.
+func (s *service) SearchesPerSession(
+	ctx context.Context,
+	filters Filters,
+) (DataFrame[time.Time, float64], error) {
+	var b sql.Builder
+
+	b.Str("SELECT toStartOfInterval(toDateTime(session_timestamp),").
+		Call(interval, filters.TimeRange).Str(") AS time,").
+		Strs("toFloat64(COUNT(*) / uniqExact(session_uuid))",
+			"FROM pageviews",
+			"WHERE search_term != ''",
+			"AND session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("GROUP BY time",
+		"ORDER BY time")
+
+	return doTypedQuery[time.Time, float64](s.db, ctx, &b)
+}
+
+// TopSearchExits implements Service. It returns number of searches with no
+// further pageview in session per search term.
+func (s *service) TopSearchExits(
+	ctx context.Context,
+	filters Filters,
+	limit uint64,
+) (DataFrame[string, uint64], error) {
+	var b sql.Builder
+
+	b.Strs("WITH searches AS (",
+		"  SELECT lower(search_term) AS term,",
+		"  leadInFrame(toNullable(toInt64(timestamp))) OVER (",
+		"    PARTITION BY session_uuid ORDER BY timestamp ASC",
+		"    ROWS BETWEEN CURRENT ROW AND 1 FOLLOWING",
+		"  ) AS next_ts",
+		"  FROM pageviews",
+		"  WHERE session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs(")",
+		"SELECT term, COUNT(*) AS exits",
+		"FROM searches",
+		"WHERE term != '' AND next_ts IS NULL",
+		"GROUP BY term",
+		"ORDER BY exits DESC, term ASC",
+	).Fmt("LIMIT %v", limit)
+
+	return doQuery[string](s.db, ctx, &b)
+}
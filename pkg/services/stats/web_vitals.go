@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prismelabs/analytics/pkg/event"
+	"github.com/prismelabs/analytics/pkg/services/eventdb"
+	"github.com/prismelabs/analytics/pkg/sql"
+)
+
+// WebVitalsDimension defines a dimension used to break down Web Vitals
+// percentiles.
+type WebVitalsDimension string
+
+// Supported WebVitalsDimension.
+const (
+	WebVitalsByPage    WebVitalsDimension = "path"
+	WebVitalsByBrowser WebVitalsDimension = "browser_family"
+	WebVitalsByCountry WebVitalsDimension = "country_code"
+)
+
+// Percentiles holds 75th and 90th percentiles of a set of Count samples.
+type Percentiles struct {
+	Count uint64
+	P75   float64
+	P90   float64
+}
+
+// WebVitals implements Service.
+func (s *service) WebVitals(
+	ctx context.Context,
+	filters Filters,
+	metric event.WebVitalMetric,
+) (DataFrame[time.Time, Percentiles], error) {
+	var b sql.Builder
+
+	b.Str("SELECT toStartOfInterval(toDateTime(timestamp),").
+		Call(interval, filters.TimeRange).Str(") AS time,").
+		Strs("COUNT(*),",
+			"arrayElement(quantiles(0.75, 0.9)(value) AS q, 1), q[2]",
+			"FROM web_vitals").
+		Str("WHERE metric = ?", string(metric)).
+		Str("AND session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("GROUP BY time",
+		"ORDER BY time")
+
+	return doPercentilesQuery[time.Time](s.db, ctx, &b)
+}
+
+// WebVitalsBreakdown implements Service.
+func (s *service) WebVitalsBreakdown(
+	ctx context.Context,
+	filters Filters,
+	metric event.WebVitalMetric,
+	dimension WebVitalsDimension,
+	limit uint64,
+) (DataFrame[string, Percentiles], error) {
+	var b sql.Builder
+
+	switch dimension {
+	case WebVitalsByPage:
+		b.Strs("SELECT path AS key,",
+			"COUNT(*) AS samples,",
+			"arrayElement(quantiles(0.75, 0.9)(value) AS q, 1), q[2]",
+			"FROM web_vitals")
+	case WebVitalsByBrowser, WebVitalsByCountry:
+		b.Strs("SELECT web_vitals_sessions."+string(dimension), "AS key,",
+			"COUNT(*) AS samples,",
+			"arrayElement(quantiles(0.75, 0.9)(value) AS q, 1), q[2]",
+			"FROM web_vitals",
+			"LEFT JOIN (",
+			"  SELECT session_uuid, any("+string(dimension)+") AS "+string(dimension),
+			"  FROM sessions",
+			"  WHERE session_uuid IN (").Call(sessionQuery, filters).Strs(")",
+			"  GROUP BY session_uuid",
+			") AS web_vitals_sessions",
+			"ON web_vitals_sessions.session_uuid = web_vitals.session_uuid")
+	default:
+		return DataFrame[string, Percentiles]{}, fmt.Errorf("invalid web vitals dimension: %q", dimension)
+	}
+
+	b.Str("WHERE metric = ?", string(metric)).
+		Str("AND web_vitals.session_uuid IN (").Call(sessionQuery, filters).Str(")")
+	if (filters.TimeRange != TimeRange{}) {
+		b.Str("AND").Call(timeFilter, "timestamp", filters)
+	}
+	b.Strs("GROUP BY key",
+		"ORDER BY samples DESC",
+	).Fmt("LIMIT %v", limit)
+
+	return doPercentilesQuery[string](s.db, ctx, &b)
+}
+
+func doPercentilesQuery[K any](
+	db eventdb.Service,
+	ctx context.Context,
+	builder *sql.Builder,
+) (DataFrame[K, Percentiles], error) {
+	df := DataFrame[K, Percentiles]{
+		Keys:   []K{},
+		Values: []Percentiles{},
+	}
+
+	query, args := finishQuery(builder)
+
+	result, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return DataFrame[K, Percentiles]{}, fmt.Errorf("query %v failed: %w", query, err)
+	}
+
+	for result.Next() {
+		var k K
+		var v Percentiles
+		err := result.Scan(&k, &v.Count, &v.P75, &v.P90)
+		if err != nil {
+			return DataFrame[K, Percentiles]{}, err
+		}
+
+		df.Keys = append(df.Keys, k)
+		df.Values = append(df.Values, v)
+	}
+
+	return df, nil
+}
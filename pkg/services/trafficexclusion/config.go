@@ -0,0 +1,21 @@
+package trafficexclusion
+
+import (
+	"github.com/negrel/configue"
+)
+
+// Config holds service configuration.
+type Config struct {
+	IPs []string
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringSliceVar(&c.IPs, "trafficexclusion.ips", nil, "comma separated `list` of [DOMAIN=]IP or [DOMAIN=]CIDR of internal traffic, addresses without domain apply to all domains (e.g. 10.0.0.0/8,www.example.com=203.0.113.7)")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	_, _, err := parseRanges(c.IPs)
+	return err
+}
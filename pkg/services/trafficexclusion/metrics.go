@@ -0,0 +1,22 @@
+package trafficexclusion
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	excluded *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		excluded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "trafficexclusion_excluded_total",
+			Help: "Number of excluded internal traffic hits",
+		}, []string{"reason"}),
+	}
+
+	promRegistry.MustRegister(
+		m.excluded,
+	)
+
+	return m
+}
@@ -0,0 +1,146 @@
+package trafficexclusion
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exclusion reasons.
+const (
+	IpReason = "ip"
+)
+
+// Request holds data of an ingestion request checked against exclusion
+// rules.
+type Request struct {
+	// Domain of tracked page.
+	Domain string
+	// IP address of client.
+	IpAddr string
+}
+
+// Service define an internal traffic exclusion service.
+type Service interface {
+	// Exclude returns reason of exclusion of given request, if any. Excluded
+	// requests are counted per reason. Domain isn't used as a label as it
+	// comes from client and isn't validated.
+	Exclude(Request) (reason string, excluded bool)
+}
+
+type service struct {
+	metrics      metrics
+	prefixes     []netip.Prefix
+	domainRanges map[string][]netip.Prefix
+}
+
+// NewService returns a new internal traffic exclusion Service.
+func NewService(cfg Config, logger log.Logger, promRegistry *prometheus.Registry) (Service, error) {
+	logger = logger.With("service", "trafficexclusion")
+
+	prefixes, domainRanges, err := parseRanges(cfg.IPs)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("internal traffic ranges loaded", "ranges", prefixes, "domains", len(domainRanges))
+
+	return &service{
+		metrics:      newMetrics(promRegistry),
+		prefixes:     prefixes,
+		domainRanges: domainRanges,
+	}, nil
+}
+
+// Exclude implements Service.
+func (s *service) Exclude(req Request) (string, bool) {
+	reason, excluded := s.match(req)
+	if excluded {
+		s.metrics.excluded.With(prometheus.Labels{"reason": reason}).Inc()
+	}
+
+	return reason, excluded
+}
+
+func (s *service) match(req Request) (string, bool) {
+	domainRanges := s.domainRanges[req.Domain]
+	if len(s.prefixes) == 0 && len(domainRanges) == 0 {
+		return "", false
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(req.IpAddr))
+	if err != nil {
+		return "", false
+	}
+	addr = addr.Unmap()
+
+	if containsAddr(s.prefixes, addr) || containsAddr(domainRanges, addr) {
+		return IpReason, true
+	}
+
+	return "", false
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseRanges parses list of [DOMAIN=]IP and [DOMAIN=]CIDR. Domain specific
+// ranges are applied in addition to ranges without domain.
+func parseRanges(ranges []string) ([]netip.Prefix, map[string][]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	domainRanges := make(map[string][]netip.Prefix)
+
+	for _, r := range ranges {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+
+		domain, ip, hasDomain := strings.Cut(r, "=")
+		if !hasDomain {
+			ip = domain
+		}
+
+		prefix, err := parsePrefix(strings.TrimSpace(ip))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid internal traffic range %q: %w", r, err)
+		}
+
+		if hasDomain {
+			domain = strings.TrimSpace(domain)
+			if domain == "" {
+				return nil, nil, fmt.Errorf("invalid internal traffic range %q: empty domain", r)
+			}
+			domainRanges[domain] = append(domainRanges[domain], prefix)
+		} else {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes, domainRanges, nil
+}
+
+func parsePrefix(ip string) (netip.Prefix, error) {
+	if strings.Contains(ip, "/") {
+		prefix, err := netip.ParsePrefix(ip)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
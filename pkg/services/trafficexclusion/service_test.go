@@ -0,0 +1,101 @@
+package trafficexclusion
+
+import (
+	"io"
+	"testing"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	logger := log.New("trafficexclusion_service_test", io.Discard, false)
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		for _, ips := range [][]string{
+			{"10.0.0.0/33"},
+			{"not an ip"},
+			{"=10.0.0.1"},
+			{"example.com=foo"},
+		} {
+			cfg := Config{IPs: ips}
+			require.Error(t, cfg.Validate())
+			_, err := NewService(cfg, logger, prometheus.NewRegistry())
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("Exclude", func(t *testing.T) {
+		srv, err := NewService(Config{
+			IPs: []string{"10.0.0.0/8", "example.com=203.0.113.7", "example.com=2001:db8::/32"},
+		}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		type testCase struct {
+			name    string
+			request Request
+			reason  string
+		}
+
+		testCases := []testCase{
+			{
+				name:    "NoAddress",
+				request: Request{Domain: "example.com"},
+			},
+			{
+				name:    "ExternalTraffic",
+				request: Request{Domain: "example.com", IpAddr: "8.8.8.8"},
+			},
+			{
+				name:    "GlobalRange",
+				request: Request{Domain: "foo.com", IpAddr: "10.1.2.3"},
+				reason:  IpReason,
+			},
+			{
+				name:    "DomainAddress",
+				request: Request{Domain: "example.com", IpAddr: "203.0.113.7"},
+				reason:  IpReason,
+			},
+			{
+				name:    "DomainAddressOfAnotherDomain",
+				request: Request{Domain: "foo.com", IpAddr: "203.0.113.7"},
+			},
+			{
+				name:    "DomainIPv6Range",
+				request: Request{Domain: "example.com", IpAddr: "2001:db8::1"},
+				reason:  IpReason,
+			},
+			{
+				name:    "InvalidAddress",
+				request: Request{Domain: "foo.com", IpAddr: "invalid"},
+			},
+			{
+				name:    "IPv4MappedIPv6",
+				request: Request{Domain: "foo.com", IpAddr: "::ffff:10.0.0.1"},
+				reason:  IpReason,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				reason, excluded := srv.Exclude(tc.request)
+				require.Equal(t, tc.reason, reason)
+				require.Equal(t, tc.reason != "", excluded)
+			})
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		srv, err := NewService(Config{IPs: []string{"10.0.0.0/8"}}, logger, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		srv.Exclude(Request{Domain: "example.com", IpAddr: "10.0.0.1"})
+		srv.Exclude(Request{Domain: "example.com", IpAddr: "10.0.0.2"})
+		srv.Exclude(Request{Domain: "example.com", IpAddr: "8.8.8.8"})
+
+		excluded := srv.(*service).metrics.excluded
+		require.Equal(t, float64(2), testutil.ToFloat64(excluded.WithLabelValues(IpReason)))
+	})
+}
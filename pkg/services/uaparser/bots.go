@@ -0,0 +1,64 @@
+package uaparser
+
+import "regexp"
+
+// OtherBotFamily is the family of bots that aren't part of the bot families
+// table.
+const OtherBotFamily = "Other"
+
+// Bot families table. Embedded uap-core regexes are patched to replace family
+// of every bot with "bot" so bot families are matched against raw user agent
+// instead. Entries are matched in order, first match wins.
+var botFamilies = []struct {
+	family string
+	regex  *regexp.Regexp
+}{
+	{"Googlebot", regexp.MustCompile(`Googlebot|Google-InspectionTool|GoogleOther|Storebot-Google`)},
+	{"AdsBot-Google", regexp.MustCompile(`AdsBot-Google|Mediapartners-Google`)},
+	{"Bingbot", regexp.MustCompile(`(?i)bingbot|BingPreview|adidxbot|msnbot`)},
+	{"YandexBot", regexp.MustCompile(`Yandex[A-Za-z]*`)},
+	{"Baiduspider", regexp.MustCompile(`(?i)baiduspider`)},
+	{"DuckDuckBot", regexp.MustCompile(`DuckDuckBot|DuckAssistBot`)},
+	{"Applebot", regexp.MustCompile(`Applebot`)},
+	{"GPTBot", regexp.MustCompile(`GPTBot`)},
+	{"ChatGPT-User", regexp.MustCompile(`ChatGPT-User`)},
+	{"OAI-SearchBot", regexp.MustCompile(`OAI-SearchBot`)},
+	{"ClaudeBot", regexp.MustCompile(`ClaudeBot|Claude-User|Claude-SearchBot|Claude-Web|anthropic-ai`)},
+	{"PerplexityBot", regexp.MustCompile(`PerplexityBot|Perplexity-User`)},
+	{"CCBot", regexp.MustCompile(`CCBot`)},
+	{"Bytespider", regexp.MustCompile(`Bytespider`)},
+	{"Amazonbot", regexp.MustCompile(`Amazonbot`)},
+	{"Meta", regexp.MustCompile(`meta-externalagent|meta-externalfetcher|facebookexternalhit|FacebookBot`)},
+	{"Twitterbot", regexp.MustCompile(`Twitterbot`)},
+	{"LinkedInBot", regexp.MustCompile(`LinkedInBot`)},
+	{"Slackbot", regexp.MustCompile(`Slackbot`)},
+	{"Discordbot", regexp.MustCompile(`Discordbot`)},
+	{"AhrefsBot", regexp.MustCompile(`AhrefsBot`)},
+	{"SemrushBot", regexp.MustCompile(`SemrushBot`)},
+	{"MJ12bot", regexp.MustCompile(`MJ12bot`)},
+	{"DotBot", regexp.MustCompile(`DotBot`)},
+	{"PetalBot", regexp.MustCompile(`PetalBot`)},
+	{"HeadlessChrome", regexp.MustCompile(`HeadlessChrome`)},
+}
+
+// BotFamilies returns the list of bot families that can be detected. Last
+// family is always OtherBotFamily.
+func BotFamilies() []string {
+	families := make([]string, 0, len(botFamilies)+1)
+	for _, bf := range botFamilies {
+		families = append(families, bf.family)
+	}
+
+	return append(families, OtherBotFamily)
+}
+
+// botFamily returns family of bot with the given user agent.
+func botFamily(userAgent string) string {
+	for _, bf := range botFamilies {
+		if bf.regex.MatchString(userAgent) {
+			return bf.family
+		}
+	}
+
+	return OtherBotFamily
+}
@@ -6,4 +6,6 @@ type Client struct {
 	OperatingSystem string `json:"operating_system"`
 	Device          string `json:"device"`
 	IsBot           bool   `json:"is_bot"`
+	// Family of bot, empty if client isn't a bot.
+	BotFamily string `json:"bot_family"`
 }
@@ -55,6 +55,9 @@ func (s service) ParseUserAgent(userAgent string) Client {
 		Device:          client.Device.Family,
 		IsBot:           isBot,
 	}
+	if isBot {
+		result.BotFamily = botFamily(userAgent)
+	}
 
 	// https://www.youtube.com/watch?v=ftDVCo8SFD4
 	if result.Device == "K" {
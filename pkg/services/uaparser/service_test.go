@@ -82,6 +82,7 @@ func TestService(t *testing.T) {
 				OperatingSystem: "Other",
 				Device:          "Spider",
 				IsBot:           true,
+				BotFamily:       "Applebot",
 			},
 			userAgent: "Applebot",
 		},
@@ -91,9 +92,30 @@ func TestService(t *testing.T) {
 				OperatingSystem: "Other",
 				Device:          "Spider",
 				IsBot:           true,
+				BotFamily:       "AdsBot-Google",
 			},
 			userAgent: "AdsBot-Google",
 		},
+		{
+			expectedClient: Client{
+				BrowserFamily:   "bot",
+				OperatingSystem: "Other",
+				Device:          "Spider",
+				IsBot:           true,
+				BotFamily:       "GPTBot",
+			},
+			userAgent: "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)",
+		},
+		{
+			expectedClient: Client{
+				BrowserFamily:   "bot",
+				OperatingSystem: "Other",
+				Device:          "Spider",
+				IsBot:           true,
+				BotFamily:       OtherBotFamily,
+			},
+			userAgent: "ExampleCrawler/1.0",
+		},
 	}
 
 	logger := log.New("test_logger_1", io.Discard, false)
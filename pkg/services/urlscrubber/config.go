@@ -0,0 +1,44 @@
+package urlscrubber
+
+import (
+	"fmt"
+
+	"github.com/negrel/configue"
+)
+
+// QueryMode defines how query parameters missing from allowlist are handled.
+type QueryMode string
+
+// Supported QueryMode.
+const (
+	// Parameters are removed.
+	DropQueryMode QueryMode = "drop"
+	// Parameters values are replaced by their hash.
+	HashQueryMode QueryMode = "hash"
+)
+
+// Config holds service configuration.
+type Config struct {
+	QueryAllowlist []string
+	QueryMode      string
+	KeepFragment   bool
+	RedactPath     bool
+}
+
+// RegisterOptions registers Config fields as options.
+func (c *Config) RegisterOptions(f *configue.Figue) {
+	f.StringSliceVar(&c.QueryAllowlist, "urlscrubber.query.allowlist", nil, "comma separated `list` of query parameters kept in ingested URLs, UTM parameters are always kept")
+	f.StringVar(&c.QueryMode, "urlscrubber.query.mode", string(DropQueryMode), "`mode` of query parameters missing from allowlist: drop or hash")
+	f.BoolVar(&c.KeepFragment, "urlscrubber.keep.fragment", false, "keep fragment of ingested URLs, email and token-looking segments of fragment are redacted")
+	f.BoolVar(&c.RedactPath, "urlscrubber.redact.path", true, "redact email and token-looking path segments of ingested URLs")
+}
+
+// Validate validates configuration options.
+func (c *Config) Validate() error {
+	switch QueryMode(c.QueryMode) {
+	case DropQueryMode, HashQueryMode:
+		return nil
+	default:
+		return fmt.Errorf("invalid url scrubber query mode %q", c.QueryMode)
+	}
+}
@@ -0,0 +1,22 @@
+package urlscrubber
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	redactions *prometheus.CounterVec
+}
+
+func newMetrics(promRegistry *prometheus.Registry) metrics {
+	m := metrics{
+		redactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlscrubber_redactions_total",
+			Help: "Number of redactions applied to ingested URLs",
+		}, []string{"kind"}),
+	}
+
+	promRegistry.MustRegister(
+		m.redactions,
+	)
+
+	return m
+}
@@ -0,0 +1,260 @@
+package urlscrubber
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/prismelabs/analytics/pkg/log"
+	"github.com/prismelabs/analytics/pkg/uri"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// Placeholders of redacted segments and values.
+const (
+	EmailPlaceholder = ":email"
+	TokenPlaceholder = ":token"
+)
+
+// Redaction kinds.
+const (
+	queryParamRedaction = "query_param"
+	emailRedaction      = "email"
+	tokenRedaction      = "token"
+	fragmentRedaction   = "fragment"
+)
+
+var (
+	emailRegex = regexp.MustCompile(`^[^@/\s]+@[^@/\s]+\.[a-zA-Z]{2,}$`)
+	hexRegex   = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	tokenRegex = regexp.MustCompile(`^[A-Za-z0-9_\-.=~+]{16,}$`)
+	// URLs, emails and tokens embedded in free form text.
+	textUrlRegex   = regexp.MustCompile(`https?://[^\s"'<>()\[\]{}]+`)
+	textEmailRegex = regexp.MustCompile(`[^@\s/"'<>()\[\]{}:,;]+@[^@\s/"'<>()\[\]{}:,;]+\.[a-zA-Z]{2,}`)
+	textTokenRegex = regexp.MustCompile(`[A-Za-z0-9_\-.=~+]{16,}`)
+)
+
+// Service define an URL scrubbing service removing personal data from
+// ingested URLs before they reach session store and event store.
+type Service interface {
+	// Scrub returns given URI with query parameters missing from allowlist
+	// dropped or hashed, fragment removed and email and token-looking segments
+	// redacted.
+	Scrub(uri.Uri) uri.Uri
+	// ScrubPath redacts email and token-looking segments of given path.
+	ScrubPath(path string) string
+	// ScrubText scrubs URLs embedded in given free form text (e.g. error
+	// messages or site search terms) and redacts emails and tokens.
+	ScrubText(text string) string
+}
+
+type service struct {
+	metrics      metrics
+	allowlist    map[string]struct{}
+	mode         QueryMode
+	keepFragment bool
+	redactPath   bool
+}
+
+// NewService returns a new URL scrubbing Service.
+func NewService(cfg Config, logger log.Logger, promRegistry *prometheus.Registry) Service {
+	allowlist := make(map[string]struct{}, len(cfg.QueryAllowlist))
+	for _, param := range cfg.QueryAllowlist {
+		if param = strings.TrimSpace(param); param != "" {
+			allowlist[param] = struct{}{}
+		}
+	}
+
+	logger.With("service", "urlscrubber").
+		Info("url scrubber configured", "query_allowlist", cfg.QueryAllowlist, "query_mode", cfg.QueryMode)
+
+	return &service{
+		metrics:      newMetrics(promRegistry),
+		allowlist:    allowlist,
+		mode:         QueryMode(cfg.QueryMode),
+		keepFragment: cfg.KeepFragment,
+		redactPath:   cfg.RedactPath,
+	}
+}
+
+// Scrub implements Service.
+func (s *service) Scrub(u uri.Uri) uri.Uri {
+	if !u.IsValid() {
+		return u
+	}
+
+	changed := false
+
+	path := s.ScrubPath(u.Path())
+	changed = changed || path != u.Path()
+
+	query := u.QueryString()
+	if query != "" {
+		query = s.scrubQuery(query)
+		changed = changed || query != u.QueryString()
+	}
+
+	fragment := u.Hash()
+	if fragment != "" {
+		if s.keepFragment {
+			fragment = s.scrubSegments(fragment)
+		} else {
+			s.metrics.redactions.WithLabelValues(fragmentRedaction).Inc()
+			fragment = ""
+		}
+		changed = changed || fragment != u.Hash()
+	}
+
+	if !changed {
+		return u
+	}
+
+	str := u.Scheme() + "://" + u.Host() + path
+	if query != "" {
+		str += "?" + query
+	}
+	if fragment != "" {
+		str += "#" + fragment
+	}
+
+	result, err := uri.Parse(str)
+	if err != nil {
+		// Should never happen, fallback to URI without path, query and fragment.
+		return u.RootUri()
+	}
+
+	return result
+}
+
+// ScrubPath implements Service.
+func (s *service) ScrubPath(path string) string {
+	if !s.redactPath {
+		return path
+	}
+
+	return s.scrubSegments(path)
+}
+
+// ScrubText implements Service.
+func (s *service) ScrubText(text string) string {
+	text = textUrlRegex.ReplaceAllStringFunc(text, func(rawUri string) string {
+		u, err := uri.Parse(rawUri)
+		if err != nil {
+			return rawUri
+		}
+		return s.Scrub(u).String()
+	})
+
+	text = textEmailRegex.ReplaceAllStringFunc(text, func(string) string {
+		s.metrics.redactions.WithLabelValues(emailRedaction).Inc()
+		return EmailPlaceholder
+	})
+
+	return textTokenRegex.ReplaceAllStringFunc(text, func(word string) string {
+		if !isToken(word) {
+			return word
+		}
+		s.metrics.redactions.WithLabelValues(tokenRedaction).Inc()
+		return TokenPlaceholder
+	})
+}
+
+func (s *service) scrubSegments(str string) string {
+	var segments []string
+	for i, segment := range strings.Split(str, "/") {
+		placeholder := s.redactValue(segment)
+		if placeholder == "" {
+			continue
+		}
+
+		if segments == nil {
+			segments = strings.Split(str, "/")
+		}
+		segments[i] = placeholder
+	}
+
+	if segments == nil {
+		return str
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func (s *service) scrubQuery(query string) string {
+	var args, result fasthttp.Args
+	args.Parse(query)
+
+	args.VisitAll(func(keyBytes, valueBytes []byte) {
+		key := string(keyBytes)
+		value := string(valueBytes)
+
+		_, allowed := s.allowlist[key]
+		if allowed || strings.HasPrefix(key, "utm_") {
+			if placeholder := s.redactValue(value); placeholder != "" {
+				value = placeholder
+			}
+			result.Add(key, value)
+			return
+		}
+
+		s.metrics.redactions.WithLabelValues(queryParamRedaction).Inc()
+		if s.mode == HashQueryMode {
+			hash := sha256.Sum256(valueBytes)
+			result.Add(key, hex.EncodeToString(hash[:8]))
+		}
+	})
+
+	return string(result.QueryString())
+}
+
+// redactValue returns placeholder of given value if it looks like an email
+// or a token and an empty string otherwise.
+func (s *service) redactValue(value string) string {
+	if len(value) < 6 {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		value = unescaped
+	}
+
+	if strings.IndexByte(value, '@') != -1 && emailRegex.MatchString(value) {
+		s.metrics.redactions.WithLabelValues(emailRedaction).Inc()
+		return EmailPlaceholder
+	}
+	if isToken(value) {
+		s.metrics.redactions.WithLabelValues(tokenRedaction).Inc()
+		return TokenPlaceholder
+	}
+
+	return ""
+}
+
+// isToken returns true if given string looks like a secret token: an
+// hexadecimal string of at least 16 characters or a string of at least 16
+// characters mixing digits, lower and upper case letters (e.g. base64
+// encoded secrets and JWTs). Slugs and UUIDs aren't considered as tokens.
+func isToken(str string) bool {
+	if hexRegex.MatchString(str) {
+		return true
+	}
+	if !tokenRegex.MatchString(str) {
+		return false
+	}
+
+	var digit, lower, upper bool
+	for _, c := range str {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		}
+	}
+
+	return digit && lower && upper
+}
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/prismelabs/analytics/pkg/dataview"
 	"github.com/prismelabs/analytics/pkg/event"
 )
 
@@ -27,6 +28,7 @@ func Session() event.Session {
 func PageView(session event.Session) event.PageView {
 	return event.PageView{
 		Session: session,
+		EventId: EventId(),
 		Timestamp: session.SessionTime().Add(
 			time.Duration(session.PageviewCount) * time.Minute,
 		),
@@ -43,6 +45,7 @@ func FileDownload(session event.Session) event.FileDownload {
 		),
 		PageUri: PageUri(session),
 		Session: session,
+		EventId: EventId(),
 		FileUrl: PageUri(session),
 	}
 }
@@ -55,6 +58,7 @@ func OutboundLinkClick(session event.Session) event.OutboundLinkClick {
 		),
 		PageUri: PageUri(session),
 		Session: session,
+		EventId: EventId(),
 		Link:    Uri(),
 	}
 }
@@ -67,8 +71,64 @@ func CustomEvent(session event.Session) event.Custom {
 		),
 		PageUri: PageUri(session),
 		Session: session,
+		EventId: EventId(),
 		Name:    "click",
 		Keys:    []string{"x", "y"},
 		Values:  []string{"100", "200"},
+		Types:   []dataview.ValueType{dataview.NumberValue, dataview.NumberValue},
+	}
+}
+
+// Engagement returns a random valid event.Engagement.
+func Engagement(session event.Session) event.Engagement {
+	return event.Engagement{
+		Timestamp: session.SessionTime().Add(
+			time.Duration(session.PageviewCount) * time.Minute,
+		),
+		PageUri:     PageUri(session),
+		Session:     session,
+		EventId:     EventId(),
+		ActiveTime:  time.Duration(rand.Intn(60_000)) * time.Millisecond,
+		ScrollDepth: uint8(rand.Intn(101)),
+	}
+}
+
+// WebVital returns a random valid event.WebVital.
+func WebVital(session event.Session) event.WebVital {
+	metric := Item([]event.WebVitalMetric{
+		event.LcpWebVital, event.InpWebVital, event.ClsWebVital,
+		event.TtfbWebVital, event.FcpWebVital,
+	})
+	value := rand.Float64() * 5000
+	if metric == event.ClsWebVital {
+		value = rand.Float64()
+	}
+
+	return event.WebVital{
+		Timestamp: session.SessionTime().Add(
+			time.Duration(session.PageviewCount) * time.Minute,
+		),
+		PageUri: PageUri(session),
+		Session: session,
+		EventId: EventId(),
+		Metric:  metric,
+		Value:   value,
+	}
+}
+
+// Error returns a random valid event.Error.
+func Error(session event.Session) event.Error {
+	return event.Error{
+		Timestamp: session.SessionTime().Add(
+			time.Duration(session.PageviewCount) * time.Minute,
+		),
+		PageUri:     PageUri(session),
+		Session:     session,
+		EventId:     EventId(),
+		Message:     "TypeError: undefined is not a function",
+		Source:      PageUri(session).String() + "/main.js",
+		Line:        uint32(rand.Intn(1000)),
+		Column:      uint32(rand.Intn(120)),
+		Fingerprint: String(AlphaNum, 16),
 	}
 }
@@ -140,3 +140,8 @@ func UuidV7(t time.Time) uuid.UUID {
 	u[6] = 0x70 // version byte.
 	return u
 }
+
+// EventId returns a random event id.
+func EventId() string {
+	return uuid.NewString()
+}
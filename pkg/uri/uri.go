@@ -38,11 +38,21 @@ func NewFromFasthttpUri(furi *fasthttp.URI) (Uri, error) {
 
 	data := utils.CopyBytes(furi.FullURI())
 
+	// Path is escaped in full URI, its length may differ from decoded path
+	// length.
+	pathLen := len(data) - len(furi.Scheme()) - len("://") - len(furi.Host())
+	if queryLen := len(furi.QueryString()); queryLen > 0 {
+		pathLen -= queryLen + 1
+	}
+	if hashLen := len(furi.Hash()); hashLen > 0 {
+		pathLen -= hashLen + 1
+	}
+
 	return Uri{
 		data:      data,
 		schemeLen: len(furi.Scheme()),
 		hostLen:   len(furi.Host()),
-		pathLen:   len(furi.Path()),
+		pathLen:   pathLen,
 		queryLen:  len(furi.QueryString()),
 		hashLen:   len(furi.Hash()),
 	}, nil
@@ -137,6 +147,48 @@ func (u *Uri) RootUri() Uri {
 	}
 }
 
+// WithPath returns a copy of Uri with path replaced by the given one. Path
+// must be normalized and start with a slash.
+func (u *Uri) WithPath(path string) Uri {
+	start := u.schemeLen + len("://") + u.hostLen
+	end := start + u.pathLen
+
+	data := make([]byte, 0, len(u.data)-u.pathLen+len(path))
+	data = append(data, u.data[:start]...)
+	data = append(data, path...)
+	data = append(data, u.data[end:]...)
+
+	return Uri{
+		data:      data,
+		schemeLen: u.schemeLen,
+		hostLen:   u.hostLen,
+		pathLen:   len(path),
+		queryLen:  u.queryLen,
+		hashLen:   u.hashLen,
+	}
+}
+
+// WithQuery returns a copy of Uri with query string replaced by the given
+// one. Query must be encoded and must not start with a question mark.
+func (u *Uri) WithQuery(query string) Uri {
+	str := u.Scheme() + "://" + u.Host() + u.Path()
+	if query != "" {
+		str += "?" + query
+	}
+	if u.hashLen > 0 {
+		str += "#" + u.Hash()
+	}
+
+	return Uri{
+		data:      []byte(str),
+		schemeLen: u.schemeLen,
+		hostLen:   u.hostLen,
+		pathLen:   u.pathLen,
+		queryLen:  len(query),
+		hashLen:   u.hashLen,
+	}
+}
+
 // String implements fmt.Stringer.
 func (u Uri) String() string {
 	if u.queryLen == 0 {
@@ -47,6 +47,28 @@ func TestUri(t *testing.T) {
 				query:    "",
 				hash:     "",
 			},
+			{
+				uri:      "app://com.example.ios/Home%20Screen?tab=1",
+				scheme:   "app",
+				host:     "com.example.ios",
+				hostname: "com.example.ios",
+				origin:   "app://com.example.ios",
+				path:     "/Home%20Screen",
+				query:    "tab=1",
+				hash:     "",
+			},
+			{
+				// Path length must be the escaped one, otherwise query and
+				// hash are shifted.
+				uri:      "https://example.org/caf%C3%A9/a%20b?q=1#top",
+				scheme:   "https",
+				host:     "example.org",
+				hostname: "example.org",
+				origin:   "https://example.org",
+				path:     "/caf%C3%A9/a%20b",
+				query:    "q=1",
+				hash:     "top",
+			},
 			{
 				uri:           "./hello/world",
 				expectedError: ErrUriIsRelative,
@@ -82,6 +104,32 @@ func TestUri(t *testing.T) {
 			require.Equal(t, "https://www.example.com/", rootUri.String())
 		})
 
+		t.Run("WithPath", func(t *testing.T) {
+			uri, err := Parse("https://www.example.com/foo/bar?q=baz#qux")
+			require.NoError(t, err)
+			newUri := uri.WithPath("/foo/:id")
+			require.Equal(t, "/foo/:id", newUri.Path())
+			require.Equal(t, "q=baz", newUri.QueryString())
+			require.Equal(t, "qux", newUri.Hash())
+			require.Equal(t, "https://www.example.com/foo/:id?q=baz#qux", newUri.String())
+			// Original uri is unchanged.
+			require.Equal(t, "/foo/bar", uri.Path())
+		})
+
+		t.Run("WithQuery", func(t *testing.T) {
+			uri, err := Parse("https://www.example.com/search?q=shoes&page=2#qux")
+			require.NoError(t, err)
+			newUri := uri.WithQuery("page=2")
+			require.Equal(t, "/search", newUri.Path())
+			require.Equal(t, "page=2", newUri.QueryString())
+			require.Equal(t, "qux", newUri.Hash())
+			require.Equal(t, "https://www.example.com/search?page=2#qux", newUri.String())
+
+			newUri = uri.WithQuery("")
+			require.Equal(t, "", newUri.QueryString())
+			require.Equal(t, "qux", newUri.Hash())
+		})
+
 		t.Run("ParsedUriIsCopied", func(t *testing.T) {
 			rawUri := []byte("https://www.example.com/")
 
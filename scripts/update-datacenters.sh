@@ -0,0 +1,11 @@
+#!/usr/bin/env bash
+
+set -euo pipefail
+
+repository_root="$(git rev-parse --show-toplevel)"
+
+for version in ipv4 ipv6; do
+	echo "downloading latest version of $repository_root/pkg/embedded/datacenters/$version.txt"
+	curl "https://raw.githubusercontent.com/X4BNet/lists_vpn/main/output/datacenter/$version.txt" \
+		-o "$repository_root/pkg/embedded/datacenters/$version.txt"
+done
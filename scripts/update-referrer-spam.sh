@@ -0,0 +1,9 @@
+#!/usr/bin/env bash
+
+set -euo pipefail
+
+repository_root="$(git rev-parse --show-toplevel)"
+
+echo "downloading latest version of $repository_root/pkg/embedded/referrer_spam/spammers.txt"
+curl https://raw.githubusercontent.com/matomo-org/referrer-spam-list/master/spammers.txt \
+	-o "$repository_root/pkg/embedded/referrer_spam/spammers.txt"
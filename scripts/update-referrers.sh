@@ -0,0 +1,12 @@
+#!/usr/bin/env bash
+
+set -euo pipefail
+
+repository_root="$(git rev-parse --show-toplevel)"
+
+# Embedded database is an intentional subset of major providers (see
+# pkg/embedded/referrers.go), this script replaces it with the full database.
+
+echo "downloading latest version of $repository_root/pkg/embedded/referrers/referrers.json"
+curl https://s3-eu-west-1.amazonaws.com/snowplow-hosted-assets/third-party/referer-parser/referers-latest.json \
+	-o "$repository_root/pkg/embedded/referrers/referrers.json"
@@ -14,6 +14,11 @@ export const PRISME_NOSCRIPT_OUTBOUND_LINK_EVENTS_URL = PRISME_API_URL +
   "/noscript/events/outbound-links";
 export const PRISME_FILE_DOWNLOAD_EVENTS_URL = PRISME_API_URL +
   "/events/file-downloads";
+export const PRISME_ENGAGEMENT_EVENTS_URL = PRISME_API_URL +
+  "/events/engagement";
+export const PRISME_WEB_VITALS_EVENTS_URL = PRISME_API_URL +
+  "/events/web-vitals";
+export const PRISME_ERRORS_EVENTS_URL = PRISME_API_URL + "/events/errors";
 
 export const PRISME_METRICS_URL = PRISME_ADMIN_URL + "/metrics";
 
@@ -0,0 +1,52 @@
+import { expect } from "@std/expect";
+import { faker } from "@faker-js/faker";
+
+import { PRISME_PAGEVIEWS_URL } from "../const.ts";
+
+const seed = new Date().getTime();
+console.log("faker seed", seed);
+faker.seed(seed);
+
+Deno.test("app screen view with valid app key", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-App-Id": "com.example.ios",
+      "X-Prisme-App-Key": "secret",
+      "X-Prisme-Screen": "Home",
+      "X-Prisme-App-Version": "1.2.3",
+      "X-Prisme-Os-Version": "iOS 18.0",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+});
+
+Deno.test("app screen view with invalid app key", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-App-Id": "com.example.ios",
+      "X-Prisme-App-Key": "not-the-secret",
+      "X-Prisme-Screen": "Home",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(401);
+});
+
+Deno.test("app screen view of unknown app", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-App-Id": "com.example.unknown",
+      "X-Prisme-App-Key": "secret",
+      "X-Prisme-Screen": "Home",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(401);
+});
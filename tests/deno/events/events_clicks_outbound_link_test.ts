@@ -188,6 +188,31 @@ Deno.test("valid outbound link click event", async () => {
   });
 });
 
+Deno.test("outbound link personal data is scrubbed", async () => {
+  const response = await fetch(PRISME_OUTBOUND_LINK_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+    },
+    body:
+      "https://anotherwebsite.localhost/users/john@example.com?token=secret&utm_source=newsletter#access_token=secret",
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestOutboundLinkClickEvent();
+
+  expect(data).toMatchObject({
+    event: {
+      domain: "mywebsite.localhost",
+      path: "/",
+      link: "https://anotherwebsite.localhost/users/:email?utm_source=newsletter",
+    },
+  });
+});
+
 Deno.test("valid ping request", async () => {
   const response = await fetch(PRISME_OUTBOUND_LINK_EVENTS_URL, {
     method: "POST",
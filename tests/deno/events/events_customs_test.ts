@@ -561,6 +561,178 @@ Deno.test("valid custom event with Windows + Chrome user agent", async () => {
   });
 });
 
+Deno.test("custom event with too large body is rejected", async () => {
+  const response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/foo", {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ foo: "x".repeat(32 * 1024) }),
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(413);
+});
+
+Deno.test("custom event with too many properties is rejected", async () => {
+  const response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/foo", {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify(
+      Object.fromEntries([...Array(65).keys()].map((i) => ["prop" + i, i])),
+    ),
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
+Deno.test("custom event with invalid revenue currency is rejected", async () => {
+  const response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/purchase", {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "X-Prisme-Revenue": "49.90 XXX",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
+Deno.test("valid custom event with revenue", async () => {
+  const response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/purchase", {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "X-Prisme-Revenue": "49.90 EUR",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestCustomEvent();
+
+  expect(data).toMatchObject({
+    event: {
+      domain: "mywebsite.localhost",
+      path: "/",
+      name: "purchase",
+      properties: {},
+      revenue: 49.9,
+      currency: "EUR",
+    },
+  });
+});
+
+Deno.test("valid custom event with gzip compressed properties", async () => {
+  const props = {
+    x: Math.round(Math.random() * 100),
+    y: Math.round(Math.random() * 100),
+  };
+  const body = await new Response(
+    new Blob([JSON.stringify(props)]).stream().pipeThrough(
+      new CompressionStream("gzip"),
+    ),
+  ).arrayBuffer();
+  const response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/foo", {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+      "Content-Encoding": "gzip",
+    },
+    body,
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestCustomEvent();
+
+  expect(data).toMatchObject({
+    event: {
+      domain: "mywebsite.localhost",
+      name: "foo",
+      properties: props,
+    },
+  });
+});
+
+Deno.test("custom event with unsupported content encoding", async () => {
+  const response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/foo", {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+      "Content-Encoding": "br",
+    },
+    body: JSON.stringify({}),
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(415);
+});
+
+Deno.test("custom event delivered twice with the same event id", async () => {
+  const eventId = crypto.randomUUID();
+  const headers = {
+    Origin: "http://mywebsite.localhost",
+    "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+    "X-Prisme-Referrer": "http://mywebsite.localhost/",
+    "X-Prisme-Event-Id": eventId,
+  };
+
+  let response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/foo", {
+    method: "POST",
+    headers,
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestCustomEvent();
+  expect(data).toMatchObject({
+    event: {
+      domain: "mywebsite.localhost",
+      event_id: eventId,
+      name: "foo",
+    },
+  });
+
+  // Duplicate is dropped.
+  response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/foo", {
+    method: "POST",
+    headers,
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(204);
+});
+
+Deno.test("custom event with invalid event id", async () => {
+  const response = await fetch(PRISME_CUSTOM_EVENTS_URL + "/foo", {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "X-Prisme-Event-Id": "<script>",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
 // deno-lint-ignore no-explicit-any
 async function getLatestCustomEvent(): Promise<any> {
   // Wait for clickhouse to ingest batch.
@@ -0,0 +1,167 @@
+import { expect } from "@std/expect";
+import { faker } from "@faker-js/faker";
+
+import { createClient } from "@clickhouse/client-web";
+import {
+  PRISME_ENGAGEMENT_EVENTS_URL,
+  PRISME_VISITOR_ID_REGEX,
+  TIMESTAMP_REGEX,
+  UUID_V7_REGEX,
+} from "../const.ts";
+import { randomIpWithSession, sleep } from "../utils.ts";
+
+const seed = new Date().getTime();
+console.log("faker seed", seed);
+faker.seed(seed);
+
+Deno.test("GET request instead of POST request", async () => {
+  const response = await fetch(PRISME_ENGAGEMENT_EVENTS_URL, {
+    method: "GET",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(405);
+});
+
+Deno.test("non JSON content type", async () => {
+  const response = await fetch(PRISME_ENGAGEMENT_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "text/plain",
+    },
+    body: JSON.stringify({ active_time: 1000, scroll_depth: 50 }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("invalid scroll depth", async () => {
+  const response = await fetch(PRISME_ENGAGEMENT_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ active_time: 1000, scroll_depth: 101 }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("negative active time", async () => {
+  const response = await fetch(PRISME_ENGAGEMENT_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ active_time: -1, scroll_depth: 10 }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("invalid sessionless engagement event", async () => {
+  const response = await fetch(PRISME_ENGAGEMENT_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      // No session associated with this ip.
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ active_time: 1000, scroll_depth: 50 }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("valid test cases break", async () => {
+  // Sleep so valid test cases rows are more recent than invalid ones.
+  await sleep(1000);
+});
+
+Deno.test("valid engagement event", async () => {
+  const response = await fetch(PRISME_ENGAGEMENT_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost", {
+        path: "/foo",
+      }),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/foo",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ active_time: 12345, scroll_depth: 75 }),
+  });
+  expect(response.status).toBe(200);
+  await response.body?.cancel();
+
+  const data = await getLatestEngagementEvent();
+
+  expect(data).toMatchObject({
+    session: {
+      domain: "mywebsite.localhost",
+      entry_path: "/foo",
+      exit_timestamp: expect.stringMatching(TIMESTAMP_REGEX),
+      exit_path: "/foo",
+      visitor_id: expect.stringMatching(PRISME_VISITOR_ID_REGEX),
+      session_uuid: expect.stringMatching(UUID_V7_REGEX),
+      version: 1,
+    },
+    event: {
+      timestamp: expect.stringMatching(TIMESTAMP_REGEX),
+      domain: "mywebsite.localhost",
+      path: "/foo",
+      visitor_id: expect.stringMatching(PRISME_VISITOR_ID_REGEX),
+      session_uuid: expect.stringMatching(UUID_V7_REGEX),
+      active_time: 12345,
+      scroll_depth: 75,
+    },
+  });
+});
+
+// deno-lint-ignore no-explicit-any
+async function getLatestEngagementEvent(): Promise<any> {
+  // Wait for clickhouse to ingest batch.
+  await sleep(1000);
+
+  const client = createClient({
+    url: "http://clickhouse.localhost:8123",
+    username: "clickhouse",
+    password: "password",
+    database: "prisme",
+  });
+
+  const sessions = await client.query({
+    query: "SELECT * FROM sessions ORDER BY exit_timestamp DESC LIMIT 1",
+  });
+  // deno-lint-ignore no-explicit-any
+  const session = await sessions.json().then((r: any) => r.data[0]);
+  expect(session.sign).toBe(1);
+  delete session.sign;
+
+  const events = await client.query({
+    query: `SELECT * FROM engagements WHERE visitor_id = '${session
+      .visitor_id as string}' ORDER BY timestamp DESC LIMIT 1`,
+  });
+  // deno-lint-ignore no-explicit-any
+  const ev = await events.json().then((r: any) => r.data[0]);
+  if (ev === null || ev === undefined) return null;
+  expect(ev.visitor_id).toBe(session.visitor_id);
+  expect(ev.session_uuid).toBe(session.session_uuid);
+
+  return { event: ev, session };
+}
@@ -0,0 +1,175 @@
+import { expect } from "@std/expect";
+import { faker } from "@faker-js/faker";
+
+import { createClient } from "@clickhouse/client-web";
+import {
+  PRISME_ERRORS_EVENTS_URL,
+  PRISME_PAGEVIEWS_URL,
+  PRISME_VISITOR_ID_REGEX,
+  TIMESTAMP_REGEX,
+  UUID_V7_REGEX,
+} from "../const.ts";
+import { randomIpWithSession, sleep } from "../utils.ts";
+
+const seed = new Date().getTime();
+console.log("faker seed", seed);
+faker.seed(seed);
+
+Deno.test("missing error message", async () => {
+  const response = await fetch(PRISME_ERRORS_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ source: "http://mywebsite.localhost/main.js" }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("invalid sessionless error event", async () => {
+  const response = await fetch(PRISME_ERRORS_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      // No session associated with this ip.
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ message: "Error: oops" }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("too many errors are rate limited", async () => {
+  const ip = await randomIpWithSession("mywebsite.localhost");
+  const statuses = [];
+  for (let i = 0; i < 11; i++) {
+    const response = await fetch(PRISME_ERRORS_EVENTS_URL, {
+      method: "POST",
+      headers: {
+        Origin: "http://mywebsite.localhost",
+        "X-Forwarded-For": ip,
+        "X-Prisme-Referrer": "http://mywebsite.localhost/",
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({ message: `Error: ${i}` }),
+    });
+    await response.body?.cancel();
+    statuses.push(response.status);
+  }
+
+  expect(statuses.slice(0, 10).every((s) => s === 200)).toBe(true);
+  expect(statuses[10]).toBe(429);
+});
+
+Deno.test("error retried after session creation is accepted", async () => {
+  const ip = faker.internet.ip();
+  const sendError = () =>
+    fetch(PRISME_ERRORS_EVENTS_URL, {
+      method: "POST",
+      headers: {
+        Origin: "http://mywebsite.localhost",
+        "X-Forwarded-For": ip,
+        "X-Prisme-Referrer": "http://mywebsite.localhost/",
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({ message: "Error: retried" }),
+    });
+
+  // No session yet.
+  let response = await sendError();
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+
+  // Create session.
+  response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": ip,
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+    },
+  });
+  await response.body?.cancel();
+
+  // Retry isn't considered as a duplicate.
+  response = await sendError();
+  expect(response.status).toBe(200);
+  await response.body?.cancel();
+});
+
+Deno.test("valid test cases break", async () => {
+  // Sleep so valid test cases rows are more recent than invalid ones.
+  await sleep(1000);
+});
+
+Deno.test("valid error event is deduplicated", async () => {
+  const ip = await randomIpWithSession("mywebsite.localhost");
+  for (let i = 0; i < 2; i++) {
+    const response = await fetch(PRISME_ERRORS_EVENTS_URL, {
+      method: "POST",
+      headers: {
+        Origin: "http://mywebsite.localhost",
+        "X-Forwarded-For": ip,
+        "X-Prisme-Referrer": "http://mywebsite.localhost/",
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({
+        message: "TypeError: x is undefined",
+        source: "http://mywebsite.localhost/main.js",
+        line: 42,
+        column: 7,
+      }),
+    });
+    expect(response.status).toBe(200);
+    await response.body?.cancel();
+  }
+
+  const events = await getLatestErrorEvents();
+  expect(events).toMatchObject([
+    {
+      timestamp: expect.stringMatching(TIMESTAMP_REGEX),
+      domain: "mywebsite.localhost",
+      path: "/",
+      visitor_id: expect.stringMatching(PRISME_VISITOR_ID_REGEX),
+      session_uuid: expect.stringMatching(UUID_V7_REGEX),
+      message: "TypeError: x is undefined",
+      source: "http://mywebsite.localhost/main.js",
+      line: 42,
+      column: 7,
+      fingerprint: expect.stringMatching(/^[0-9a-f]{16}$/),
+    },
+  ]);
+});
+
+// deno-lint-ignore no-explicit-any
+async function getLatestErrorEvents(): Promise<any> {
+  // Wait for clickhouse to ingest batch.
+  await sleep(1000);
+
+  const client = createClient({
+    url: "http://clickhouse.localhost:8123",
+    username: "clickhouse",
+    password: "password",
+    database: "prisme",
+  });
+
+  const sessions = await client.query({
+    query: "SELECT * FROM sessions ORDER BY exit_timestamp DESC LIMIT 1",
+  });
+  // deno-lint-ignore no-explicit-any
+  const session = await sessions.json().then((r: any) => r.data[0]);
+
+  const events = await client.query({
+    query: `SELECT * FROM errors WHERE visitor_id = '${session
+      .visitor_id as string}' ORDER BY timestamp DESC`,
+  });
+  // deno-lint-ignore no-explicit-any
+  return await events.json().then((r: any) => r.data);
+}
@@ -55,6 +55,33 @@ Deno.test("invalid URL in X-Prisme-Document-Referrer header", async () => {
   expect(response.status).toBe(400);
 });
 
+Deno.test("referrer spam in X-Prisme-Document-Referrer header is blocked", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost",
+      "X-Prisme-Document-Referrer": "https://www.semalt.com/",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
+Deno.test("pageview of blocked path is blocked", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/blocked/foo",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
 Deno.test("invalid URL in Referer header", async () => {
   const response = await fetch(PRISME_PAGEVIEWS_URL, {
     method: "POST",
@@ -139,6 +166,8 @@ Deno.test("valid internal pageview traffic with no session associated", async ()
       utm_campaign: "",
       utm_term: "",
       utm_content: "",
+      source: "direct",
+      channel: "direct",
       version: 1,
     },
     pageview: {
@@ -199,6 +228,54 @@ Deno.test("valid pageview with status 404", async () => {
   });
 });
 
+Deno.test("valid pageview with properties", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      Referer: "https://mywebsite.localhost/foo?bar=baz#qux",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ author: "John", logged_in: true }),
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestPageview();
+
+  expect(data).toMatchObject({
+    session: {
+      domain: "mywebsite.localhost",
+      exit_path: "/foo",
+      exit_keys: ["author", "logged_in"],
+      exit_values: ['"John"', "true"],
+      version: 1,
+    },
+    pageview: {
+      domain: "mywebsite.localhost",
+      path: "/foo",
+      keys: ["author", "logged_in"],
+      values: ['"John"', "true"],
+    },
+  });
+});
+
+Deno.test("pageview with non JSON body is rejected", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      Referer: "https://mywebsite.localhost/foo",
+      "Content-Type": "text/plain",
+    },
+    body: "author=John",
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
 Deno.test("registered domain in Origin header and valid referrer is accepted", async () => {
   const response = await fetch(PRISME_PAGEVIEWS_URL, {
     method: "POST",
@@ -277,6 +354,8 @@ Deno.test("registered domain in Origin header and valid X-Prisme-Referrer is acc
       utm_campaign: "",
       utm_term: "",
       utm_content: "",
+      source: "example.com",
+      channel: "referral",
       version: 1,
     },
     pageview: {
@@ -647,6 +726,8 @@ Deno.test("valid pageview with UTM parameters", async () => {
       utm_campaign: "spring sale",
       utm_term: "running shoes",
       utm_content: "logolink",
+      source: "github",
+      channel: "paid",
       version: 1,
     },
     pageview: {
@@ -692,6 +773,8 @@ Deno.test("valid pageview with ref query parameter", async () => {
       utm_campaign: "",
       utm_term: "",
       utm_content: "",
+      source: "advertising1",
+      channel: "referral",
       version: 1,
     },
     pageview: {
@@ -980,6 +1063,256 @@ Deno.test("valid consecutive pageviews with visitor id defined on second event",
   });
 });
 
+Deno.test("valid pageview with site search term", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      Referer: "http://mywebsite.localhost/search?q=+Running%20Shoes+&page=2",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestPageview();
+
+  expect(data).toMatchObject({
+    pageview: {
+      domain: "mywebsite.localhost",
+      path: "/search",
+      search_term: "Running Shoes",
+      status: 200,
+    },
+  });
+});
+
+Deno.test("valid pageview with site search term containing an email", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      Referer:
+        "http://mywebsite.localhost/search?q=orders%20of%20john.doe%40example.com",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestPageview();
+
+  expect(data).toMatchObject({
+    pageview: {
+      domain: "mywebsite.localhost",
+      path: "/search",
+      search_term: "orders of :email",
+      status: 200,
+    },
+  });
+});
+
+Deno.test("pageview with Do Not Track signal is dropped", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      DNT: "1",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(204);
+});
+
+Deno.test("pageview with Global Privacy Control signal is anonymized", async () => {
+  const visitorId = `visitor-id-${Math.random()}`;
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://foo.mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://foo.mywebsite.localhost/gpc",
+      "X-Prisme-Visitor-Id": visitorId,
+      "Sec-GPC": "1",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestPageview();
+
+  expect(data).toMatchObject({
+    session: {
+      domain: "foo.mywebsite.localhost",
+      entry_path: "/gpc",
+      exit_path: "/gpc",
+      operating_system: "Other",
+      browser_family: "Other",
+      device: "Other",
+      country_code: "XX",
+      visitor_id: expect.stringMatching(PRISME_VISITOR_ID_REGEX),
+      version: 1,
+    },
+    pageview: {
+      domain: "foo.mywebsite.localhost",
+      path: "/gpc",
+      status: 200,
+    },
+  });
+  expect(data.session.visitor_id).not.toBe(visitorId);
+});
+
+Deno.test("pageview with client timestamp of offline queued event", async () => {
+  // Client clock is an hour ahead and pageview was queued 10 minutes ago.
+  const clientTime = Date.now() + 60 * 60 * 1000;
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/offline",
+      "X-Prisme-Timestamp": String(clientTime - 10 * 60 * 1000),
+      "X-Prisme-Client-Time": String(clientTime),
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+});
+
+Deno.test("pageview with client timestamp outside accepted window", async () => {
+  const clientTime = Date.now();
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/offline",
+      "X-Prisme-Timestamp": String(clientTime - 7 * 24 * 60 * 60 * 1000),
+      "X-Prisme-Client-Time": String(clientTime),
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
+Deno.test("pageview with client timestamp but no client time", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/offline",
+      "X-Prisme-Timestamp": String(Date.now()),
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
+Deno.test("pageview with virtual page path and title", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/app?utm_source=newsletter",
+      "X-Prisme-Page-Path": "/checkout/payment",
+      "X-Prisme-Page-Title": encodeURIComponent("Paiement sécurisé"),
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestPageview();
+
+  expect(data).toMatchObject({
+    session: {
+      domain: "mywebsite.localhost",
+      entry_path: "/checkout/payment",
+      exit_path: "/checkout/payment",
+      utm_source: "newsletter",
+      exit_title: "Paiement sécurisé",
+    },
+    pageview: {
+      domain: "mywebsite.localhost",
+      path: "/checkout/payment",
+      title: "Paiement sécurisé",
+      status: 200,
+    },
+  });
+});
+
+Deno.test("pageview with invalid virtual page path", async () => {
+  for (const path of ["checkout", "//evil.example/checkout", "/checkout#step"]) {
+    const response = await fetch(PRISME_PAGEVIEWS_URL, {
+      method: "POST",
+      headers: {
+        Origin: "http://mywebsite.localhost",
+        "X-Forwarded-For": faker.internet.ip(),
+        "X-Prisme-Referrer": "http://mywebsite.localhost/app",
+        "X-Prisme-Page-Path": path,
+      },
+    });
+    await response.body?.cancel();
+    expect(response.status).toBe(400);
+  }
+});
+
+Deno.test("pageview with invalid page title", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/app",
+      "X-Prisme-Page-Title": "%E0%A4%A",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
+Deno.test("pageview with event id", async () => {
+  const eventId = crypto.randomUUID();
+  const headers = {
+    Origin: "http://mywebsite.localhost",
+    "X-Forwarded-For": faker.internet.ip(),
+    "X-Prisme-Referrer": "http://mywebsite.localhost/",
+    "X-Prisme-Event-Id": eventId,
+  };
+
+  let response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers,
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestPageview();
+
+  expect(data).toMatchObject({
+    session: {
+      domain: "mywebsite.localhost",
+      exit_event_id: eventId,
+      version: 1,
+    },
+    pageview: {
+      domain: "mywebsite.localhost",
+      event_id: eventId,
+    },
+  });
+
+  // Retry is dropped.
+  response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers,
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(204);
+});
+
 // deno-lint-ignore no-explicit-any
 async function getLatestPageview(): Promise<any> {
   // Wait for clickhouse to ingest batch.
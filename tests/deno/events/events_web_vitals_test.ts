@@ -0,0 +1,144 @@
+import { expect } from "@std/expect";
+import { faker } from "@faker-js/faker";
+
+import { createClient } from "@clickhouse/client-web";
+import {
+  PRISME_VISITOR_ID_REGEX,
+  PRISME_WEB_VITALS_EVENTS_URL,
+  TIMESTAMP_REGEX,
+  UUID_V7_REGEX,
+} from "../const.ts";
+import { randomIpWithSession, sleep } from "../utils.ts";
+
+const seed = new Date().getTime();
+console.log("faker seed", seed);
+faker.seed(seed);
+
+Deno.test("unknown web vitals metric", async () => {
+  const response = await fetch(PRISME_WEB_VITALS_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ FID: 10 }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("negative web vitals value", async () => {
+  const response = await fetch(PRISME_WEB_VITALS_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ LCP: -1 }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("empty web vitals body", async () => {
+  const response = await fetch(PRISME_WEB_VITALS_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({}),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("invalid sessionless web vitals event", async () => {
+  const response = await fetch(PRISME_WEB_VITALS_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      // No session associated with this ip.
+      "X-Forwarded-For": faker.internet.ip(),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ LCP: 1200 }),
+  });
+  expect(response.status).toBe(400);
+  await response.body?.cancel();
+});
+
+Deno.test("valid test cases break", async () => {
+  // Sleep so valid test cases rows are more recent than invalid ones.
+  await sleep(1000);
+});
+
+Deno.test("valid web vitals event", async () => {
+  const response = await fetch(PRISME_WEB_VITALS_EVENTS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": await randomIpWithSession("mywebsite.localhost"),
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({ LCP: 1234.5, CLS: 0.05 }),
+  });
+  expect(response.status).toBe(200);
+  await response.body?.cancel();
+
+  const events = await getLatestWebVitalsEvents();
+  expect(events).toMatchObject([
+    {
+      timestamp: expect.stringMatching(TIMESTAMP_REGEX),
+      domain: "mywebsite.localhost",
+      path: "/",
+      visitor_id: expect.stringMatching(PRISME_VISITOR_ID_REGEX),
+      session_uuid: expect.stringMatching(UUID_V7_REGEX),
+      metric: "CLS",
+      value: 0.05,
+    },
+    {
+      timestamp: expect.stringMatching(TIMESTAMP_REGEX),
+      domain: "mywebsite.localhost",
+      path: "/",
+      visitor_id: expect.stringMatching(PRISME_VISITOR_ID_REGEX),
+      session_uuid: expect.stringMatching(UUID_V7_REGEX),
+      metric: "LCP",
+      value: 1234.5,
+    },
+  ]);
+});
+
+// deno-lint-ignore no-explicit-any
+async function getLatestWebVitalsEvents(): Promise<any> {
+  // Wait for clickhouse to ingest batch.
+  await sleep(1000);
+
+  const client = createClient({
+    url: "http://clickhouse.localhost:8123",
+    username: "clickhouse",
+    password: "password",
+    database: "prisme",
+  });
+
+  const sessions = await client.query({
+    query: "SELECT * FROM sessions ORDER BY exit_timestamp DESC LIMIT 1",
+  });
+  // deno-lint-ignore no-explicit-any
+  const session = await sessions.json().then((r: any) => r.data[0]);
+
+  const events = await client.query({
+    query: `SELECT * FROM web_vitals WHERE visitor_id = '${session
+      .visitor_id as string}' ORDER BY metric ASC`,
+  });
+  // deno-lint-ignore no-explicit-any
+  return await events.json().then((r: any) => r.data);
+}
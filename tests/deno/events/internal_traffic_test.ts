@@ -0,0 +1,72 @@
+import { expect } from "@std/expect";
+
+import { PRISME_API_URL, PRISME_PAGEVIEWS_URL } from "../const.ts";
+
+Deno.test("pageview from internal IP address is excluded", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://foo.mywebsite.localhost",
+      "X-Forwarded-For": "198.51.100.7",
+      "X-Prisme-Referrer": "http://foo.mywebsite.localhost/",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(204);
+});
+
+Deno.test("pageview from internal IP address of another domain isn't excluded", async () => {
+  const response = await fetch(PRISME_PAGEVIEWS_URL, {
+    method: "POST",
+    headers: {
+      Origin: "http://mywebsite.localhost",
+      "X-Forwarded-For": "198.51.100.7",
+      "X-Prisme-Referrer": "http://mywebsite.localhost/",
+    },
+  });
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+});
+
+Deno.test("opt-out redirects to registered origin", async () => {
+  const response = await fetch(
+    PRISME_API_URL + "/opt-out?redirect=" +
+      encodeURIComponent("http://mywebsite.localhost/foo?bar=baz"),
+    { redirect: "manual" },
+  );
+  await response.body?.cancel();
+  expect(response.status).toBe(303);
+  expect(response.headers.get("Location")).toBe(
+    "http://mywebsite.localhost/foo?bar=baz#prisme-opt-out",
+  );
+  expect(response.headers.get("Set-Cookie")).toBeNull();
+});
+
+Deno.test("opt-in redirects to registered origin", async () => {
+  const response = await fetch(
+    PRISME_API_URL + "/opt-in?redirect=" +
+      encodeURIComponent("http://mywebsite.localhost/"),
+    { redirect: "manual" },
+  );
+  await response.body?.cancel();
+  expect(response.status).toBe(303);
+  expect(response.headers.get("Location")).toBe(
+    "http://mywebsite.localhost/#prisme-opt-in",
+  );
+});
+
+Deno.test("opt-out without redirect is rejected", async () => {
+  const response = await fetch(PRISME_API_URL + "/opt-out");
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
+
+Deno.test("opt-out redirect to non registered origin is rejected", async () => {
+  const response = await fetch(
+    PRISME_API_URL + "/opt-out?redirect=" +
+      encodeURIComponent("http://evil.localhost/"),
+    { redirect: "manual" },
+  );
+  await response.body?.cancel();
+  expect(response.status).toBe(400);
+});
@@ -1041,6 +1041,36 @@ Deno.test("valid consecutive pageviews with visitor id defined on second event",
   });
 });
 
+Deno.test("valid pageview with event id query parameter", async () => {
+  const eventId = crypto.randomUUID();
+  const response = await fetch(
+    PRISME_NOSCRIPT_PAGEVIEWS_URL + `?event-id=${eventId}`,
+    {
+      method: "GET",
+      headers: {
+        Origin: "http://foo.mywebsite.localhost",
+        "X-Forwarded-For": faker.internet.ip(),
+        Referer: "http://foo.mywebsite.localhost/",
+      },
+    },
+  );
+  await response.body?.cancel();
+  expect(response.status).toBe(200);
+
+  const data = await getLatestPageview();
+
+  expect(data).toMatchObject({
+    session: {
+      domain: "foo.mywebsite.localhost",
+      exit_event_id: eventId,
+    },
+    pageview: {
+      domain: "foo.mywebsite.localhost",
+      event_id: eventId,
+    },
+  });
+});
+
 // deno-lint-ignore no-explicit-any
 async function getLatestPageview(): Promise<any> {
   // Wait for clickhouse to ingest batch.
@@ -33,11 +33,55 @@
   var trackFileDownloads = currentScriptDataset.fileDownloads !== "false"
   // Status code.
   var statusCode = currentScriptDataset.status || "200"
+  // Track engagement (active time and scroll depth).
+  var trackEngagement = currentScriptDataset.engagement !== "false"
+  // Interval in seconds between engagement events, 0 to only send them when
+  // page is hidden or left.
+  var engagementInterval = parseInt(currentScriptDataset.engagementInterval || "0", 10) || 0
+  // Track Core Web Vitals (opt-in).
+  var trackWebVitals = currentScriptDataset.webVitals === "true"
+  // Track JavaScript errors (opt-in).
+  var trackErrors = currentScriptDataset.errors === "true"
+  // Custom properties of automatic pageviews as a JSON object
+  // (e.g. data-pageview-properties='{"author": "John"}').
+  var pageviewProperties = {}
+  try {
+    pageviewProperties = JSON.parse(currentScriptDataset.pageviewProperties || "{}")
+  } catch (err) {
+    console.warn("prisme: ignoring invalid data-pageview-properties attribute:", err)
+  }
+  // Send hash route of pages (e.g. #/users/42), hash changes are tracked by
+  // popstate listener. Hash route must be promoted into path server side
+  // (hash_routing path rule).
+  var hashRouting = currentScriptDataset.hashRouting === "true"
 
   // State variables.
   var referrer = doc.referrer.replace(loc.host, domain);
   var pageviewCount = 0
-  var trackingDisabled = localStorage.getItem("prismeAnalytics.tracking.enable") === "false"
+  // Virtual page path of last pageview, events are attributed to it.
+  var virtualPath = null
+  var trackingEnableKey = "prismeAnalytics.tracking.enable"
+  // Save opt-out / opt-in of redirections from Prisme /api/v1/opt-out and
+  // /api/v1/opt-in endpoints. Local storage is the only opt-out mechanism,
+  // events requests are cross origin and don't carry cookies.
+  if (loc.hash === "#prisme-opt-out" || loc.hash === "#prisme-opt-in") {
+    localStorage.setItem(trackingEnableKey, String(loc.hash === "#prisme-opt-in"))
+    history.replaceState(history.state, "", loc.pathname + loc.search)
+  }
+  var trackingDisabled = localStorage.getItem(trackingEnableKey) === "false"
+  // Engagement state of current page.
+  var engagementOptions = null
+  var activeTime = 0
+  var activeSince = null
+  var maxScrollDepth = 0
+  // Web Vitals of initial page load.
+  var webVitals = {}
+  var webVitalsOptions = null
+  // Maximum number of retries of an event.
+  var maxRetries = 2
+  // Errors already reported by this page.
+  var reportedErrors = {}
+  var reportedErrorsCount = 0
 
   function defaultOptions(options) {
     if (!options) options = {}
@@ -59,13 +103,21 @@
 
     if (!options[visitorIdString]) options[visitorIdString] = visitorId
 
-    options.url = scheme.concat("//", options.domain, options.path, loc.search)
+    if (!options.virtualPath) options.virtualPath = virtualPath
+
+    options.url = scheme.concat("//", options.domain, options.path, loc.search, hashRouting ? loc.hash : "")
+    // URL of page as seen by server, virtual page path overrides path and
+    // query string.
+    options.pageUrl = options.virtualPath
+      ? scheme.concat("//", options.domain, options.virtualPath, options.virtualPath.indexOf("?") === -1 ? loc.search : "")
+      : options.url
 
     return options
   }
 
   function configureHeaders(options, headers) {
     headers["X-Prisme-Referrer"] = options.url
+    if (options.virtualPath) headers["X-Prisme-Page-Path"] = options.virtualPath
     if (options[visitorIdString]) {
       headers["X-Prisme-Visitor-Id"] = options[visitorIdString].toString()
     }
@@ -80,6 +132,41 @@
     }, options)
   }
 
+  // Returns a new random event id.
+  function newEventId() {
+    // crypto.randomUUID is only available in secure contexts.
+    if (global.crypto && crypto.randomUUID) return crypto.randomUUID()
+    return Date.now().toString(36).concat("-", Math.random().toString(36).slice(2), Math.random().toString(36).slice(2))
+  }
+
+  // Sends an event to Prisme. Each event has an id (X-Prisme-Event-Id header)
+  // that is reused when event is retried so Prisme can deduplicate events
+  // delivered twice. Events are retried on network errors and on retryable
+  // responses (409 Conflict of events still being processed, 429 Too Many
+  // Requests and server errors).
+  function sendEvent(url, options, eventId, attempt) {
+    eventId = eventId || newEventId()
+    attempt = attempt || 0
+    options.headers["X-Prisme-Event-Id"] = eventId
+
+    function retry() {
+      return new Promise(function(resolve) {
+        setTimeout(resolve, 1000 * Math.pow(2, attempt))
+      }).then(function() {
+        return sendEvent(url, options, eventId, attempt + 1)
+      })
+    }
+
+    return doFetch(url, fetchDefaultOptions(options)).then(function(response) {
+      var status = response.status
+      if (attempt < maxRetries && (status === 409 || status === 429 || status >= 500)) return retry()
+      return response
+    }, function(err) {
+      if (attempt < maxRetries) return retry()
+      throw err
+    })
+  }
+
   function shouldFollowLink(event, anchor) {
     // Another handler prevent default behavior.
     if (event.defaultPrevented) { return false }
@@ -90,29 +177,175 @@
   }
 
 
+  function scrollDepth() {
+    var docElement = doc.documentElement
+    var height = docElement.scrollHeight
+    if (height <= 0) return 100
+    var depth = Math.round((global.scrollY + global.innerHeight) / height * 100)
+    return Math.max(0, Math.min(100, depth))
+  }
+
+  function updateScrollDepth() {
+    maxScrollDepth = Math.max(maxScrollDepth, scrollDepth())
+  }
+
+  function sendEngagement() {
+    if (trackingDisabled || !engagementOptions) return;
+
+    if (activeSince !== null) {
+      var now = Date.now()
+      activeTime += now - activeSince
+      activeSince = now
+    }
+    updateScrollDepth()
+    if (activeTime === 0) return;
+
+    sendEvent(prismeApiEventsUrl.concat("/engagement"), {
+      headers: configureHeaders(engagementOptions, {
+        "Content-Type": "application/json",
+      }),
+      body: JSON.stringify({
+        active_time: activeTime,
+        scroll_depth: maxScrollDepth,
+      }),
+    });
+
+    activeTime = 0
+  }
+
+  function startEngagement(options) {
+    // Flush engagement of previous page.
+    sendEngagement()
+
+    engagementOptions = options
+    activeTime = 0
+    activeSince = doc.visibilityState === "visible" ? Date.now() : null
+    maxScrollDepth = 0
+  }
+
+  function observePerformance(type, callback, options) {
+    try {
+      new PerformanceObserver(function(list) {
+        list.getEntries().forEach(callback)
+      }).observe(Object.assign({ type: type, buffered: true }, options))
+    } catch (_) {
+      // Entry type not supported by browser.
+    }
+  }
+
+  function collectWebVitals() {
+    var nav = performance.getEntriesByType("navigation")[0]
+    if (nav) webVitals.TTFB = Math.max(0, nav.responseStart)
+
+    observePerformance("paint", function(entry) {
+      if (entry.name === "first-contentful-paint") webVitals.FCP = entry.startTime
+    })
+    observePerformance("largest-contentful-paint", function(entry) {
+      webVitals.LCP = entry.startTime
+    })
+
+    // Layout shifts are grouped in session windows (1s gap, 5s max), CLS is
+    // the largest window.
+    var clsWindow = 0, clsFirst = 0, clsLast = 0
+    observePerformance("layout-shift", function(entry) {
+      if (entry.hadRecentInput) return
+      if (clsWindow && entry.startTime - clsLast < 1000 && entry.startTime - clsFirst < 5000) {
+        clsWindow += entry.value
+      } else {
+        clsWindow = entry.value
+        clsFirst = entry.startTime
+      }
+      clsLast = entry.startTime
+      webVitals.CLS = Math.max(webVitals.CLS || 0, clsWindow)
+    })
+
+    // INP is approximated by the longest interaction.
+    observePerformance("event", function(entry) {
+      if (entry.interactionId) webVitals.INP = Math.max(webVitals.INP || 0, entry.duration)
+    }, { durationThreshold: 40 })
+  }
+
+  function sendWebVitals() {
+    if (trackingDisabled || !webVitalsOptions || Object.keys(webVitals).length === 0) return;
+
+    sendEvent(prismeApiEventsUrl.concat("/web-vitals"), {
+      headers: configureHeaders(webVitalsOptions, {
+        "Content-Type": "application/json",
+      }),
+      body: JSON.stringify(webVitals),
+    });
+
+    // Web Vitals are only reported once per page load.
+    webVitalsOptions = null
+  }
+
+  function sendError(message, source, line, column) {
+    if (trackingDisabled || !message) return;
+
+    // Deduplicate and limit errors client side, server applies its own limits.
+    var key = [message, source, line].join(":")
+    if (reportedErrors[key] || reportedErrorsCount >= 10) return;
+    reportedErrors[key] = true
+    reportedErrorsCount++
+
+    var options = defaultOptions()
+    sendEvent(prismeApiEventsUrl.concat("/errors"), {
+      headers: configureHeaders(options, {
+        "Content-Type": "application/json",
+      }),
+      body: JSON.stringify({
+        message: String(message),
+        source: source || "",
+        line: line || 0,
+        column: column || 0,
+      }),
+    });
+  }
+
   function pageview(options) {
     if (trackingDisabled) return;
     pageviewCount++
+    // Virtual page path and title of single page applications views
+    // (e.g. { virtualPath: "/checkout/step-2", title: "Payment" }).
+    virtualPath = (options && options.virtualPath) || null
     options = defaultOptions(options)
 
-    doFetch(prismeApiEventsUrl.concat("/pageviews"), fetchDefaultOptions({
-      headers: configureHeaders(options, {
-        "X-Prisme-Document-Referrer": referrer,
-        "X-Prisme-Status": options.status,
-      }),
-    }));
+    if (trackEngagement) startEngagement(options)
+    // Web Vitals only measure initial page load, send them before the first
+    // client side navigation.
+    if (trackWebVitals) {
+      sendWebVitals()
+      if (pageviewCount === 1) webVitalsOptions = options
+    }
+
+    var headers = configureHeaders(options, {
+      "X-Prisme-Document-Referrer": referrer,
+      "X-Prisme-Status": options.status,
+    })
+    if (options.title) headers["X-Prisme-Page-Title"] = encodeURIComponent(options.title)
+    var properties = Object.assign({}, pageviewProperties, options.properties)
+    var body
+    if (Object.keys(properties).length > 0) {
+      headers["Content-Type"] = "application/json"
+      body = JSON.stringify(properties)
+    }
 
-    referrer = options.url
+    sendEvent(prismeApiEventsUrl.concat("/pageviews"), {
+      headers: headers,
+      body: body,
+    });
+
+    referrer = options.pageUrl
   }
 
   function sendClickEvent(kind, url, options) {
     if (trackingDisabled) return Promise.resolve();
     options = defaultOptions(options)
 
-    return doFetch(prismeApiEventsUrl.concat(kind), fetchDefaultOptions({
+    return sendEvent(prismeApiEventsUrl.concat(kind), {
       headers: configureHeaders(options, {}),
       body: url
-    }));
+    });
   }
 
   function handleLinkClickEvent(event) {
@@ -138,6 +371,42 @@
     }
   }
 
+  if (trackEngagement) {
+    documentAddEventListener("visibilitychange", function() {
+      if (doc.visibilityState === "hidden") {
+        sendEngagement()
+        activeSince = null
+      } else if (activeSince === null) {
+        activeSince = Date.now()
+      }
+    })
+    global[addEventListenerString]("scroll", updateScrollDepth, { passive: true })
+    if (engagementInterval > 0) {
+      setInterval(function() {
+        if (doc.visibilityState === "visible") sendEngagement()
+      }, engagementInterval * 1000)
+    }
+  }
+
+  if (trackErrors) {
+    global[addEventListenerString]("error", function(event) {
+      // Ignore resource loading errors.
+      if (!event.message) return
+      sendError(event.message, event.filename, event.lineno, event.colno)
+    })
+    global[addEventListenerString]("unhandledrejection", function(event) {
+      var reason = event.reason
+      sendError(reason instanceof Error ? reason.name + ": " + reason.message : String(reason))
+    })
+  }
+
+  if (trackWebVitals && global.PerformanceObserver) {
+    collectWebVitals()
+    documentAddEventListener("visibilitychange", function() {
+      if (doc.visibilityState === "hidden") sendWebVitals()
+    })
+  }
+
   if (!manual && (trackOutboundLinks || trackFileDownloads)) {
     documentAddEventListener('click', handleLinkClickEvent)
     documentAddEventListener('auxclick', handleLinkClickEvent)
@@ -149,12 +418,19 @@
       if (trackingDisabled) return;
       options = defaultOptions(options)
 
-      doFetch(prismeUrl.concat("/api/v1/events/custom/", eventName), fetchDefaultOptions({
-        headers: configureHeaders(options, {
-          "Content-Type": "application/json",
-        }),
+      var headers = configureHeaders(options, {
+        "Content-Type": "application/json",
+      })
+      // Revenue is an object with an amount and an ISO 4217 currency code
+      // (e.g. { amount: 49.90, currency: "EUR" }).
+      if (options.revenue) {
+        headers["X-Prisme-Revenue"] = String(options.revenue.amount).concat(" ", options.revenue.currency)
+      }
+
+      sendEvent(prismeUrl.concat("/api/v1/events/custom/", eventName), {
+        headers: headers,
         body: JSON.stringify(properties)
-      }));
+      });
     },
   }
   global.prisme = globalPrisme